@@ -7,6 +7,7 @@ package main
 import (
 	"context"
 	"log"
+	"os"
 	"strings"
 
 	"github.com/urfave/cli/v3"
@@ -29,12 +30,16 @@ func command(config *remoteConsoleConfig) *cli.Command {
 		Description: "OpenCHAMI remote console service",
 		Before: func(ctx context.Context, c *cli.Command) (context.Context, error) {
 			config.SmdURL = ensureTrailingSlash(config.SmdURL)
+			config.Creds.DefaultPassword = os.Getenv("RCS_CREDS_DEFAULT_PASSWORD")
 
 			return ctx, validateConfig(config)
 		},
 		Action: func(context.Context, *cli.Command) error {
 			return runService(*config)
 		},
+		Commands: []*cli.Command{
+			verifyLogsCommand(config),
+		},
 	}
 
 	err := gcli.ParseToV3(config, &cmd.Flags, sflags.EnvPrefix("RCS_"))
@@ -6,6 +6,7 @@ package main
 
 import (
 	"fmt"
+	"net/url"
 	"slices"
 
 	"github.com/OpenCHAMI/remote-console/internal/conman"
@@ -30,6 +31,8 @@ type remoteConsoleConfig struct {
 	SmdURL               string `desc:"URL for the SMD service"`
 	JwksURL              string `desc:"JWKS URL for fetching public keys for JWT validation (optional)"`
 	JwksFetchInterval    int    `desc:"Interval in seconds to retry fetching JWKS on failure"`
+	AdminScope           string `desc:"JWT scope or role required for the session administration endpoints"`
+	AuthzPolicyFile      string `desc:"Path to the per-node authorization policy file (optional)"`
 	Oauth2               OAuth2Config
 }
 
@@ -44,6 +47,8 @@ func DefaultConfig() remoteConsoleConfig {
 		SmdURL:               "http://cray-smd/",
 		JwksURL:              "",
 		JwksFetchInterval:    5,
+		AdminScope:           "admin",
+		AuthzPolicyFile:      "",
 		// Note: Oauth2 vs OAuth2 so the sflags generate the correct flag name
 		Oauth2: OAuth2Config{},
 	}
@@ -55,10 +60,15 @@ func validateCredsConfig(config *remoteConsoleConfig) error {
 	if credConfig.SecureStorageAdapter != "" {
 		_, err := creds.NewStorageAdapter(string(credConfig.SecureStorageAdapter))
 		if err != nil {
-			return fmt.Errorf("invalid secure storage adapter: %s, valid values are (vault or local)", credConfig.SecureStorageAdapter)
+			return fmt.Errorf("invalid secure storage adapter: %s, valid values are (vault, local, file, secrets-dir or env)", credConfig.SecureStorageAdapter)
 		}
 
-		if credConfig.SecureStorageAdapter == creds.StorageAdapterLocal {
+		switch credConfig.SecureStorageAdapter {
+		case creds.StorageAdapterVault:
+			if err := validateVaultAuthConfig(credConfig); err != nil {
+				return err
+			}
+		case creds.StorageAdapterLocal:
 			if credConfig.LocalStoreFilePath == "" {
 				return fmt.Errorf("a local storage path must be set when using the local secure storage adapter")
 			}
@@ -66,9 +76,68 @@ func validateCredsConfig(config *remoteConsoleConfig) error {
 			if credConfig.LocalStoreKey == "" {
 				return fmt.Errorf("a local storage key must be set when using the local secure storage adapter")
 			}
+		case creds.StorageAdapterFile:
+			if credConfig.CredentialsFilePath == "" {
+				return fmt.Errorf("a credentials file path must be set when using the file secure storage adapter")
+			}
+		case creds.StorageAdapterSecretsDir:
+			if credConfig.SecretsDirPath == "" {
+				return fmt.Errorf("a secrets directory path must be set when using the secrets-dir secure storage adapter")
+			}
+		case creds.StorageAdapterEnv:
+			if credConfig.DefaultUsername == "" {
+				return fmt.Errorf("a default username must be set when using the env secure storage adapter")
+			}
 		}
 	}
 
+	if credConfig.DefaultPasswordFile != "" && credConfig.DefaultPassword != "" {
+		return fmt.Errorf("a default password file and RCS_CREDS_DEFAULT_PASSWORD cannot both be set")
+	}
+
+	switch credConfig.HostKeyVerification {
+	case "", creds.HostKeyVerificationOff:
+	case creds.HostKeyVerificationKnownHosts:
+		if credConfig.SecureStorageKnownHostsPath == "" {
+			return fmt.Errorf("a secure storage known hosts path must be set when using known-hosts host key verification")
+		}
+	case creds.HostKeyVerificationTOFU:
+		if credConfig.HostKeyPinsPath == "" {
+			return fmt.Errorf("a host key pins path must be set when using tofu host key verification")
+		}
+	default:
+		return fmt.Errorf("invalid host key verification: %s, valid values are (off, known-hosts or tofu)", credConfig.HostKeyVerification)
+	}
+	if credConfig.HostKeyVerification != "" && credConfig.HostKeyVerification != creds.HostKeyVerificationOff && credConfig.KnownHostsPath == "" {
+		return fmt.Errorf("a known hosts path must be set when verifying host keys")
+	}
+
+	if credConfig.SshCertRenewBefore < 0 {
+		return fmt.Errorf("invalid SSH certificate renew before: %d, it must not be negative", credConfig.SshCertRenewBefore)
+	}
+	if credConfig.SshCertSigningURL != "" {
+		if u, err := url.Parse(credConfig.SshCertSigningURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("invalid SSH certificate signing URL %q", credConfig.SshCertSigningURL)
+		}
+	}
+
+	return nil
+}
+
+func validateVaultAuthConfig(credConfig creds.CredsConfig) error {
+	switch credConfig.VaultAuthMethod {
+	case "", creds.VaultAuthKubernetes, creds.VaultAuthToken:
+	case creds.VaultAuthAppRole:
+		if credConfig.VaultAppRoleID == "" || credConfig.VaultAppRoleSecretIDFile == "" {
+			return fmt.Errorf("a vault approle role ID and secret ID file must be set when using approle vault authentication")
+		}
+	case creds.VaultAuthCert:
+		if credConfig.VaultClientCertFile == "" || credConfig.VaultClientKeyFile == "" {
+			return fmt.Errorf("a vault client certificate and key file must be set when using cert vault authentication")
+		}
+	default:
+		return fmt.Errorf("invalid vault auth method: %s, valid values are (kubernetes, token, approle or cert)", credConfig.VaultAuthMethod)
+	}
 	return nil
 }
 
@@ -76,6 +145,7 @@ func validateConfig(config *remoteConsoleConfig) error {
 	if err := validateCredsConfig(config); err != nil {
 		return err
 	}
+	config.Log.ApplyDeprecated()
 
 	// Validate OAuth2 configuration - either all or nothing
 	oauth2 := config.Oauth2
@@ -21,6 +21,7 @@ import (
 	"github.com/OpenCHAMI/remote-console/internal/console"
 	"github.com/OpenCHAMI/remote-console/internal/creds"
 	"github.com/OpenCHAMI/remote-console/internal/logs"
+	"github.com/OpenCHAMI/remote-console/internal/metrics"
 	"github.com/OpenCHAMI/remote-console/internal/nodes"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/clientcredentials"
@@ -28,9 +29,17 @@ import (
 
 const smdHTTPTimeout = 15 * time.Second
 
+const (
+	// How often SSH certificates are checked for an early renewal
+	certRenewalCheckInterval = 60 * time.Second
+	// Longest wait before retrying a renewal that failed or renewed nothing
+	certRenewalMaxBackoff = 30 * time.Minute
+)
+
 // ConmanService defines the interface for conman service operations
 type ConmanService interface {
-	ConfigureConman(nodes map[string]*nodes.NodeConsoleInfo, passwords map[string]compcreds.CompCredentials, sshConsoleKeyPath string) (bool, error)
+	ConfigureConman(nodes map[string]*nodes.NodeConsoleInfo, passwords map[string]compcreds.CompCredentials, sshConsoleKeyPath string, nodeKeyPaths map[string]string, knownHostsPath string) (bool, error)
+	UpdateConsoles(nodes map[string]*nodes.NodeConsoleInfo, passwords map[string]compcreds.CompCredentials, sshConsoleKeyPath string, nodeKeyPaths map[string]string, knownHostsPath string, changed []string) ([]string, bool, error)
 	ExecuteConman() error
 	SignalConmanTERM() error
 	SignalConmanHUP() error
@@ -40,13 +49,19 @@ type ConmanService interface {
 type CredsService interface {
 	GetPasswordsWithRetries(ctx context.Context, bmcXNames []string, maxTries, waitSecs int) (map[string]compcreds.CompCredentials, error)
 	EnsureConsoleKeysPresent() (bool, error)
-	CheckForUpdates() (bool, error)
+	EnsureNodeKeysPresent(xnames []string) (map[string]string, []string, error)
+	EnsureKnownHosts(consoles map[string]*nodes.NodeConsoleInfo) (string, error)
+	CheckForUpdates() (creds.CredentialChanges, error)
+	CertificateRenewalDue() bool
+	RenewCertificates() ([]string, error)
 }
 
 // LogsService defines the interface for logs service operations
 type LogsService interface {
-	UpdateLogRotateConf(consoleLogsPath string, nodes map[string]*nodes.NodeConsoleInfo) error
-	LogRotate(consoleLogsPath string) bool
+	UpdateRotationTargets(consoleLogsPath string, nodes map[string]*nodes.NodeConsoleInfo)
+	LogRotate(reopenConsoles func() error)
+	EnforceDiskBudget()
+	CheckpointIntegrity()
 	AggregateFiles(consoleLogsPath string, nodes map[string]*nodes.NodeConsoleInfo)
 }
 
@@ -74,11 +89,8 @@ func watchForNodesUpdates(ctx context.Context, config remoteConsoleConfig, httpC
 
 				nodes := nodes.CurrentNodes()
 
-				// also update log rotation configuration
-				slog.Info("Updating log rotation configuration for node changes")
-				if err := logsService.UpdateLogRotateConf(conmanLogsPath, nodes); err != nil {
-					slog.Error("Failed to update log rotation configuration for node changes", "error", err)
-				}
+				// also update the consoles whose logs are rotated
+				logsService.UpdateRotationTargets(conmanLogsPath, nodes)
 
 				// make sure we are aggregating any new console log files
 				slog.Info("Updating log aggregation configuration for node changes")
@@ -88,32 +100,77 @@ func watchForNodesUpdates(ctx context.Context, config remoteConsoleConfig, httpC
 	}
 }
 
-// Watch for credential updates and signal conman as needed
+// Watch for credential updates and reconnect the consoles whose credentials
+// changed, restarting conman when their definitions changed. SSH certificates
+// about to expire are renewed, and applied ahead of the regular check when
+// any were. Renewals that fail or renew nothing are retried with a backoff.
 func watchForCredUpdates(ctx context.Context, config remoteConsoleConfig, credsService CredsService, conmanService ConmanService) {
 	ticker := time.NewTicker(time.Duration(config.CredsMonitorInterval) * time.Second)
 	defer ticker.Stop()
+	certTicker := time.NewTicker(certRenewalCheckInterval)
+	defer certTicker.Stop()
+
+	backoff := certRenewalCheckInterval
+	var nextRenewal time.Time
 
 	for {
 		select {
 		case <-ctx.Done():
 			slog.Info("Exiting credential watch loop due to shutdown")
 			return
-		case <-ticker.C:
-			changed, err := credsService.CheckForUpdates()
+		case <-certTicker.C:
+			if time.Now().Before(nextRenewal) || !credsService.CertificateRenewalDue() {
+				continue
+			}
+			renewed, err := credsService.RenewCertificates()
 			if err != nil {
-				slog.Error("Failed to check for credential updates", "error", err)
+				slog.Error("Failed to renew SSH certificates", "error", err)
 			}
-
-			if changed {
-				slog.Info("Credential changes detected, signaling conman to restart")
-				if err := conmanService.SignalConmanTERM(); err != nil {
-					slog.Error("Failed to signal conman with SIGTERM", "error", err)
-				}
+			if err != nil || len(renewed) == 0 {
+				nextRenewal = time.Now().Add(backoff)
+				slog.Debug("Retrying SSH certificate renewal later", "retryAt", nextRenewal)
+				backoff = min(2*backoff, certRenewalMaxBackoff)
+			} else {
+				backoff = certRenewalCheckInterval
+				nextRenewal = time.Time{}
 			}
+			if len(renewed) == 0 {
+				continue
+			}
+			slog.Info("SSH certificates renewed, applying them early", "keys", renewed)
+			applyCredentialUpdates(config, credsService, conmanService)
+		case <-ticker.C:
+			applyCredentialUpdates(config, credsService, conmanService)
 		}
 	}
 }
 
+// Check for credential updates and apply them to the consoles
+func applyCredentialUpdates(config remoteConsoleConfig, credsService CredsService, conmanService ConmanService) {
+	changes, err := credsService.CheckForUpdates()
+	if err != nil {
+		slog.Error("Failed to check for credential updates", "error", err)
+	}
+	if !changes.Changed() {
+		return
+	}
+
+	currentNodes := nodes.CurrentNodes()
+	consoles := changes.Consoles(currentNodes)
+	reconnected, restart, err := conmanService.UpdateConsoles(currentNodes, changes.Passwords, config.Creds.SshConsoleKeyPath, changes.NodeKeyPaths, changes.KnownHostsPath, consoles)
+	if err != nil {
+		slog.Error("Failed to update consoles for credential changes", "error", err)
+	}
+	if err != nil || restart {
+		slog.Info("Credential changes detected, signaling conman to restart", "consoles", consoles)
+		if err := conmanService.SignalConmanTERM(); err != nil {
+			slog.Error("Failed to signal conman with SIGTERM", "error", err)
+		}
+		return
+	}
+	slog.Info("Credential changes detected, reconnected consoles", "consoles", consoles, "reconnected", reconnected)
+}
+
 // Log rotation setup and loop
 func logRotate(ctx context.Context, config remoteConsoleConfig, conmanService ConmanService, logsService LogsService) {
 	logConfig := config.Log
@@ -122,17 +179,17 @@ func logRotate(ctx context.Context, config remoteConsoleConfig, conmanService Co
 		"enabled", logConfig.LogRotateEnabled,
 		"checkFrequencySec", logConfig.LogRotateCheckFrequency,
 		"consoleFileSize", logConfig.ConsoleLogsFileSize,
+		"consoleMaxAgeHours", logConfig.ConsoleLogsMaxAgeHours,
 		"consoleNumRotate", logConfig.ConsoleLogsNumRotate,
+		"consoleCompression", logConfig.ConsoleLogsCompression,
 		"aggFileSize", logConfig.AggLogsFileSize,
 		"aggNumRotate", logConfig.AggLogsNumRotate)
 
 	// conman will add the conman directory, so we point the logs service their
 	conmanLogsPath := filepath.Join(config.Conman.LogsPath, "conman")
 
-	// Create the log rotation configuration file
-	if err := logsService.UpdateLogRotateConf(conmanLogsPath, nodes.CurrentNodes()); err != nil {
-		slog.Error("Failed to update log rotation configuration", "error", err)
-	}
+	logsService.UpdateRotationTargets(conmanLogsPath, nodes.CurrentNodes())
+	logsService.EnforceDiskBudget()
 
 	sleepDuration := 300 * time.Second
 	logRotCheckFreqSec := logConfig.LogRotateCheckFrequency
@@ -151,13 +208,35 @@ func logRotate(ctx context.Context, config remoteConsoleConfig, conmanService Co
 			slog.Info("Exiting log rotation loop due to shutdown")
 			return
 		case <-ticker.C:
-			restartConman := logsService.LogRotate(conmanLogsPath)
-			if restartConman {
-				slog.Info("Log files rotated, signaling conmand")
-				if err := conmanService.SignalConmanHUP(); err != nil {
-					slog.Error("Failed to signal conman with SIGHUP", "error", err)
-				}
-			}
+			logsService.LogRotate(conmanService.SignalConmanHUP)
+			logsService.EnforceDiskBudget()
+		}
+	}
+}
+
+// Checkpoint the console log hash chains when integrity mode is enabled
+func integrityCheckpoints(ctx context.Context, config remoteConsoleConfig, logsService LogsService) {
+	if !config.Log.IntegrityEnabled {
+		return
+	}
+
+	checkFreqSec := config.Log.IntegrityCheckFrequency
+	if checkFreqSec <= 0 {
+		slog.Warn("Integrity checkpoint frequency invalid, defaulting to 10 sec", "inputValue", checkFreqSec)
+		checkFreqSec = 10
+	}
+
+	ticker := time.NewTicker(time.Duration(checkFreqSec) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Exiting integrity checkpoint loop due to shutdown")
+			logsService.CheckpointIntegrity()
+			return
+		case <-ticker.C:
+			logsService.CheckpointIntegrity()
 		}
 	}
 }
@@ -197,7 +276,17 @@ func runConman(ctx context.Context, config remoteConsoleConfig, conmanService Co
 			}
 		}
 
-		hasNodes, err := conmanService.ConfigureConman(currentNodes, passwords, config.Creds.SshConsoleKeyPath)
+		nodeKeyPaths, _, err := credService.EnsureNodeKeysPresent(requireCredentials)
+		if err != nil {
+			slog.Warn("Failed to ensure per-node SSH keys present", "error", err)
+		}
+
+		knownHostsPath, err := credService.EnsureKnownHosts(currentNodes)
+		if err != nil {
+			slog.Warn("Failed to ensure SSH host keys known", "error", err)
+		}
+
+		hasNodes, err := conmanService.ConfigureConman(currentNodes, passwords, config.Creds.SshConsoleKeyPath, nodeKeyPaths, knownHostsPath)
 		if err != nil {
 			slog.Error("Failed to configure conman", "error", err)
 			if waitWithContext(5 * time.Second) {
@@ -246,8 +335,10 @@ func runService(config remoteConsoleConfig) error {
 	if err != nil {
 		return fmt.Errorf("failed to initialize logs service: %w", err)
 	}
-	// Initialize aggregation log early so it is present in the first logrotate config.
+	// Initialize aggregation log early so it is rotated from the first check.
 	logsService.EnsureAggLog()
+	metrics.Register(logsService.Metrics)
+	metrics.Register(credsService.Metrics)
 
 	if _, err := credsService.EnsureConsoleKeysPresent(); err != nil {
 		slog.Warn("Failed to ensure console SSH keys present", "error", err)
@@ -291,6 +382,12 @@ func runService(config remoteConsoleConfig) error {
 	// goroutine for log rotation
 	go logRotate(serviceCtx, config, conmanService, logsService)
 
+	// goroutine for the console watch webhooks and event log
+	go logsService.RunWatches(serviceCtx)
+
+	// goroutine for console log hash chain checkpoints
+	go integrityCheckpoints(serviceCtx, config, logsService)
+
 	// goroutine to watches for changes in console configuration
 	go watchForNodesUpdates(serviceCtx, config, smdHTTPClient, conmanService, logsService)
 
@@ -335,6 +432,16 @@ func runService(config remoteConsoleConfig) error {
 		slog.Warn("No JWKS URL provided - JWT authentication is disabled")
 	}
 
+	// Load the per-node authorization policy if one is provided
+	if config.AuthzPolicyFile != "" {
+		if err := console.LoadAuthzPolicy(config.AuthzPolicyFile); err != nil {
+			serviceStopCtx()
+			return err
+		}
+	} else {
+		slog.Info("No authorization policy provided - any authenticated user may access every console")
+	}
+
 	// Setup a channel to wait for the os to tell us to stop.
 	// NOTE - This must be set up before initializing anything that needs
 	//  to be cleaned up.  This will trap any signals and wait to
@@ -342,7 +449,8 @@ func runService(config remoteConsoleConfig) error {
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
 
-	router := console.SetupRoutes(conmanLogsPath)
+	console.AdminScope = config.AdminScope
+	router := console.SetupRoutes(conmanLogsPath, logsService, credsService)
 
 	slog.Info("Starting HTTP server", "address", config.HttpListen)
 	server := &http.Server{Addr: config.HttpListen, Handler: router}
@@ -383,6 +491,11 @@ func runService(config remoteConsoleConfig) error {
 			slog.Error("Failed to shutdown HTTP server gracefully", "error", err)
 			os.Exit(1)
 		}
+
+		// Send the aggregated output still queued for the sinks
+		if err := logsService.CloseSinks(shutdownCtx); err != nil {
+			slog.Error("Failed to close aggregation log sinks", "error", err)
+		}
 		serverStopCtx()
 	}()
 
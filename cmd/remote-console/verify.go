@@ -0,0 +1,94 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// This file contains the verify-logs command checking the console logs
+// against their hash chains
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/OpenCHAMI/remote-console/internal/logs"
+)
+
+func verifyLogsCommand(config *remoteConsoleConfig) *cli.Command {
+	return &cli.Command{
+		Name:      "verify-logs",
+		Usage:     "verify console logs against their hash chains",
+		ArgsUsage: "[xname...]",
+		Description: "Checks the live and rotated console logs of the given nodes, or of every node " +
+			"with a hash chain, and reports any gap or modification. Exits with status 1 when a " +
+			"log fails verification.",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "json", Usage: "print the reports as JSON"},
+		},
+		Action: func(ctx context.Context, c *cli.Command) error {
+			return verifyLogs(*config, c.Args().Slice(), c.Bool("json"), c.Root().Writer)
+		},
+	}
+}
+
+func verifyLogs(config remoteConsoleConfig, xnames []string, asJSON bool, w io.Writer) error {
+	key, err := logs.LoadIntegrityKey(config.Log.IntegrityKeyFile)
+	if err != nil {
+		return err
+	}
+	if len(xnames) == 0 {
+		if xnames, err = logs.IntegrityNodes(config.Log.IntegrityPath); err != nil {
+			return err
+		}
+	}
+
+	consoleLogsPath := filepath.Join(config.Conman.LogsPath, "conman")
+	reports := []logs.IntegrityReport{}
+	failed := 0
+	for _, xname := range xnames {
+		report, err := logs.VerifyConsoleLog(consoleLogsPath, config.Log.ConsoleLogsBackupPath, config.Log.IntegrityPath, key, xname)
+		if err != nil {
+			return fmt.Errorf("failed to verify console logs of %s: %w", xname, err)
+		}
+		if !report.Verified {
+			failed++
+		}
+		reports = append(reports, report)
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(reports); err != nil {
+			return err
+		}
+	} else {
+		for _, report := range reports {
+			printIntegrityReport(w, report)
+		}
+	}
+
+	if failed > 0 {
+		return cli.Exit(fmt.Sprintf("console logs of %d of %d nodes failed verification", failed, len(reports)), 1)
+	}
+	return nil
+}
+
+func printIntegrityReport(w io.Writer, report logs.IntegrityReport) {
+	result := "verified"
+	if !report.Verified {
+		result = "FAILED"
+	}
+	_, _ = fmt.Fprintf(w, "%s: %s (%d records, %d segments, head %s)\n", report.Node, result, report.Records, len(report.Segments), report.Head)
+	for _, p := range report.Problems {
+		_, _ = fmt.Fprintf(w, "  %s: segment %d %s: %s\n", p.Kind, p.Segment, p.File, p.Detail)
+	}
+	for _, name := range report.Unverified {
+		_, _ = fmt.Fprintf(w, "  unverified: %s predates the hash chain\n", name)
+	}
+}
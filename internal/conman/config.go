@@ -10,6 +10,7 @@ type ConmanConfig struct {
 	LogsPath           string `desc:"Path to conman log files."`
 	PidFilePath        string `desc:"Path to the conman PID file."`
 	ConsoleScriptsPath string `desc:"Path to console helper scripts."`
+	PasswordsDir       string `desc:"Directory where the credentials of each console are written for the console helpers. When empty the credentials are in the conman configuration."`
 }
 
 func DefaultConmanConfig() ConmanConfig {
@@ -19,5 +20,6 @@ func DefaultConmanConfig() ConmanConfig {
 		LogsPath:           "/var/log/conman",
 		PidFilePath:        "/var/run/conman.pid",
 		ConsoleScriptsPath: "/usr/bin",
+		PasswordsDir:       "",
 	}
 }
@@ -16,6 +16,7 @@ import (
 	"log/slog"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
@@ -29,9 +30,16 @@ import (
 )
 
 type ConmanService struct {
-	config  ConmanConfig
-	mutex   sync.Mutex
-	command *exec.Cmd
+	config   ConmanConfig
+	mutex    sync.Mutex
+	command  *exec.Cmd
+	consoles map[string]consoleEntry // the consoles in the last configuration written
+}
+
+// consoleEntry is a console's line in the conman configuration
+type consoleEntry struct {
+	line    string
+	devArgs string // the helper command line of SSH consoles
 }
 
 func NewConmanService(config ConmanConfig) *ConmanService {
@@ -42,11 +50,15 @@ func NewConmanService(config ConmanConfig) *ConmanService {
 	}
 }
 
-func (cs *ConmanService) ConfigureConman(nodeMap map[string]*nodes.NodeConsoleInfo, passwords map[string]compcredentials.CompCredentials, sshConsoleKeyPath string) (bool, error) {
+// ConfigureConman writes the conman configuration for the nodes. SSH consoles
+// use the key file in nodeKeyPaths for their node, or else the shared key at
+// sshConsoleKeyPath. When knownHostsPath is set SSH consoles only connect to
+// BMCs whose host key is in that file.
+func (cs *ConmanService) ConfigureConman(nodeMap map[string]*nodes.NodeConsoleInfo, passwords map[string]compcredentials.CompCredentials, sshConsoleKeyPath string, nodeKeyPaths map[string]string, knownHostsPath string) (bool, error) {
 	cs.mutex.Lock()
 	defer cs.mutex.Unlock()
 
-	return cs.updateConfigFile(nodeMap, passwords, sshConsoleKeyPath, true)
+	return cs.updateConfigFile(nodeMap, passwords, sshConsoleKeyPath, nodeKeyPaths, knownHostsPath, true)
 }
 
 func generateBaseConfig(config ConmanConfig) ([]byte, error) {
@@ -95,23 +107,48 @@ func willUpdateConfig(baseConfig []byte) bool {
 	return value != 'F' && value != 'f'
 }
 
-func generateIPMIConsoleConfig(nci *nodes.NodeConsoleInfo, creds compcredentials.CompCredentials) string {
+// generateIPMIConsoleConfig returns the console entry of an IPMI console.
+// When credentialsFile is set the console runs through the ipmi-console
+// helper, which reads the credentials from it, rather than conman's own IPMI
+// support with the credentials in the configuration.
+func (cs *ConmanService) generateIPMIConsoleConfig(nci *nodes.NodeConsoleInfo, creds compcredentials.CompCredentials, credentialsFile string) consoleEntry {
 	slog.Debug("Configuring IPMI console", "nodeID", nci.ID, "host", nci.ConnectionHost, "username", creds.Username)
-	return fmt.Sprintf("console name=\"%s\" dev=\"ipmi:%s\" ipmiopts=\"U:%s,P:%s,W:solpayloadsize\"\n",
-		nci.ID, nci.ConnectionHost, creds.Username, creds.Password)
+	if credentialsFile != "" {
+		devArgs := fmt.Sprintf("%s/ipmi-console -c %s %s", cs.config.ConsoleScriptsPath, credentialsFile, nci.ConnectionHost)
+		return consoleEntry{line: fmt.Sprintf("console name=\"%s\" dev=\"%s\"\n", nci.ID, devArgs), devArgs: devArgs}
+	}
+	return consoleEntry{line: fmt.Sprintf("console name=\"%s\" dev=\"ipmi:%s\" ipmiopts=\"U:%s,P:%s,W:solpayloadsize\"\n",
+		nci.ID, nci.ConnectionHost, creds.Username, creds.Password)}
 }
 
-func (cs *ConmanService) generateSSHConsoleConfig(nci *nodes.NodeConsoleInfo, creds compcredentials.CompCredentials, sshConsoleKeyPath string) string {
+// generateSSHConsoleConfig returns the console entry of an SSH console. When
+// credentialsFile is set the helper reads the username and the password or
+// key path from it rather than having them on its command line.
+func (cs *ConmanService) generateSSHConsoleConfig(nci *nodes.NodeConsoleInfo, creds compcredentials.CompCredentials, sshConsoleKeyPath, knownHostsPath, credentialsFile string) consoleEntry {
 	var devArgs string
 
+	// The helpers verify the host key when given a known_hosts file
+	hostKeyArgs := ""
+	if knownHostsPath != "" {
+		hostKeyArgs = fmt.Sprintf(" -k %s", knownHostsPath)
+	}
+
 	// If we have password creds, use those, otherwise use key-based.
 	if creds.Password != "" {
 		slog.Debug("Configuring SSH console with password", "nodeID", nci.ID, "host", nci.ConnectionHost, "port", nci.ConnectionPort, "username", creds.Username, "entryCmd", nci.ConsoleEntryCommand)
-		devArgs = fmt.Sprintf("%s/ssh-pwd-console %s %d %s %s", cs.config.ConsoleScriptsPath, nci.ConnectionHost, nci.ConnectionPort, creds.Username, creds.Password)
+		if credentialsFile != "" {
+			devArgs = fmt.Sprintf("%s/ssh-pwd-console%s -c %s %s %d", cs.config.ConsoleScriptsPath, hostKeyArgs, credentialsFile, nci.ConnectionHost, nci.ConnectionPort)
+		} else {
+			devArgs = fmt.Sprintf("%s/ssh-pwd-console%s %s %d %s %s", cs.config.ConsoleScriptsPath, hostKeyArgs, nci.ConnectionHost, nci.ConnectionPort, creds.Username, creds.Password)
+		}
 	} else {
 		// Key based auth, note that we still use the username from the secure store.
 		slog.Debug("Configuring SSH console with key", "nodeID", nci.ID, "host", nci.ConnectionHost, "port", nci.ConnectionPort, "username", creds.Username, "keyPath", sshConsoleKeyPath, "entryCmd", nci.ConsoleEntryCommand)
-		devArgs = fmt.Sprintf("%s/ssh-key-console %s %d %s %s", cs.config.ConsoleScriptsPath, nci.ConnectionHost, nci.ConnectionPort, creds.Username, sshConsoleKeyPath)
+		if credentialsFile != "" {
+			devArgs = fmt.Sprintf("%s/ssh-key-console%s -c %s %s %d", cs.config.ConsoleScriptsPath, hostKeyArgs, credentialsFile, nci.ConnectionHost, nci.ConnectionPort)
+		} else {
+			devArgs = fmt.Sprintf("%s/ssh-key-console%s %s %d %s %s", cs.config.ConsoleScriptsPath, hostKeyArgs, nci.ConnectionHost, nci.ConnectionPort, creds.Username, sshConsoleKeyPath)
+		}
 	}
 
 	if nci.ConsoleEntryCommand != "" {
@@ -120,10 +157,10 @@ func (cs *ConmanService) generateSSHConsoleConfig(nci *nodes.NodeConsoleInfo, cr
 		devArgs = fmt.Sprintf("%s %s", devArgs, base64EncodedCmd)
 	}
 
-	return fmt.Sprintf("console name=\"%s\" dev=\"%s\"\n", nci.ID, devArgs)
+	return consoleEntry{line: fmt.Sprintf("console name=\"%s\" dev=\"%s\"\n", nci.ID, devArgs), devArgs: devArgs}
 }
 
-func (cs *ConmanService) updateConfigFile(nodeMap map[string]*nodes.NodeConsoleInfo, passwords map[string]compcredentials.CompCredentials, sshConsoleKeyPath string, forceUpdate bool) (bool, error) {
+func (cs *ConmanService) updateConfigFile(nodeMap map[string]*nodes.NodeConsoleInfo, passwords map[string]compcredentials.CompCredentials, sshConsoleKeyPath string, nodeKeyPaths map[string]string, knownHostsPath string, forceUpdate bool) (bool, error) {
 	slog.Info("Updating conman configuration file")
 
 	bs, err := generateBaseConfig(cs.config)
@@ -154,7 +191,8 @@ func (cs *ConmanService) updateConfigFile(nodeMap map[string]*nodes.NodeConsoleI
 
 	slog.Info("Populating conman configuration with nodes", "nodeCount", len(nodeMap))
 
-	consoles := make([]string, 0, len(nodeMap))
+	entries := make(map[string]consoleEntry, len(nodeMap))
+	credentialFiles := make(map[string]string)
 
 	for _, nci := range nodeMap {
 		creds, ok := passwords[nci.ID]
@@ -162,26 +200,53 @@ func (cs *ConmanService) updateConfigFile(nodeMap map[string]*nodes.NodeConsoleI
 			slog.Warn("No credentials found for node", "nodeID", nci.ID)
 		}
 
+		// The helpers read the credentials from a file of their own when
+		// there is a directory for them
+		credentialsFile := ""
+		if cs.config.PasswordsDir != "" {
+			credentialsFile = filepath.Join(cs.config.PasswordsDir, nci.ID)
+		}
+
 		switch nci.ConnectionType {
 		// IPMI connection
 		case nodes.IPMI:
-			output := generateIPMIConsoleConfig(nci, creds)
-			consoles = append(consoles, output)
+			entries[nci.ID] = cs.generateIPMIConsoleConfig(nci, creds, credentialsFile)
+			if credentialsFile != "" {
+				credentialFiles[nci.ID] = credentialsFileContent(creds.Username, creds.Password, "")
+			}
 
 		// SSH connection
 		case nodes.SSH:
-			output := cs.generateSSHConsoleConfig(nci, creds, sshConsoleKeyPath)
-			consoles = append(consoles, output)
+			keyPath := sshConsoleKeyPath
+			if nodeKeyPath, ok := nodeKeyPaths[nci.ID]; ok {
+				keyPath = nodeKeyPath
+			}
+			entries[nci.ID] = cs.generateSSHConsoleConfig(nci, creds, keyPath, knownHostsPath, credentialsFile)
+			if credentialsFile != "" {
+				credentialFiles[nci.ID] = credentialsFileContent(creds.Username, creds.Password, keyPath)
+			}
+		}
+	}
+
+	// The credential files are in place before conman starts the helpers
+	if cs.config.PasswordsDir != "" {
+		if err := writeCredentialFiles(cs.config.PasswordsDir, credentialFiles); err != nil {
+			return false, err
 		}
 	}
 
 	// Sort consoles for consistent output
+	consoles := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		consoles = append(consoles, entry.line)
+	}
 	sort.Strings(consoles)
 	for _, output := range consoles {
 		if _, err = cf.WriteString(output); err != nil {
 			return false, fmt.Errorf("unable to write console entry into file: %w", err)
 		}
 	}
+	cs.consoles = entries
 
 	return len(nodeMap) > 0, nil
 }
@@ -6,9 +6,11 @@ package conman
 
 import (
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/Cray-HPE/hms-compcredentials"
 	"github.com/stretchr/testify/require"
@@ -53,6 +55,7 @@ func TestConfigureConman(t *testing.T) {
 	config.ConfFilePath = filepath.Join(tempDir, "conman.conf")
 	config.LogsPath = filepath.Join(tempDir, "logs")
 	config.PidFilePath = filepath.Join(tempDir, "conman.pid")
+	config.PasswordsDir = filepath.Join(tempDir, "passwords")
 
 	nodes := map[string]*nodes.NodeConsoleInfo{
 		"x0c0s1b0": {
@@ -71,6 +74,11 @@ func TestConfigureConman(t *testing.T) {
 			ConnectionType: nodes.SSH,
 			ConnectionHost: "x0c0s3b0",
 		},
+		"x0c0s4b0": {
+			ID:             "x0c0s4b0",
+			ConnectionType: nodes.SSH,
+			ConnectionHost: "x0c0s4b0",
+		},
 	}
 
 	passwords := map[string]compcredentials.CompCredentials{
@@ -86,11 +94,15 @@ func TestConfigureConman(t *testing.T) {
 			Username: "admin",
 			Password: "password3",
 		},
+		"x0c0s4b0": {
+			Username: "admin",
+		},
 	}
 	service := NewConmanService(config)
 
 	// First call should create the config file
-	updated, err := service.ConfigureConman(nodes, passwords, "/tmp/ssh_console_key")
+	nodeKeyPaths := map[string]string{"x0c0s4b0": "/tmp/conman-keys/x0c0s4b0"}
+	updated, err := service.ConfigureConman(nodes, passwords, "/tmp/ssh_console_key", nodeKeyPaths, "")
 	require.NoError(t, err)
 	require.True(t, updated)
 
@@ -110,13 +122,179 @@ SERVER timestamp=1h
 GLOBAL seropts="115200,8n1"
 GLOBAL log="conman/console.%N"
 GLOBAL logopts="sanitize,timestamp"
-console name="x0c0s1b0" dev="ipmi:x0c0s1b0" ipmiopts="U:admin,P:password1,W:solpayloadsize"
-console name="x0c0s2b0" dev="/usr/bin/ssh-key-console x0c0s2b0 2222 admin /tmp/ssh_console_key"
-console name="x0c0s3b0" dev="/usr/bin/ssh-pwd-console x0c0s3b0 0 admin password3"
+console name="x0c0s1b0" dev="/usr/bin/ipmi-console -c /passwords/x0c0s1b0 x0c0s1b0"
+console name="x0c0s2b0" dev="/usr/bin/ssh-key-console -c /passwords/x0c0s2b0 x0c0s2b0 2222"
+console name="x0c0s3b0" dev="/usr/bin/ssh-pwd-console -c /passwords/x0c0s3b0 x0c0s3b0 0"
+console name="x0c0s4b0" dev="/usr/bin/ssh-key-console -c /passwords/x0c0s4b0 x0c0s4b0 0"
 `
 	// Remove temporary directory path from generated config for comparison
 	generatedConfigStr := string(generatedConfig)
 	generatedConfigStr = strings.ReplaceAll(generatedConfigStr, tempDir, "")
 
 	require.Equal(t, expected, generatedConfigStr)
+
+	// The credentials are only in each console's own file
+	for xname, content := range map[string]string{
+		"x0c0s1b0": "username=admin\npassword=password1\n",
+		"x0c0s2b0": "username=admin\nkey=/tmp/ssh_console_key\n",
+		"x0c0s3b0": "username=admin\npassword=password3\n",
+		"x0c0s4b0": "username=admin\nkey=/tmp/conman-keys/x0c0s4b0\n",
+	} {
+		credentials, err := os.ReadFile(filepath.Join(config.PasswordsDir, xname))
+		require.NoError(t, err)
+		require.Equal(t, content, string(credentials))
+	}
+	info, err := os.Stat(filepath.Join(config.PasswordsDir, "x0c0s3b0"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	files, err := os.ReadDir(config.PasswordsDir)
+	require.NoError(t, err)
+	require.Len(t, files, 4)
+}
+
+func TestConfigureConmanKnownHosts(t *testing.T) {
+	tempDir := t.TempDir()
+
+	config := DefaultConmanConfig()
+	config.BaseConfFilePath = "../../scripts/conman.conf.tmpl"
+	config.ConfFilePath = filepath.Join(tempDir, "conman.conf")
+	config.PasswordsDir = ""
+
+	nodeMap := map[string]*nodes.NodeConsoleInfo{
+		"x0c0s1b0": {ID: "x0c0s1b0", ConnectionType: nodes.IPMI, ConnectionHost: "x0c0s1b0"},
+		"x0c0s2b0": {ID: "x0c0s2b0", ConnectionType: nodes.SSH, ConnectionHost: "x0c0s2b0"},
+		"x0c0s3b0": {ID: "x0c0s3b0", ConnectionType: nodes.SSH, ConnectionHost: "x0c0s3b0"},
+	}
+	passwords := map[string]compcredentials.CompCredentials{
+		"x0c0s1b0": {Username: "admin", Password: "password1"},
+		"x0c0s2b0": {Username: "admin"},
+		"x0c0s3b0": {Username: "admin", Password: "password3"},
+	}
+
+	// SSH consoles are given the known_hosts file, IPMI consoles are left alone
+	_, err := NewConmanService(config).ConfigureConman(nodeMap, passwords, "/tmp/ssh_console_key", nil, "/app/conman.known_hosts")
+	require.NoError(t, err)
+	generatedConfig, err := os.ReadFile(config.ConfFilePath)
+	require.NoError(t, err)
+	require.Contains(t, string(generatedConfig), `console name="x0c0s1b0" dev="ipmi:x0c0s1b0" ipmiopts="U:admin,P:password1,W:solpayloadsize"`)
+	require.Contains(t, string(generatedConfig), `console name="x0c0s2b0" dev="/usr/bin/ssh-key-console -k /app/conman.known_hosts x0c0s2b0 0 admin /tmp/ssh_console_key"`)
+	require.Contains(t, string(generatedConfig), `console name="x0c0s3b0" dev="/usr/bin/ssh-pwd-console -k /app/conman.known_hosts x0c0s3b0 0 admin password3"`)
+}
+
+// startHelper runs a stand-in for a console helper conmand would start,
+// returning a channel closed once it has exited
+func startHelper(t *testing.T, path string, args ...string) <-chan struct{} {
+	cmd := exec.Command(path, args...)
+	require.NoError(t, cmd.Start())
+	exited := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		close(exited)
+	}()
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+	})
+	return exited
+}
+
+func requireStopped(t *testing.T, exited <-chan struct{}) {
+	select {
+	case <-exited:
+	case <-time.After(10 * time.Second):
+		require.Fail(t, "console helper wasn't stopped")
+	}
+}
+
+func TestUpdateConsoles(t *testing.T) {
+	tempDir := t.TempDir()
+
+	config := DefaultConmanConfig()
+	config.BaseConfFilePath = "../../scripts/conman.conf.tmpl"
+	config.ConfFilePath = filepath.Join(tempDir, "conman.conf")
+	config.ConsoleScriptsPath = tempDir
+	config.PasswordsDir = filepath.Join(tempDir, "passwords")
+
+	// Stand-ins for the helpers conmand would run
+	for _, name := range []string{"ssh-pwd-console", "ipmi-console"} {
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, name), []byte("#!/bin/sh\nwhile true; do sleep 1; done\n"), 0700))
+	}
+
+	nodeMap := map[string]*nodes.NodeConsoleInfo{
+		"x0c0s1b0": {ID: "x0c0s1b0", ConnectionType: nodes.IPMI, ConnectionHost: "x0c0s1b0"},
+		"x0c0s2b0": {ID: "x0c0s2b0", ConnectionType: nodes.SSH, ConnectionHost: "x0c0s2b0"},
+		"x0c0s3b0": {ID: "x0c0s3b0", ConnectionType: nodes.SSH, ConnectionHost: "x0c0s3b0"},
+	}
+	passwords := map[string]compcredentials.CompCredentials{
+		"x0c0s1b0": {Username: "admin", Password: "password1"},
+		"x0c0s2b0": {Username: "admin", Password: "password2"},
+		"x0c0s3b0": {Username: "admin", Password: "password3"},
+	}
+	service := NewConmanService(config)
+
+	// Nothing is known of the running consoles before conman is configured
+	_, restart, err := service.UpdateConsoles(nodeMap, passwords, "", nil, "", []string{"x0c0s2b0"})
+	require.NoError(t, err)
+	require.True(t, restart)
+
+	_, err = service.ConfigureConman(nodeMap, passwords, "", nil, "")
+	require.NoError(t, err)
+	// The helpers are children of the test, standing in for conmand
+	service.command = &exec.Cmd{Process: &os.Process{Pid: os.Getpid()}}
+	ipmiFile := filepath.Join(config.PasswordsDir, "x0c0s1b0")
+	sshFile := filepath.Join(config.PasswordsDir, "x0c0s2b0")
+	ipmiExited := startHelper(t, filepath.Join(tempDir, "ipmi-console"), "-c", ipmiFile, "x0c0s1b0")
+	sshExited := startHelper(t, filepath.Join(tempDir, "ssh-pwd-console"), "-c", sshFile, "x0c0s2b0", "0")
+
+	// A new SSH username and password only reconnect their console
+	passwords["x0c0s2b0"] = compcredentials.CompCredentials{Username: "root", Password: "changed2"}
+	reconnected, restart, err := service.UpdateConsoles(nodeMap, passwords, "", nil, "", []string{"x0c0s2b0"})
+	require.NoError(t, err)
+	require.False(t, restart)
+	require.Equal(t, []string{"x0c0s2b0"}, reconnected)
+	requireStopped(t, sshExited)
+	credentials, err := os.ReadFile(sshFile)
+	require.NoError(t, err)
+	require.Equal(t, "username=root\npassword=changed2\n", string(credentials))
+
+	// As does a new IPMI password
+	passwords["x0c0s1b0"] = compcredentials.CompCredentials{Username: "admin", Password: "changed1"}
+	reconnected, restart, err = service.UpdateConsoles(nodeMap, passwords, "", nil, "", []string{"x0c0s1b0"})
+	require.NoError(t, err)
+	require.False(t, restart)
+	require.Equal(t, []string{"x0c0s1b0"}, reconnected)
+	requireStopped(t, ipmiExited)
+	credentials, err = os.ReadFile(ipmiFile)
+	require.NoError(t, err)
+	require.Equal(t, "username=admin\npassword=changed1\n", string(credentials))
+
+	// A console changing from a password to a key uses another helper, so conmand must restart
+	passwords["x0c0s3b0"] = compcredentials.CompCredentials{Username: "admin"}
+	_, restart, err = service.UpdateConsoles(nodeMap, passwords, "/tmp/ssh_console_key", nil, "", []string{"x0c0s3b0"})
+	require.NoError(t, err)
+	require.True(t, restart)
+	credentials, err = os.ReadFile(filepath.Join(config.PasswordsDir, "x0c0s3b0"))
+	require.NoError(t, err)
+	require.Equal(t, "username=admin\nkey=/tmp/ssh_console_key\n", string(credentials))
+
+	// Without a passwords directory an IPMI password is in the console definition
+	config.PasswordsDir = ""
+	service = NewConmanService(config)
+	_, err = service.ConfigureConman(nodeMap, passwords, "", nil, "")
+	require.NoError(t, err)
+	passwords["x0c0s1b0"] = compcredentials.CompCredentials{Username: "admin", Password: "changed again"}
+	_, restart, err = service.UpdateConsoles(nodeMap, passwords, "", nil, "", []string{"x0c0s1b0"})
+	require.NoError(t, err)
+	require.True(t, restart)
+}
+
+func TestIsHelperCommand(t *testing.T) {
+	devArgs := "/usr/bin/ssh-pwd-console -c /app/conman-passwords/x0c0s2b0 x0c0s2b0 0"
+	require.True(t, isHelperCommand(devArgs, devArgs))
+	require.True(t, isHelperCommand("/usr/bin/expect -- "+devArgs, devArgs))
+	require.True(t, isHelperCommand("/bin/sh "+devArgs, devArgs))
+
+	// Other processes merely ending with the arguments are left alone
+	require.False(t, isHelperCommand("/bin/sh -c "+devArgs, devArgs))
+	require.False(t, isHelperCommand("/usr/bin/tail -f "+devArgs, devArgs))
+	require.False(t, isHelperCommand("/usr/bin/expect -- /usr/bin/ssh-pwd-console -c /app/conman-passwords/x0c0s2b0 x0c0s2b0 0 extra", devArgs))
 }
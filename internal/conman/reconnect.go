@@ -0,0 +1,204 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// This file contains the code to apply credential changes to the running
+// consoles without restarting conmand
+
+package conman
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/Cray-HPE/hms-compcredentials"
+
+	"github.com/OpenCHAMI/remote-console/internal/nodes"
+)
+
+// credentialsFileContent returns the credentials file read by a console
+// helper, as name=value lines: the username and the password, or else the
+// key path
+func credentialsFileContent(username, password, keyPath string) string {
+	content := "username=" + username + "\n"
+	if password != "" {
+		return content + "password=" + password + "\n"
+	}
+	if keyPath != "" {
+		content += "key=" + keyPath + "\n"
+	}
+	return content
+}
+
+// writeCredentialFiles writes the credentials of each console to a file of
+// its own, leaving the files that are current alone and removing those of
+// consoles that are gone
+func writeCredentialFiles(dir string, credentials map[string]string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("unable to create passwords directory: %w", err)
+	}
+
+	for xname, content := range credentials {
+		path := filepath.Join(dir, xname)
+		if current, err := os.ReadFile(path); err == nil && string(current) == content {
+			continue
+		}
+		if err := writeCredentialsFile(path, content); err != nil {
+			return fmt.Errorf("unable to write credentials file of %s: %w", xname, err)
+		}
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("unable to read passwords directory: %w", err)
+	}
+	for _, file := range files {
+		if _, ok := credentials[file.Name()]; ok {
+			continue
+		}
+		path := filepath.Join(dir, file.Name())
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("Failed to remove stale credentials file", "path", path, "error", err)
+		}
+	}
+	return nil
+}
+
+// writeCredentialsFile replaces a credentials file, so a helper starting
+// meanwhile reads the old or the new credentials whole
+func writeCredentialsFile(path, content string) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	}()
+	if _, err := f.WriteString(content); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// UpdateConsoles applies credential changes to the consoles in changed. The
+// configuration is written again, and when only the files the console
+// helpers read changed their helpers are stopped so conmand reconnects them
+// with the new credentials. With a passwords directory every console reads
+// its credentials from a file. It returns the consoles reconnected, and true
+// when conmand must instead be restarted because console definitions changed.
+func (cs *ConmanService) UpdateConsoles(nodeMap map[string]*nodes.NodeConsoleInfo, passwords map[string]compcredentials.CompCredentials, sshConsoleKeyPath string, nodeKeyPaths map[string]string, knownHostsPath string, changed []string) ([]string, bool, error) {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+
+	// Without the running configuration or the credentials only a restart helps
+	previous := cs.consoles
+	if previous == nil || passwords == nil {
+		return nil, true, nil
+	}
+
+	if _, err := cs.updateConfigFile(nodeMap, passwords, sshConsoleKeyPath, nodeKeyPaths, knownHostsPath, true); err != nil {
+		return nil, true, err
+	}
+
+	if len(cs.consoles) != len(previous) {
+		return nil, true, nil
+	}
+	for xname, entry := range cs.consoles {
+		if previousEntry, ok := previous[xname]; !ok || previousEntry.line != entry.line {
+			slog.Info("Console definition changed", "xname", xname)
+			return nil, true, nil
+		}
+	}
+
+	var helpers []string
+	for _, xname := range changed {
+		if entry, ok := cs.consoles[xname]; ok && entry.devArgs != "" {
+			helpers = append(helpers, xname)
+		}
+	}
+	if len(helpers) == 0 {
+		return nil, false, nil
+	}
+
+	// Without conmand running there are no helpers, and it starts them anew
+	if cs.command == nil || cs.command.Process == nil {
+		return nil, false, nil
+	}
+	pids, err := findConsoleHelpers(cs.command.Process.Pid, cs.consoles, helpers)
+	if err != nil {
+		return nil, true, err
+	}
+	var reconnected []string
+	for _, xname := range helpers {
+		for _, pid := range pids[xname] {
+			slog.Info("Stopping console helper to reconnect with new credentials", "xname", xname, "pid", pid)
+			if err := syscall.Kill(pid, syscall.SIGTERM); err != nil && !errors.Is(err, syscall.ESRCH) {
+				return reconnected, true, fmt.Errorf("failed to stop console helper of %s: %w", xname, err)
+			}
+		}
+		if len(pids[xname]) > 0 {
+			reconnected = append(reconnected, xname)
+		}
+	}
+	return reconnected, false, nil
+}
+
+// findConsoleHelpers finds the helper processes conmand started for the
+// consoles, matching their command line against each console's dev arguments
+func findConsoleHelpers(conmandPid int, consoles map[string]consoleEntry, xnames []string) (map[string][]int, error) {
+	var outBuf bytes.Buffer
+	cmd := exec.Command("ps", "-ww", "-eo", "pid,ppid,args")
+	cmd.Stdout = &outBuf
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("error getting current processes: %w", err)
+	}
+
+	pids := make(map[string][]int)
+	for _, readLine := range strings.Split(outBuf.String(), "\n") {
+		fields := strings.Fields(readLine)
+		if len(fields) < 3 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		// Only conmand's own children are helpers
+		if ppid, err := strconv.Atoi(fields[1]); err != nil || ppid != conmandPid {
+			continue
+		}
+		args := strings.Join(fields[2:], " ")
+		for _, xname := range xnames {
+			if isHelperCommand(args, consoles[xname].devArgs) {
+				pids[xname] = append(pids[xname], pid)
+			}
+		}
+	}
+	return pids, nil
+}
+
+// isHelperCommand reports whether a command line runs a console's helper.
+// Helpers are scripts, so the kernel runs them through their interpreter and
+// the command line is the interpreter, an optional "--", then devArgs.
+func isHelperCommand(args, devArgs string) bool {
+	if args == devArgs {
+		return true
+	}
+	_, rest, ok := strings.Cut(args, " ")
+	if !ok {
+		return false
+	}
+	return rest == devArgs || rest == "-- "+devArgs
+}
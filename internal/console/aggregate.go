@@ -0,0 +1,276 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// This file contains the live stream of aggregated console output from many nodes
+
+package console
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/nxadm/tail/ratelimiter"
+
+	"github.com/OpenCHAMI/remote-console/internal/logs"
+	"github.com/OpenCHAMI/remote-console/internal/nodes"
+)
+
+const (
+	sessionModeAggregate = "aggregate"
+
+	// number of lines buffered per stream before lines are dropped
+	aggregateBufferLines = 4096
+)
+
+// Aggregate frame types sent to the client
+const (
+	aggregateFrameStatus  = "status"
+	aggregateFrameOutput  = "output"
+	aggregateFrameDropped = "dropped"
+)
+
+// aggregateFrame is the JSON framing used for aggregated console output
+type aggregateFrame struct {
+	Type      string            `json:"type"`
+	Seq       uint64            `json:"seq,omitempty"`
+	Node      string            `json:"node,omitempty"`
+	Timestamp *time.Time        `json:"timestamp,omitempty"`
+	File      string            `json:"file,omitempty"`
+	Offset    *int64            `json:"offset,omitempty"`
+	Data      string            `json:"data,omitempty"`
+	Nodes     []string          `json:"nodes,omitempty"`
+	Rejected  map[string]string `json:"rejected,omitempty"`
+	Dropped   uint64            `json:"dropped,omitempty"`
+}
+
+// consoleFeed provides the live aggregated console output
+type consoleFeed interface {
+	Subscribe(accept func(xname string) bool, buffer int) *logs.Subscription
+	Unsubscribe(sub *logs.Subscription)
+}
+
+// selectAggregateNodes resolves the nodes and groups parameters and checks each
+// node against the inventory and the authorization policy
+func selectAggregateNodes(perms *permissions, nodesParam, groupsParam string) (accepted []string, rejected map[string]string, err error) {
+	var requested []string
+	if nodesParam != "" {
+		requested, err = nodes.ExpandHostlist(nodesParam)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Invalid nodes parameter: %w", err)
+		}
+	}
+	for _, group := range strings.Split(groupsParam, ",") {
+		if group = strings.TrimSpace(group); group != "" {
+			requested = append(requested, nodes.GroupMembers(group)...)
+		}
+	}
+
+	slices.Sort(requested)
+	requested = slices.Compact(requested)
+
+	rejected = make(map[string]string)
+	for _, nodeID := range requested {
+		switch {
+		case !nodes.IsCurrentNode(nodeID):
+			rejected[nodeID] = "node not found"
+		case !perms.allowed(nodeID, AccessTail):
+			rejected[nodeID] = "not authorized"
+		default:
+			accepted = append(accepted, nodeID)
+		}
+	}
+	return accepted, rejected, nil
+}
+
+// aggregateStream sends aggregated console output to a single client
+type aggregateStream struct {
+	out         tailOutput
+	tracked     *trackedSession
+	rateLimiter *ratelimiter.LeakyBucket // Rate limit combined console output
+	closeOnce   sync.Once
+}
+
+func (as *aggregateStream) writeFrame(frame aggregateFrame) error {
+	data, err := json.Marshal(frame)
+	if err != nil {
+		return fmt.Errorf("failed to encode aggregate frame: %w", err)
+	}
+	line := string(data) + "\n"
+
+	// Apply rate limiting (convert bytes to KB, rounded up)
+	kb := uint16((len(line) + 1023) / 1024)
+	for !as.rateLimiter.Pour(kb) {
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if err := as.out.writeLine(line, -1); err != nil {
+		return err
+	}
+	as.tracked.addBytesOut(len(line))
+	return nil
+}
+
+func (as *aggregateStream) close(reason sessionCloseReason, message string) {
+	as.closeOnce.Do(func() {
+		as.out.close(reason, message)
+	})
+}
+
+// terminate notifies the user of the reason and closes the stream
+func (as *aggregateStream) terminate(reason string) {
+	if err := as.out.writeNotice(fmt.Sprintf("\n[Session terminated: %s]\n", reason)); err != nil {
+		slog.Debug("Failed to send termination notice to aggregate stream", "error", err)
+	}
+	as.close(sessionCloseNormal, closeMessageText(reason))
+}
+
+// stream forwards subscribed console lines until the client goes away
+func (as *aggregateStream) stream(r *http.Request, sub *logs.Subscription) {
+	var reportedDropped, seq uint64
+	for {
+		select {
+		case <-r.Context().Done():
+			as.close(sessionCloseCanceled, "session ended")
+			return
+		case <-as.out.Done():
+			return
+		case line := <-sub.Lines():
+			if dropped := sub.Dropped(); dropped > reportedDropped {
+				if err := as.writeFrame(aggregateFrame{Type: aggregateFrameDropped, Dropped: dropped - reportedDropped}); err != nil {
+					as.close(sessionCloseError, "error sending console output")
+					return
+				}
+				reportedDropped = dropped
+			}
+
+			seq++
+			ts := line.Timestamp.UTC()
+			frame := aggregateFrame{
+				Type:      aggregateFrameOutput,
+				Seq:       seq,
+				Node:      line.Node,
+				Timestamp: &ts,
+				File:      line.File,
+				Offset:    &line.Offset,
+				Data:      line.Text,
+			}
+			if err := as.writeFrame(frame); err != nil {
+				slog.Debug("Failed to send aggregated console output", "error", err)
+				as.close(sessionCloseError, "error sending console output")
+				return
+			}
+		}
+	}
+}
+
+// doAggregateStream handles GET /logs/stream
+func doAggregateStream(feed consoleFeed, registry *sessionRegistry, w http.ResponseWriter, r *http.Request) {
+	// Make sure the request is cleaned up
+	defer drainAndCloseRequestBody(r)
+
+	params := r.URL.Query()
+	nodesParam, groupsParam := params.Get("nodes"), params.Get("groups")
+	if nodesParam == "" && groupsParam == "" {
+		sendJSONError(w, http.StatusBadRequest, "Nodes or groups parameter is required")
+		return
+	}
+
+	accepted, rejected, err := selectAggregateNodes(permissionsFromContext(r.Context()), nodesParam, groupsParam)
+	if err != nil {
+		sendJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if len(accepted) == 0 {
+		status := http.StatusNotFound
+		for _, reason := range rejected {
+			if reason == "not authorized" {
+				status = http.StatusForbidden
+				break
+			}
+		}
+		sendResponseJSON(w, status, aggregateFrame{Type: aggregateFrameStatus, Rejected: rejected})
+		return
+	}
+
+	// Use a WebSocket when the client asks for one, otherwise stream over plain HTTP
+	var out tailOutput
+	var ws *webSocketSession
+	if websocket.IsWebSocketUpgrade(r) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			slog.Error("Failed to upgrade WebSocket connection for aggregate stream", "error", err)
+			// Can't send HTTP error after upgrade attempt
+			return
+		}
+		ws = newWebSocketSession(conn, "aggregate stream")
+		ws.Start()
+		out = wsTailOutput{ws}
+	} else {
+		format, err := logStreamFormat(r)
+		if err != nil {
+			sendJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		httpOut := newHTTPTailOutput(w, format == logStreamFormatSSE)
+		if err := httpOut.start(); err != nil {
+			slog.Error("Failed to start aggregate stream", "error", err)
+			return
+		}
+		out = httpOut
+	}
+
+	selected := make(map[string]bool, len(accepted))
+	for _, nodeID := range accepted {
+		selected[nodeID] = true
+	}
+
+	// The registry entry names the selection in pdsh style, with groups as @group
+	selection := nodesParam
+	for _, group := range strings.Split(groupsParam, ",") {
+		if group = strings.TrimSpace(group); group != "" {
+			selection = strings.TrimPrefix(selection+",@"+group, ",")
+		}
+	}
+
+	as := &aggregateStream{
+		out:         out,
+		rateLimiter: ratelimiter.NewLeakyBucket(rateLimitBurstKB, rateLimitInterval),
+	}
+	as.tracked = registry.register(selection, sessionModeAggregate, r, as.terminate)
+	defer registry.unregister(as.tracked)
+	defer as.close(sessionCloseNormal, "")
+
+	if ws != nil {
+		go func() {
+			for {
+				if _, _, err := ws.Read(); err != nil {
+					as.close(sessionCloseNormal, "")
+					return
+				}
+			}
+		}()
+	}
+
+	sub := feed.Subscribe(func(xname string) bool { return selected[xname] }, aggregateBufferLines)
+	defer feed.Unsubscribe(sub)
+
+	slog.Info("Starting aggregate console stream", "nodes", len(accepted), "rejected", len(rejected), "user", as.tracked.user)
+
+	if err := as.writeFrame(aggregateFrame{Type: aggregateFrameStatus, Nodes: accepted, Rejected: rejected}); err != nil {
+		slog.Warn("Failed to send aggregate status frame", "error", err)
+		return
+	}
+
+	as.stream(r, sub)
+
+	slog.Info("Aggregate console stream ended", "nodes", len(accepted))
+}
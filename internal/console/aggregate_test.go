@@ -0,0 +1,78 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package console
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nxadm/tail/ratelimiter"
+	"github.com/stretchr/testify/require"
+
+	"github.com/OpenCHAMI/remote-console/internal/logs"
+)
+
+func TestSelectAggregateNodes(t *testing.T) {
+	accepted, rejected, err := selectAggregateNodes(nil, "x1000c0s[0-1]b0n0,x1000c0s0b0n0", "")
+	require.NoError(t, err)
+	require.Empty(t, accepted)
+	require.Equal(t, map[string]string{
+		"x1000c0s0b0n0": "node not found",
+		"x1000c0s1b0n0": "node not found",
+	}, rejected)
+
+	_, _, err = selectAggregateNodes(nil, "x1000c0s[0-", "")
+	require.Error(t, err)
+}
+
+func TestDoAggregateStreamRejectsRequests(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected int
+	}{
+		{"missing selection", "", http.StatusBadRequest},
+		{"invalid hostlist", "?nodes=x1000c0s[0-", http.StatusBadRequest},
+		{"unknown nodes", "?nodes=x1000c0s[0-1]b0n0", http.StatusNotFound},
+		{"empty group", "?groups=compute", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/logs/stream"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			doAggregateStream(&logs.LogsService{}, newSessionRegistry(), rec, req)
+			require.Equal(t, tt.expected, rec.Code)
+		})
+	}
+}
+
+func TestAggregateFrames(t *testing.T) {
+	out := &recordingTailOutput{done: make(chan struct{})}
+	as := &aggregateStream{
+		out:         out,
+		rateLimiter: ratelimiter.NewLeakyBucket(rateLimitBurstKB, rateLimitInterval),
+	}
+
+	ts := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	offset := int64(42)
+	require.NoError(t, as.writeFrame(aggregateFrame{Type: aggregateFrameOutput, Seq: 1, Node: "x1000c0s0b0n0", Timestamp: &ts, File: "console.x1000c0s0b0n0", Offset: &offset, Data: "login:"}))
+	require.NoError(t, as.writeFrame(aggregateFrame{Type: aggregateFrameDropped, Dropped: 3}))
+	require.Equal(t, []string{
+		`{"type":"output","seq":1,"node":"x1000c0s0b0n0","timestamp":"2026-03-04T05:06:07Z","file":"console.x1000c0s0b0n0","offset":42,"data":"login:"}` + "\n",
+		`{"type":"dropped","dropped":3}` + "\n",
+	}, out.lines)
+
+	var frame aggregateFrame
+	require.NoError(t, json.Unmarshal([]byte(out.lines[0]), &frame))
+	require.Equal(t, "x1000c0s0b0n0", frame.Node)
+
+	// Closing more than once keeps the first reason
+	as.close(sessionCloseNormal, "")
+	as.close(sessionCloseError, "")
+}
@@ -0,0 +1,201 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// This file contains the claim based per-node authorization policy
+
+package console
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"slices"
+
+	"github.com/OpenCHAMI/jwtauth/v5"
+	"gopkg.in/yaml.v3"
+
+	"github.com/OpenCHAMI/remote-console/internal/nodes"
+)
+
+// Console access modes that can be granted by the authorization policy
+const (
+	AccessList        = "list"
+	AccessTail        = "tail"
+	AccessInteractive = "interactive"
+	AccessForce       = "force"
+)
+
+var validAccessModes = []string{AccessList, AccessTail, AccessInteractive, AccessForce}
+
+// AuthzPolicy holds the loaded authorization policy, nil when authorization is disabled
+var AuthzPolicy *Policy
+
+// Policy maps token claims to the nodes and access modes they are allowed
+type Policy struct {
+	GroupsClaim string       `yaml:"groupsClaim"`
+	TenantClaim string       `yaml:"tenantClaim"`
+	Rules       []PolicyRule `yaml:"rules"`
+}
+
+// PolicyRule grants access modes on a set of nodes to tokens matching its claims
+type PolicyRule struct {
+	Name      string      `yaml:"name"`
+	Match     PolicyMatch `yaml:"match"`
+	Nodes     []string    `yaml:"nodes"`     // xname glob patterns
+	SmdGroups []string    `yaml:"smdGroups"` // SMD group labels
+	Modes     []string    `yaml:"modes"`
+}
+
+// PolicyMatch selects the tokens a rule applies to. Every non-empty field
+// must match; within a field any listed value is sufficient. A rule with an
+// empty match applies to every request.
+type PolicyMatch struct {
+	Scopes []string `yaml:"scopes"`
+	Groups []string `yaml:"groups"`
+	Tenant string   `yaml:"tenant"`
+}
+
+// LoadAuthzPolicy reads and validates the policy file and installs it as AuthzPolicy
+func LoadAuthzPolicy(filename string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("failed to read authorization policy: %w", err)
+	}
+
+	policy, err := parsePolicy(data)
+	if err != nil {
+		return fmt.Errorf("invalid authorization policy %q: %w", filename, err)
+	}
+
+	AuthzPolicy = policy
+	slog.Info("Loaded authorization policy", "file", filename, "rules", len(policy.Rules))
+	return nil
+}
+
+func parsePolicy(data []byte) (*Policy, error) {
+	var policy Policy
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return nil, err
+	}
+
+	if policy.GroupsClaim == "" {
+		policy.GroupsClaim = "groups"
+	}
+	if policy.TenantClaim == "" {
+		policy.TenantClaim = "tenant"
+	}
+
+	for i, rule := range policy.Rules {
+		if len(rule.Modes) == 0 {
+			return nil, fmt.Errorf("rule %d (%s) grants no modes", i, rule.Name)
+		}
+		for _, mode := range rule.Modes {
+			if !slices.Contains(validAccessModes, mode) {
+				return nil, fmt.Errorf("rule %d (%s) has invalid mode %q", i, rule.Name, mode)
+			}
+		}
+		for _, pattern := range rule.Nodes {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("rule %d (%s) has invalid node pattern %q: %w", i, rule.Name, pattern, err)
+			}
+		}
+	}
+
+	return &policy, nil
+}
+
+// matches reports whether the rule applies to the given claims
+func (p *Policy) matches(rule PolicyRule, claims map[string]interface{}) bool {
+	m := rule.Match
+	if len(m.Scopes) > 0 && !slices.ContainsFunc(m.Scopes, func(s string) bool {
+		return claimContains(claims, "scope", s) || claimContains(claims, "scp", s)
+	}) {
+		return false
+	}
+	if len(m.Groups) > 0 && !slices.ContainsFunc(m.Groups, func(g string) bool {
+		return claimContains(claims, p.GroupsClaim, g)
+	}) {
+		return false
+	}
+	if m.Tenant != "" {
+		if tenant, _ := claims[p.TenantClaim].(string); tenant != m.Tenant {
+			return false
+		}
+	}
+	return true
+}
+
+// permissions returns the rules that apply to the given claims
+func (p *Policy) permissions(claims map[string]interface{}) *permissions {
+	perms := &permissions{}
+	for _, rule := range p.Rules {
+		if p.matches(rule, claims) {
+			perms.rules = append(perms.rules, rule)
+		}
+	}
+	return perms
+}
+
+// permissions is the set of rules granted to a single request
+type permissions struct {
+	rules []PolicyRule
+}
+
+// allowed reports whether mode is granted on nodeID. A nil permissions
+// value means authorization is disabled and everything is allowed.
+func (p *permissions) allowed(nodeID, mode string) bool {
+	if p == nil {
+		return true
+	}
+
+	for _, rule := range p.rules {
+		if !slices.Contains(rule.Modes, mode) {
+			continue
+		}
+		for _, pattern := range rule.Nodes {
+			if ok, _ := path.Match(pattern, nodeID); ok {
+				return true
+			}
+		}
+		for _, group := range rule.SmdGroups {
+			if nodes.IsGroupMember(group, nodeID) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type permissionsCtxKey struct{}
+
+// permissionsFromContext returns the permissions attached by authorize, nil if authorization is disabled
+func permissionsFromContext(ctx context.Context) *permissions {
+	perms, _ := ctx.Value(permissionsCtxKey{}).(*permissions)
+	return perms
+}
+
+// authorize evaluates the request claims against the policy and attaches the
+// resulting permissions to the request context for the handlers to enforce
+func authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if AuthzPolicy == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		_, claims, err := jwtauth.FromContext(r.Context())
+		if err != nil || claims == nil {
+			claims = map[string]interface{}{}
+		}
+
+		perms := AuthzPolicy.permissions(claims)
+		slog.Debug("Authorization evaluated", "user", claims["sub"], "matchedRules", len(perms.rules))
+
+		ctx := context.WithValue(r.Context(), permissionsCtxKey{}, perms)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
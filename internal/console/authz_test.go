@@ -0,0 +1,90 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package console
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testPolicy = `
+rules:
+  - name: tenant-a-researchers
+    match:
+      groups: [researchers]
+      tenant: tenant-a
+    nodes: ["x1000c0s*b0n0"]
+    modes: [list, tail, interactive]
+  - name: helpdesk
+    match:
+      scopes: [helpdesk]
+    nodes: ["*"]
+    modes: [list, tail]
+  - name: operators
+    match:
+      groups: [operators]
+    nodes: ["*"]
+    modes: [list, tail, interactive, force]
+`
+
+func TestPolicyPermissions(t *testing.T) {
+	policy, err := parsePolicy([]byte(testPolicy))
+	require.NoError(t, err)
+
+	researcher := policy.permissions(map[string]interface{}{
+		"sub":    "alice",
+		"groups": []interface{}{"researchers"},
+		"tenant": "tenant-a",
+	})
+	require.True(t, researcher.allowed("x1000c0s3b0n0", AccessInteractive))
+	require.False(t, researcher.allowed("x1000c0s3b0n0", AccessForce))
+	require.False(t, researcher.allowed("x1001c0s3b0n0", AccessList))
+
+	otherTenant := policy.permissions(map[string]interface{}{
+		"groups": []interface{}{"researchers"},
+		"tenant": "tenant-b",
+	})
+	require.False(t, otherTenant.allowed("x1000c0s3b0n0", AccessList))
+
+	helpdesk := policy.permissions(map[string]interface{}{"scope": "read helpdesk"})
+	require.True(t, helpdesk.allowed("x3000c0s1b0n0", AccessTail))
+	require.False(t, helpdesk.allowed("x3000c0s1b0n0", AccessInteractive))
+
+	operator := policy.permissions(map[string]interface{}{"groups": []interface{}{"operators"}})
+	require.True(t, operator.allowed("x3000c0s1b0n0", AccessForce))
+
+	nobody := policy.permissions(map[string]interface{}{"sub": "mallory"})
+	require.False(t, nobody.allowed("x3000c0s1b0n0", AccessList))
+
+	// nil permissions means authorization is disabled
+	var disabled *permissions
+	require.True(t, disabled.allowed("x3000c0s1b0n0", AccessForce))
+}
+
+func TestParsePolicyInvalid(t *testing.T) {
+	_, err := parsePolicy([]byte(`
+rules:
+  - name: bad-mode
+    nodes: ["*"]
+    modes: [write]
+`))
+	require.Error(t, err)
+
+	_, err = parsePolicy([]byte(`
+rules:
+  - name: bad-pattern
+    nodes: ["x1000["]
+    modes: [tail]
+`))
+	require.Error(t, err)
+
+	_, err = parsePolicy([]byte(`
+rules:
+  - name: no-modes
+    nodes: ["*"]
+`))
+	require.Error(t, err)
+}
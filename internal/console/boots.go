@@ -0,0 +1,91 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// This file contains the endpoints listing and reading the boots in console logs
+
+package console
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/OpenCHAMI/remote-console/internal/logs"
+)
+
+type ConsoleBootsResponse struct {
+	NodeID string      `json:"nodeID"`
+	Boots  []logs.Boot `json:"boots"`
+}
+
+// bootSource provides the boots found in console logs
+type bootSource interface {
+	Boots(consoleLogsPath, xname string) ([]logs.Boot, error)
+	OpenBoot(consoleLogsPath, xname string, id int) (logs.Boot, io.ReadCloser, error)
+}
+
+// doListBoots handles GET /consoles/{nodeID}/boots
+func doListBoots(consoleLogsPath string, source bootSource, w http.ResponseWriter, r *http.Request) {
+	nodeID, ok := checkLogAccess(w, r)
+	if !ok {
+		return
+	}
+
+	boots, err := source.Boots(consoleLogsPath, nodeID)
+	if errors.Is(err, logs.ErrBootsDisabled) {
+		sendJSONError(w, http.StatusNotImplemented, err.Error())
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to list boots", "nodeID", nodeID, "error", err)
+		sendJSONError(w, http.StatusInternalServerError, "failed to list boots")
+		return
+	}
+
+	sendResponseJSON(w, http.StatusOK, ConsoleBootsResponse{NodeID: nodeID, Boots: boots})
+}
+
+// doReadBoot handles GET /consoles/{nodeID}/boots/{bootID}
+func doReadBoot(consoleLogsPath string, source bootSource, w http.ResponseWriter, r *http.Request) {
+	nodeID, ok := checkLogAccess(w, r)
+	if !ok {
+		return
+	}
+
+	bootID, err := strconv.Atoi(chi.URLParam(r, "bootID"))
+	if err != nil || bootID == 0 {
+		sendJSONError(w, http.StatusBadRequest, "Boot ID must be a non-zero integer")
+		return
+	}
+
+	boot, output, err := source.OpenBoot(consoleLogsPath, nodeID, bootID)
+	switch {
+	case errors.Is(err, logs.ErrBootsDisabled):
+		sendJSONError(w, http.StatusNotImplemented, err.Error())
+		return
+	case errors.Is(err, logs.ErrBootNotFound):
+		sendJSONError(w, http.StatusNotFound, "Boot not found")
+		return
+	case err != nil:
+		slog.Error("Failed to open boot", "nodeID", nodeID, "bootID", bootID, "error", err)
+		sendJSONError(w, http.StatusInternalServerError, "failed to read boot")
+		return
+	}
+	defer func() {
+		if err := output.Close(); err != nil {
+			slog.Warn("Failed to close boot output", "nodeID", nodeID, "error", err)
+		}
+	}()
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Boot-ID", strconv.Itoa(boot.ID))
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, output); err != nil {
+		slog.Debug("Failed to send boot output", "nodeID", nodeID, "bootID", boot.ID, "error", err)
+	}
+}
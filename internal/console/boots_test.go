@@ -0,0 +1,48 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package console
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/OpenCHAMI/remote-console/internal/logs"
+)
+
+// unusedBootSource fails the test if the handler reaches it
+type unusedBootSource struct{ t *testing.T }
+
+func (s unusedBootSource) Boots(consoleLogsPath, xname string) ([]logs.Boot, error) {
+	s.t.Fatal("unexpected boot listing")
+	return nil, nil
+}
+
+func (s unusedBootSource) OpenBoot(consoleLogsPath, xname string, id int) (logs.Boot, io.ReadCloser, error) {
+	s.t.Fatal("unexpected boot read")
+	return logs.Boot{}, nil, nil
+}
+
+func TestBootsRejectUnknownNodes(t *testing.T) {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("nodeID", "x1000c0s0b0n0")
+	rctx.URLParams.Add("bootID", "-1")
+
+	req := httptest.NewRequest(http.MethodGet, "/consoles/x1000c0s0b0n0/boots", nil)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	rec := httptest.NewRecorder()
+	doListBoots("", unusedBootSource{t}, rec, req)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	doReadBoot("", unusedBootSource{t}, rec, req)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
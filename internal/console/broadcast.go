@@ -0,0 +1,362 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// This file contains the multi-console broadcast session
+
+package console
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/websocket"
+	"github.com/nxadm/tail/ratelimiter"
+
+	"github.com/OpenCHAMI/remote-console/internal/nodes"
+)
+
+const (
+	sessionModeBroadcast = "broadcast"
+
+	// maxBroadcastNodes bounds the number of consoles a single broadcast session may drive
+	maxBroadcastNodes = 256
+)
+
+// Broadcast frame types sent to the client
+const (
+	broadcastFrameStatus = "status"
+	broadcastFrameOutput = "output"
+	broadcastFrameClosed = "closed"
+)
+
+// broadcastFrame is the JSON framing used for all broadcast session output
+type broadcastFrame struct {
+	Type      string            `json:"type"`
+	Node      string            `json:"node,omitempty"`
+	Data      string            `json:"data,omitempty"`
+	Bytes     []byte            `json:"bytes,omitempty"` // output that isn't valid UTF-8, base64 encoded
+	Reason    string            `json:"reason,omitempty"`
+	Connected []string          `json:"connected,omitempty"`
+	Rejected  map[string]string `json:"rejected,omitempty"`
+}
+
+type broadcastMember struct {
+	conn    *conmanConnection
+	tracked *trackedSession
+}
+
+// broadcastSession fans one input stream out to several consoles and
+// multiplexes their output back to the client
+type broadcastSession struct {
+	ws          *webSocketSession
+	sessions    *interactiveSessions
+	registry    *sessionRegistry
+	rateLimiter *ratelimiter.LeakyBucket // Rate limit combined console output
+	mutex       sync.Mutex
+	members     map[string]*broadcastMember
+	wg          sync.WaitGroup
+}
+
+func newBroadcastSession(conn *websocket.Conn, sessions *interactiveSessions, registry *sessionRegistry) *broadcastSession {
+	return &broadcastSession{
+		ws:          newWebSocketSession(conn, "broadcast session"),
+		sessions:    sessions,
+		registry:    registry,
+		rateLimiter: ratelimiter.NewLeakyBucket(rateLimitBurstKB, rateLimitInterval),
+		members:     make(map[string]*broadcastMember),
+	}
+}
+
+func (bs *broadcastSession) writeFrame(frame broadcastFrame) error {
+	data, err := json.Marshal(frame)
+	if err != nil {
+		return fmt.Errorf("failed to encode broadcast frame: %w", err)
+	}
+	return bs.ws.Write(websocket.TextMessage, data)
+}
+
+// addNode connects to nodeID and starts streaming its output. Once added the
+// session owns the node's reservation and releases it when the node is removed.
+func (bs *broadcastSession) addNode(ctx context.Context, nodeID string, r *http.Request) error {
+	conn, err := startConmanConnection(nodeID)
+	if err != nil {
+		return err
+	}
+
+	// The member is added before removeNode can look for it on a termination
+	bs.mutex.Lock()
+	member := &broadcastMember{
+		conn: conn,
+		tracked: bs.registry.register(nodeID, sessionModeBroadcast, r, func(reason string) {
+			bs.removeNode(nodeID, reason)
+		}),
+	}
+	bs.members[nodeID] = member
+	bs.mutex.Unlock()
+
+	bs.wg.Add(1)
+	go bs.streamOutput(ctx, nodeID, member)
+	return nil
+}
+
+// removeNode disconnects a single console and releases its reservation,
+// closing the session once no consoles remain
+func (bs *broadcastSession) removeNode(nodeID, reason string) {
+	bs.mutex.Lock()
+	member, ok := bs.members[nodeID]
+	delete(bs.members, nodeID)
+	remaining := len(bs.members)
+	bs.mutex.Unlock()
+
+	if !ok {
+		return
+	}
+
+	slog.Info("Removing console from broadcast session", "nodeID", nodeID, "reason", reason)
+	member.conn.close()
+	bs.registry.unregister(member.tracked)
+	bs.sessions.release(nodeID)
+
+	if err := bs.writeFrame(broadcastFrame{Type: broadcastFrameClosed, Node: nodeID, Reason: reason}); err != nil {
+		slog.Debug("Failed to send broadcast close frame", "nodeID", nodeID, "error", err)
+	}
+
+	if remaining == 0 {
+		bs.ws.close(sessionCloseNormal, "no consoles remaining")
+	}
+}
+
+// outputFrame returns the output frame of a node, with the output as text
+// when it is valid UTF-8
+func outputFrame(nodeID string, output []byte) broadcastFrame {
+	frame := broadcastFrame{Type: broadcastFrameOutput, Node: nodeID}
+	if utf8.Valid(output) {
+		frame.Data = string(output)
+	} else {
+		frame.Bytes = output
+	}
+	return frame
+}
+
+// runeSplitter holds back a rune split across console output chunks until
+// the rest of it arrives
+type runeSplitter struct {
+	pending []byte
+}
+
+// next returns the output up to the last complete rune
+func (rs *runeSplitter) next(data []byte) []byte {
+	output := append(rs.pending, data...)
+	end := len(output)
+	for i := len(output) - 1; i >= 0 && i >= len(output)-utf8.UTFMax+1; i-- {
+		if utf8.RuneStart(output[i]) {
+			if !utf8.FullRune(output[i:]) {
+				end = i
+			}
+			break
+		}
+	}
+	rs.pending = slices.Clone(output[end:])
+	return output[:end]
+}
+
+// streamOutput forwards the output of one console to the client
+func (bs *broadcastSession) streamOutput(ctx context.Context, nodeID string, member *broadcastMember) {
+	defer bs.wg.Done()
+
+	var splitter runeSplitter
+	err := member.conn.readOutput(ctx, func(data []byte) error {
+		// Apply rate limiting (convert bytes to KB, rounded up)
+		kb := uint16((len(data) + 1023) / 1024)
+		for !bs.rateLimiter.Pour(kb) {
+			slog.Debug("Rate limit reached for broadcast, waiting for capacity", "nodeID", nodeID)
+			time.Sleep(100 * time.Millisecond)
+		}
+
+		if output := splitter.next(data); len(output) > 0 {
+			if err := bs.writeFrame(outputFrame(nodeID, output)); err != nil {
+				return err
+			}
+		}
+		member.tracked.addBytesOut(len(data))
+		return nil
+	})
+
+	// The start of a rune that never completed is sent as it is
+	if len(splitter.pending) > 0 {
+		_ = bs.writeFrame(outputFrame(nodeID, splitter.pending))
+	}
+
+	slog.Debug("Broadcast output stream ended", "nodeID", nodeID, "error", err)
+	bs.removeNode(nodeID, "console disconnected")
+}
+
+// streamInput fans client input out to every connected console
+func (bs *broadcastSession) streamInput() {
+	for {
+		messageType, message, err := bs.ws.Read()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+				slog.Warn("WebSocket unexpected close error for broadcast session", "error", err)
+				bs.ws.close(sessionCloseError, "websocket read failed")
+			} else {
+				bs.ws.close(sessionCloseNormal, "")
+			}
+			return
+		}
+
+		if messageType != websocket.TextMessage && messageType != websocket.BinaryMessage {
+			continue
+		}
+
+		bs.mutex.Lock()
+		targets := make(map[string]*broadcastMember, len(bs.members))
+		for nodeID, member := range bs.members {
+			targets[nodeID] = member
+		}
+		bs.mutex.Unlock()
+
+		for nodeID, member := range targets {
+			n, err := member.conn.Write(message)
+			if err != nil {
+				slog.Warn("Failed to write broadcast input to console", "nodeID", nodeID, "error", err)
+				bs.removeNode(nodeID, "failed to write to console")
+				continue
+			}
+			member.tracked.addBytesIn(n)
+		}
+	}
+}
+
+// close disconnects every remaining console and closes the WebSocket
+func (bs *broadcastSession) close() {
+	bs.mutex.Lock()
+	nodeIDs := make([]string, 0, len(bs.members))
+	for nodeID := range bs.members {
+		nodeIDs = append(nodeIDs, nodeID)
+	}
+	bs.mutex.Unlock()
+
+	for _, nodeID := range nodeIDs {
+		bs.removeNode(nodeID, "session closed")
+	}
+	bs.ws.close(sessionCloseNormal, "")
+}
+
+// selectBroadcastNodes checks each requested node against the inventory, the
+// authorization policy and the single writer rule. Accepted nodes are
+// reserved and must be released by the caller.
+func selectBroadcastNodes(sessions *interactiveSessions, perms *permissions, requested []string) (accepted []string, rejected map[string]string) {
+	rejected = make(map[string]string)
+	for _, nodeID := range requested {
+		switch {
+		case !nodes.IsCurrentNode(nodeID):
+			rejected[nodeID] = "node not found"
+		case !perms.allowed(nodeID, AccessInteractive):
+			rejected[nodeID] = "not authorized"
+		case !sessions.reserve(nodeID):
+			rejected[nodeID] = "console already in use"
+		default:
+			accepted = append(accepted, nodeID)
+		}
+	}
+	return accepted, rejected
+}
+
+func doBroadcastConsole(sessions *interactiveSessions, registry *sessionRegistry, w http.ResponseWriter, r *http.Request) {
+	// Make sure the request is cleaned up
+	defer drainAndCloseRequestBody(r)
+
+	nodesParam := r.URL.Query().Get("nodes")
+	if nodesParam == "" {
+		http.Error(w, "Nodes parameter is required for broadcast mode", http.StatusBadRequest)
+		return
+	}
+
+	requested, err := nodes.ExpandHostlist(nodesParam)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid nodes parameter: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(requested) > maxBroadcastNodes {
+		http.Error(w, fmt.Sprintf("Broadcast is limited to %d consoles", maxBroadcastNodes), http.StatusBadRequest)
+		return
+	}
+
+	accepted, rejected := selectBroadcastNodes(sessions, permissionsFromContext(r.Context()), requested)
+	// Consoles added to the session are released when they leave it
+	var connected []string
+	defer func() {
+		for _, nodeID := range accepted {
+			if !slices.Contains(connected, nodeID) {
+				sessions.release(nodeID)
+			}
+		}
+	}()
+
+	if len(accepted) == 0 {
+		allRejectedFor := func(reason string) bool {
+			return !slices.ContainsFunc(requested, func(nodeID string) bool { return rejected[nodeID] != reason })
+		}
+		status := http.StatusConflict
+		if allRejectedFor("not authorized") {
+			status = http.StatusForbidden
+		} else if allRejectedFor("node not found") {
+			status = http.StatusNotFound
+		}
+		sendResponseJSON(w, status, broadcastFrame{Type: broadcastFrameStatus, Rejected: rejected})
+		return
+	}
+
+	slog.Info("Starting broadcast console session", "nodes", len(accepted), "rejected", len(rejected))
+
+	// Upgrade HTTP connection to WebSocket
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		slog.Error("Failed to upgrade WebSocket connection for broadcast", "error", err)
+		// Can't send HTTP error after upgrade attempt
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	session := newBroadcastSession(conn, sessions, registry)
+	session.ws.Start()
+	defer session.close()
+
+	for _, nodeID := range accepted {
+		if err := session.addNode(ctx, nodeID, r); err != nil {
+			slog.Error("Failed to connect console for broadcast", "nodeID", nodeID, "error", err)
+			rejected[nodeID] = "failed to connect to console"
+			continue
+		}
+		connected = append(connected, nodeID)
+	}
+
+	if err := session.writeFrame(broadcastFrame{Type: broadcastFrameStatus, Connected: connected, Rejected: rejected}); err != nil {
+		slog.Warn("Failed to send broadcast status frame", "error", err)
+	}
+
+	if len(connected) == 0 {
+		session.ws.close(sessionCloseError, "failed to connect to any console")
+		return
+	}
+
+	session.streamInput()
+
+	// Stop the output streams and wait for them before releasing the consoles
+	cancel()
+	session.close()
+	session.wg.Wait()
+
+	slog.Info("Broadcast console session ended", "nodes", len(connected))
+}
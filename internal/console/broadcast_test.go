@@ -0,0 +1,120 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package console
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoBroadcastConsoleRejectsRequests(t *testing.T) {
+	sessions := newInteractiveSessions()
+	registry := newSessionRegistry()
+
+	tests := []struct {
+		name     string
+		query    string
+		expected int
+	}{
+		{"missing nodes", "mode=broadcast", http.StatusBadRequest},
+		{"invalid hostlist", "mode=broadcast&nodes=x1000c0s[0-", http.StatusBadRequest},
+		{"too many nodes", "mode=broadcast&nodes=x1000c0s[0-999]b0n0", http.StatusBadRequest},
+		{"unknown nodes", "mode=broadcast&nodes=x1000c0s[0-1]b0n0", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/consoles?"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			doBroadcastConsole(sessions, registry, rec, req)
+			require.Equal(t, tt.expected, rec.Code)
+		})
+	}
+}
+
+func TestSelectBroadcastNodesUnknown(t *testing.T) {
+	sessions := newInteractiveSessions()
+
+	accepted, rejected := selectBroadcastNodes(sessions, nil, []string{"x9999c0s0b0n0"})
+	require.Empty(t, accepted)
+	require.Equal(t, map[string]string{"x9999c0s0b0n0": "node not found"}, rejected)
+
+	// Rejected nodes must not hold a reservation
+	require.True(t, sessions.reserve("x9999c0s0b0n0"))
+}
+
+func TestBroadcastRemoveNodeReleasesReservation(t *testing.T) {
+	sessions := newInteractiveSessions()
+	registry := newSessionRegistry()
+	session := newBroadcastSession(nil, sessions, registry)
+
+	// Stand-in console connections whose process already exited
+	for _, nodeID := range []string{"x1000c0s0b0n0", "x1000c0s1b0n0"} {
+		require.True(t, sessions.reserve(nodeID))
+		reader, writer, err := os.Pipe()
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = reader.Close() })
+		exited := make(chan struct{})
+		close(exited)
+		session.members[nodeID] = &broadcastMember{
+			conn:    &conmanConnection{nodeID: nodeID, cmd: exec.Command("true"), ptmx: writer, exited: exited},
+			tracked: registry.register(nodeID, sessionModeBroadcast, httptest.NewRequest(http.MethodGet, "/", nil), func(string) {}),
+		}
+	}
+
+	// A console leaving the broadcast is free for another session at once
+	session.removeNode("x1000c0s0b0n0", "terminated")
+	require.True(t, sessions.reserve("x1000c0s0b0n0"))
+	require.False(t, sessions.reserve("x1000c0s1b0n0"))
+	require.Len(t, registry.list(), 1)
+
+	// Removing it again doesn't release a reservation it no longer owns
+	session.removeNode("x1000c0s0b0n0", "terminated")
+	require.False(t, sessions.reserve("x1000c0s0b0n0"))
+
+	session.close()
+	require.True(t, sessions.reserve("x1000c0s1b0n0"))
+	require.Empty(t, registry.list())
+}
+
+func TestBroadcastFrameEncoding(t *testing.T) {
+	data, err := json.Marshal(broadcastFrame{Type: broadcastFrameOutput, Node: "x1000c0s0b0n0", Data: "login: "})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"type":"output","node":"x1000c0s0b0n0","data":"login: "}`, string(data))
+
+	data, err = json.Marshal(broadcastFrame{
+		Type:      broadcastFrameStatus,
+		Connected: []string{"x1000c0s0b0n0"},
+		Rejected:  map[string]string{"x1000c0s1b0n0": "console already in use"},
+	})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"type":"status","connected":["x1000c0s0b0n0"],"rejected":{"x1000c0s1b0n0":"console already in use"}}`, string(data))
+
+	// Output that isn't valid UTF-8 is sent as base64 rather than replaced
+	data, err = json.Marshal(outputFrame("x1000c0s0b0n0", []byte{'o', 'k', 0xff, 0x1b}))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"type":"output","node":"x1000c0s0b0n0","bytes":"b2v/Gw=="}`, string(data))
+}
+
+func TestRuneSplitter(t *testing.T) {
+	var splitter runeSplitter
+	// "é" and "€" split across chunks are held back until complete
+	require.Equal(t, "caf", string(splitter.next([]byte("caf\xc3"))))
+	require.Equal(t, "é 1", string(splitter.next([]byte("\xa9 1\xe2"))))
+	require.Equal(t, "", string(splitter.next([]byte("\x82"))))
+	require.Equal(t, "€", string(splitter.next([]byte("\xac"))))
+	require.Empty(t, splitter.pending)
+
+	// Invalid bytes aren't held back
+	require.Equal(t, []byte{'a', 0xff}, splitter.next([]byte{'a', 0xff}))
+	require.Equal(t, []byte{0x80}, splitter.next([]byte{0x80}))
+	require.Empty(t, splitter.pending)
+}
@@ -0,0 +1,144 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package console
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/creack/pty"
+)
+
+// conmanConnection is a single conman client process attached to a PTY. It is
+// used by the sessions that do not need the reconnect handling of an
+// interactive WebSocket session.
+type conmanConnection struct {
+	nodeID    string
+	cmd       *exec.Cmd
+	ptmx      *os.File
+	ptmxMutex sync.RWMutex
+	closeOnce sync.Once
+	exited    chan struct{} // closed when the conman process exits
+}
+
+// startConmanConnection starts a conman client for nodeID
+func startConmanConnection(nodeID string) (*conmanConnection, error) {
+	cc := &conmanConnection{
+		nodeID: nodeID,
+		cmd:    exec.Command("conman", nodeID),
+		exited: make(chan struct{}),
+	}
+
+	ptmx, err := pty.Start(cc.cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start conman with PTY: %w", err)
+	}
+	cc.ptmx = ptmx
+
+	// Immediately start waiting on the process to avoid zombies
+	go func() {
+		if err := cc.cmd.Wait(); err != nil {
+			slog.Debug("Conman process wait ended with error", "nodeID", nodeID, "error", err)
+		}
+		close(cc.exited)
+	}()
+
+	return cc, nil
+}
+
+// Write sends input to the console
+func (cc *conmanConnection) Write(p []byte) (int, error) {
+	cc.ptmxMutex.RLock()
+	defer cc.ptmxMutex.RUnlock()
+
+	if cc.ptmx == nil {
+		return 0, errors.New("console connection closed")
+	}
+	return cc.ptmx.Write(p)
+}
+
+// readOutput reads console output and passes each chunk to handle until the
+// context is cancelled, the connection is closed, or handle returns an error.
+// The chunk passed to handle is only valid for the duration of the call.
+func (cc *conmanConnection) readOutput(ctx context.Context, handle func([]byte) error) error {
+	buf := make([]byte, 4096)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		cc.ptmxMutex.RLock()
+		if cc.ptmx == nil {
+			cc.ptmxMutex.RUnlock()
+			return io.EOF
+		}
+
+		// Wait with a timeout so cancellation is noticed, otherwise Read may block indefinitely
+		ready, err := waitForPTYReadable(int(cc.ptmx.Fd()), 250*time.Millisecond)
+		if err != nil {
+			cc.ptmxMutex.RUnlock()
+			return fmt.Errorf("PTY read wait failed: %w", err)
+		}
+		if !ready {
+			cc.ptmxMutex.RUnlock()
+			continue
+		}
+
+		n, err := cc.ptmx.Read(buf)
+		cc.ptmxMutex.RUnlock()
+		if err != nil {
+			// I/O errors are expected when the process is killed
+			if isEIO(err) {
+				return io.EOF
+			}
+			return err
+		}
+
+		if n > 0 {
+			if err := handle(buf[:n]); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// close disconnects from the console. It is idempotent.
+func (cc *conmanConnection) close() {
+	cc.closeOnce.Do(func() {
+		cc.ptmxMutex.RLock()
+		if cc.ptmx != nil {
+			// Graceful disconnect via the ConMan escape sequence
+			if _, err := cc.ptmx.Write([]byte("&.")); err != nil {
+				slog.Debug("Failed to write ConMan escape sequence", "nodeID", cc.nodeID, "error", err)
+			}
+		}
+		cc.ptmxMutex.RUnlock()
+
+		select {
+		case <-cc.exited:
+		case <-time.After(100 * time.Millisecond):
+			if err := cc.cmd.Process.Signal(syscall.SIGTERM); err != nil {
+				slog.Debug("Failed to signal conman process", "nodeID", cc.nodeID, "error", err)
+			}
+		}
+
+		cc.ptmxMutex.Lock()
+		if err := cc.ptmx.Close(); err != nil {
+			slog.Debug("Failed to close PTY", "nodeID", cc.nodeID, "error", err)
+		}
+		cc.ptmx = nil
+		cc.ptmxMutex.Unlock()
+	})
+}
@@ -5,24 +5,105 @@
 package console
 
 import (
+	"fmt"
+	"log/slog"
 	"net/http"
+	"slices"
 
+	"github.com/OpenCHAMI/remote-console/internal/creds"
 	"github.com/OpenCHAMI/remote-console/internal/nodes"
 )
 
 type ConsolesResponse struct {
 	Consoles []nodes.NodeConsoleInfo `json:"consoles"`
+	// Credentials is the level of the resolution chain each console's
+	// credentials came from, and MissingCredentials the consoles without
+	// any. Both are omitted until credentials have been resolved.
+	Credentials        map[string]creds.CredentialSource `json:"credentials,omitempty"`
+	MissingCredentials []string                          `json:"missingCredentials,omitempty"`
+	// HostKeyMismatches are the consoles whose BMC presented an unexpected
+	// SSH host key, which won't connect until the key is trusted or forgotten
+	HostKeyMismatches map[string]creds.HostKeyMismatch `json:"hostKeyMismatches,omitempty"`
+}
+
+// credentialReporter reports where the console credentials came from and
+// the consoles failing host key verification
+type credentialReporter interface {
+	CredentialSources() (map[string]creds.CredentialSource, bool)
+	HostKeyMismatches() map[string]creds.HostKeyMismatch
+}
+
+// hostKeyForgetter drops the host key pinned for a console's BMC
+type hostKeyForgetter interface {
+	ForgetHostKey(nci *nodes.NodeConsoleInfo) (bool, error)
 }
 
 // doConsoles handles the /consoles endpoint to list all available consoles
-func doConsoles(w http.ResponseWriter, r *http.Request) {
+func doConsoles(credentials credentialReporter, w http.ResponseWriter, r *http.Request) {
 	// get the current list of consoles
 	nodeList := nodes.CurrentNodes()
+	perms := permissionsFromContext(r.Context())
+	sources, resolved := credentials.CredentialSources()
+	mismatches := credentials.HostKeyMismatches()
 	var resp ConsolesResponse
 	for _, consoleInfo := range nodeList {
+		// only report the consoles the caller is allowed to see
+		if !perms.allowed(consoleInfo.ID, AccessList) {
+			continue
+		}
 		resp.Consoles = append(resp.Consoles, *consoleInfo)
+
+		if mismatch, ok := mismatches[consoleInfo.ID]; ok {
+			if resp.HostKeyMismatches == nil {
+				resp.HostKeyMismatches = make(map[string]creds.HostKeyMismatch)
+			}
+			resp.HostKeyMismatches[consoleInfo.ID] = mismatch
+		}
+
+		if !resolved {
+			continue
+		}
+		if source, ok := sources[consoleInfo.ID]; ok {
+			if resp.Credentials == nil {
+				resp.Credentials = make(map[string]creds.CredentialSource)
+			}
+			resp.Credentials[consoleInfo.ID] = source
+		} else {
+			resp.MissingCredentials = append(resp.MissingCredentials, consoleInfo.ID)
+		}
 	}
+	slices.Sort(resp.MissingCredentials)
 
 	// write the output
 	sendResponseJSON(w, http.StatusOK, resp)
 }
+
+// doForgetHostKey handles the /consoles/{nodeID}/hostkey endpoint to drop the
+// host key pinned for the console's BMC, after the BMC was replaced or rekeyed
+func doForgetHostKey(hostKeys hostKeyForgetter, w http.ResponseWriter, r *http.Request) {
+	defer drainAndCloseRequestBody(r)
+
+	nodeID, err := extractNodeId(r)
+	if err != nil {
+		sendJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	nci, ok := nodes.CurrentNodes()[nodeID]
+	if !ok {
+		sendJSONError(w, http.StatusNotFound, fmt.Sprintf("console %s not found", nodeID))
+		return
+	}
+
+	forgotten, err := hostKeys.ForgetHostKey(nci)
+	if err != nil {
+		slog.Error("Failed to forget host key", "nodeID", nodeID, "error", err)
+		sendJSONError(w, http.StatusInternalServerError, fmt.Sprintf("failed to forget host key of %s", nodeID))
+		return
+	}
+	if !forgotten {
+		sendJSONError(w, http.StatusNotFound, fmt.Sprintf("no host key pinned for %s", nodeID))
+		return
+	}
+	slog.Info("Host key forgotten", "nodeID", nodeID, "user", requestUser(r))
+	w.WriteHeader(http.StatusNoContent)
+}
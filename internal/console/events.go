@@ -0,0 +1,62 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// This file contains the console watch events endpoint
+
+package console
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/OpenCHAMI/remote-console/internal/logs"
+)
+
+type EventsResponse struct {
+	Events []logs.WatchEvent `json:"events"`
+}
+
+// eventSource provides the recent console watch events
+type eventSource interface {
+	Events(filter logs.EventFilter) []logs.WatchEvent
+}
+
+// doEvents handles GET /events
+func doEvents(source eventSource, w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	filter := logs.EventFilter{
+		Node: params.Get("node"),
+		Rule: params.Get("rule"),
+	}
+
+	if since := params.Get("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			sendJSONError(w, http.StatusBadRequest, "Since parameter must be an RFC 3339 timestamp")
+			return
+		}
+		filter.Since = t
+	}
+
+	if limitParam := params.Get("limit"); limitParam != "" {
+		limit, err := strconv.Atoi(limitParam)
+		if err != nil || limit < 0 {
+			sendJSONError(w, http.StatusBadRequest, "Limit parameter must be a non-negative integer")
+			return
+		}
+		filter.Limit = limit
+	}
+
+	// Events carry console output, so only return those for nodes the caller may tail
+	perms := permissionsFromContext(r.Context())
+	filter.Accept = func(xname string) bool {
+		return perms.allowed(xname, AccessTail)
+	}
+
+	resp := EventsResponse{Events: []logs.WatchEvent{}}
+	resp.Events = append(resp.Events, source.Events(filter)...)
+
+	sendResponseJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,315 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// This file contains the expect style command execution endpoint
+
+package console
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/OpenCHAMI/remote-console/internal/nodes"
+)
+
+const (
+	sessionModeExec = "exec"
+
+	defaultExecStepTimeout = 10 * time.Second
+	maxExecTimeout         = 5 * time.Minute
+	maxExecSteps           = 100
+	maxExecRequestSize     = 1024 * 1024
+	maxExecTranscriptSize  = 1024 * 1024
+)
+
+// ExecStep sends input to the console and optionally waits for output
+// matching a regular expression
+type ExecStep struct {
+	Send    string `json:"send,omitempty"`
+	Expect  string `json:"expect,omitempty"`
+	Timeout string `json:"timeout,omitempty"` // Go duration, defaults to 10s
+}
+
+// ExecRequest is the body of POST /consoles/{nodeID}/exec
+type ExecRequest struct {
+	Steps   []ExecStep `json:"steps"`
+	Timeout string     `json:"timeout,omitempty"` // overall limit, defaults to the sum of the step timeouts
+}
+
+// ExecStepResult reports the outcome of a single step
+type ExecStepResult struct {
+	Index   int      `json:"index"`
+	Send    string   `json:"send,omitempty"`
+	Expect  string   `json:"expect,omitempty"`
+	Matched bool     `json:"matched"`
+	Match   string   `json:"match,omitempty"`
+	Groups  []string `json:"groups,omitempty"`
+	Output  string   `json:"output"`
+	Elapsed string   `json:"elapsed"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// ExecResponse is the result of POST /consoles/{nodeID}/exec
+type ExecResponse struct {
+	NodeID              string           `json:"nodeID"`
+	Success             bool             `json:"success"`
+	Steps               []ExecStepResult `json:"steps"`
+	Transcript          string           `json:"transcript"`
+	TranscriptTruncated bool             `json:"transcriptTruncated,omitempty"`
+}
+
+// compiledExecStep is a validated step ready to run
+type compiledExecStep struct {
+	ExecStep
+	expect  *regexp.Regexp
+	timeout time.Duration
+}
+
+// compileExecRequest validates the request and returns the steps along with the overall timeout
+func compileExecRequest(req ExecRequest) ([]compiledExecStep, time.Duration, error) {
+	if len(req.Steps) == 0 {
+		return nil, 0, fmt.Errorf("at least one step is required")
+	}
+	if len(req.Steps) > maxExecSteps {
+		return nil, 0, fmt.Errorf("at most %d steps are allowed", maxExecSteps)
+	}
+
+	var total time.Duration
+	steps := make([]compiledExecStep, 0, len(req.Steps))
+	for i, step := range req.Steps {
+		if step.Send == "" && step.Expect == "" {
+			return nil, 0, fmt.Errorf("step %d has neither send nor expect", i)
+		}
+
+		cs := compiledExecStep{ExecStep: step, timeout: defaultExecStepTimeout}
+		if step.Timeout != "" {
+			d, err := time.ParseDuration(step.Timeout)
+			if err != nil || d <= 0 {
+				return nil, 0, fmt.Errorf("step %d has invalid timeout %q", i, step.Timeout)
+			}
+			cs.timeout = d
+		}
+		if step.Expect != "" {
+			re, err := regexp.Compile(step.Expect)
+			if err != nil {
+				return nil, 0, fmt.Errorf("step %d has invalid expect pattern: %w", i, err)
+			}
+			cs.expect = re
+		}
+
+		total += cs.timeout
+		steps = append(steps, cs)
+	}
+
+	if req.Timeout != "" {
+		d, err := time.ParseDuration(req.Timeout)
+		if err != nil || d <= 0 {
+			return nil, 0, fmt.Errorf("invalid timeout %q", req.Timeout)
+		}
+		total = d
+	}
+
+	return steps, min(total, maxExecTimeout), nil
+}
+
+// execTranscript collects all console output up to a maximum size
+type execTranscript struct {
+	buf       bytes.Buffer
+	truncated bool
+}
+
+func (t *execTranscript) append(data []byte) {
+	remaining := maxExecTranscriptSize - t.buf.Len()
+	if len(data) > remaining {
+		data = data[:remaining]
+		t.truncated = true
+	}
+	t.buf.Write(data)
+}
+
+// runExecSteps runs the steps in order, writing input to console and matching
+// against the chunks received from output. It stops at the first step whose
+// expectation is not met.
+func runExecSteps(ctx context.Context, console io.Writer, output <-chan []byte, steps []compiledExecStep, transcript *execTranscript) ([]ExecStepResult, bool) {
+	results := make([]ExecStepResult, 0, len(steps))
+
+	// Output received but not yet consumed by a match carries over to the next step
+	var pending []byte
+
+	for i, step := range steps {
+		start := time.Now()
+		result := ExecStepResult{Index: i, Send: step.Send, Expect: step.Expect}
+
+		finish := func(stepOutput []byte) {
+			result.Output = string(stepOutput)
+			result.Elapsed = time.Since(start).Round(time.Millisecond).String()
+			results = append(results, result)
+		}
+
+		if step.Send != "" {
+			if _, err := console.Write([]byte(step.Send)); err != nil {
+				result.Error = fmt.Sprintf("failed to write to console: %v", err)
+				finish(nil)
+				return results, false
+			}
+		}
+
+		if step.expect == nil {
+			result.Matched = true
+			finish(nil)
+			continue
+		}
+
+		timer := time.NewTimer(step.timeout)
+		stepOutput := pending
+		pending = nil
+		for {
+			if loc := step.expect.FindSubmatchIndex(stepOutput); loc != nil {
+				result.Matched = true
+				result.Match = string(stepOutput[loc[0]:loc[1]])
+				for g := 2; g+1 < len(loc); g += 2 {
+					if loc[g] >= 0 {
+						result.Groups = append(result.Groups, string(stepOutput[loc[g]:loc[g+1]]))
+					} else {
+						result.Groups = append(result.Groups, "")
+					}
+				}
+				pending = append([]byte(nil), stepOutput[loc[1]:]...)
+				stepOutput = stepOutput[:loc[1]]
+				break
+			}
+
+			select {
+			case data, ok := <-output:
+				if !ok {
+					result.Error = "console disconnected"
+				} else {
+					transcript.append(data)
+					stepOutput = append(stepOutput, data...)
+					continue
+				}
+			case <-timer.C:
+				result.Error = fmt.Sprintf("timed out after %s waiting for %q", step.timeout, step.Expect)
+			case <-ctx.Done():
+				result.Error = fmt.Sprintf("exec aborted: %v", context.Cause(ctx))
+			}
+			break
+		}
+		timer.Stop()
+		finish(stepOutput)
+
+		if !result.Matched {
+			return results, false
+		}
+	}
+
+	return results, true
+}
+
+func doExecConsole(sessions *interactiveSessions, registry *sessionRegistry, w http.ResponseWriter, r *http.Request) {
+	// Make sure the request is cleaned up
+	defer drainAndCloseRequestBody(r)
+
+	nodeID, err := extractNodeId(r)
+	if err != nil {
+		sendJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if !nodes.IsCurrentNode(nodeID) {
+		sendJSONError(w, http.StatusNotFound, "Node not found")
+		return
+	}
+
+	if !permissionsFromContext(r.Context()).allowed(nodeID, AccessInteractive) {
+		sendJSONError(w, http.StatusForbidden, fmt.Sprintf("Not authorized for interactive access to %s", nodeID))
+		return
+	}
+
+	var req ExecRequest
+	decoder := json.NewDecoder(io.LimitReader(r.Body, maxExecRequestSize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		sendJSONError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+
+	steps, timeout, err := compileExecRequest(req)
+	if err != nil {
+		sendJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Exec uses the console like an interactive session, so it obeys the single writer reservation
+	if ok := sessions.reserve(nodeID); !ok {
+		sendJSONError(w, http.StatusConflict, fmt.Sprintf("Console %s is already in use", nodeID))
+		return
+	}
+	defer sessions.release(nodeID)
+
+	ctx, cancel := context.WithTimeoutCause(r.Context(), timeout, fmt.Errorf("exec timed out after %s", timeout))
+	defer cancel()
+
+	terminateCtx, terminate := context.WithCancelCause(ctx)
+	defer terminate(nil)
+	tracked := registry.register(nodeID, sessionModeExec, r, func(reason string) { terminate(fmt.Errorf("%s", reason)) })
+	defer registry.unregister(tracked)
+
+	slog.Info("Starting console exec", "nodeID", nodeID, "steps", len(steps), "timeout", timeout, "user", tracked.user)
+
+	conn, err := startConmanConnection(nodeID)
+	if err != nil {
+		slog.Error("Failed to connect to console for exec", "nodeID", nodeID, "error", err)
+		sendJSONError(w, http.StatusInternalServerError, "failed to connect to console")
+		return
+	}
+	defer conn.close()
+
+	output := make(chan []byte, 64)
+	go func() {
+		defer close(output)
+		err := conn.readOutput(terminateCtx, func(data []byte) error {
+			tracked.addBytesOut(len(data))
+			select {
+			case output <- append([]byte(nil), data...):
+				return nil
+			case <-terminateCtx.Done():
+				return terminateCtx.Err()
+			}
+		})
+		slog.Debug("Exec output stream ended", "nodeID", nodeID, "error", err)
+	}()
+
+	var transcript execTranscript
+	results, success := runExecSteps(terminateCtx, countingWriter{conn, tracked}, output, steps, &transcript)
+
+	slog.Info("Console exec completed", "nodeID", nodeID, "success", success, "stepsRun", len(results))
+
+	sendResponseJSON(w, http.StatusOK, ExecResponse{
+		NodeID:              nodeID,
+		Success:             success,
+		Steps:               results,
+		Transcript:          transcript.buf.String(),
+		TranscriptTruncated: transcript.truncated,
+	})
+}
+
+// countingWriter records the bytes written to the console against the tracked session
+type countingWriter struct {
+	w       io.Writer
+	tracked *trackedSession
+}
+
+func (cw countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.tracked.addBytesIn(n)
+	return n, err
+}
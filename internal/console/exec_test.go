@@ -0,0 +1,87 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package console
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConsole replies to known input with canned output
+type fakeConsole struct {
+	output  chan []byte
+	replies map[string][]string
+}
+
+func (fc *fakeConsole) Write(p []byte) (int, error) {
+	for _, reply := range fc.replies[string(p)] {
+		fc.output <- []byte(reply)
+	}
+	return len(p), nil
+}
+
+func TestRunExecSteps(t *testing.T) {
+	console := &fakeConsole{
+		output: make(chan []byte, 16),
+		replies: map[string][]string{
+			"\r":       {"\r\nlogin: "},
+			"root\r":   {"root\r\nPass", "word: "},
+			"secret\r": {"\r\nLast login: today\r\n", "[root@nid001 ~]# "},
+		},
+	}
+
+	steps, timeout, err := compileExecRequest(ExecRequest{Steps: []ExecStep{
+		{Send: "\r", Expect: "login: $"},
+		{Send: "root\r", Expect: "Password: $"},
+		{Send: "secret\r", Expect: `\[root@(\w+) ~\]# $`},
+	}})
+	require.NoError(t, err)
+	require.Equal(t, 30*time.Second, timeout)
+
+	var transcript execTranscript
+	results, success := runExecSteps(context.Background(), console, console.output, steps, &transcript)
+	require.True(t, success)
+	require.Len(t, results, 3)
+	require.Equal(t, "Password: ", results[1].Match)
+	require.Equal(t, []string{"nid001"}, results[2].Groups)
+	require.True(t, strings.HasSuffix(transcript.buf.String(), "[root@nid001 ~]# "))
+}
+
+func TestRunExecStepsTimeout(t *testing.T) {
+	console := &fakeConsole{output: make(chan []byte, 16), replies: map[string][]string{"\r": {"nothing useful"}}}
+
+	steps, _, err := compileExecRequest(ExecRequest{Steps: []ExecStep{
+		{Send: "\r", Expect: "login:", Timeout: "50ms"},
+		{Send: "root\r"},
+	}})
+	require.NoError(t, err)
+
+	var transcript execTranscript
+	results, success := runExecSteps(context.Background(), console, console.output, steps, &transcript)
+	require.False(t, success)
+	require.Len(t, results, 1, "steps after a failed expectation must not run")
+	require.False(t, results[0].Matched)
+	require.Contains(t, results[0].Error, "timed out")
+	require.Equal(t, "nothing useful", results[0].Output)
+}
+
+func TestCompileExecRequestInvalid(t *testing.T) {
+	for name, req := range map[string]ExecRequest{
+		"no steps":        {},
+		"empty step":      {Steps: []ExecStep{{}}},
+		"bad pattern":     {Steps: []ExecStep{{Expect: "("}}},
+		"bad timeout":     {Steps: []ExecStep{{Send: "x", Timeout: "soon"}}},
+		"bad total limit": {Steps: []ExecStep{{Send: "x"}}, Timeout: "-1s"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := compileExecRequest(req)
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// This file contains the time range and pattern filters applied to console
+// log lines sent by tail sessions
+
+package console
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/OpenCHAMI/remote-console/internal/logs"
+)
+
+// lineFilter selects console log lines by time range and pattern. Lines
+// without a timestamp of their own take the time of the last line that had
+// one, so a filter must see every line of the log in order. A nil filter
+// accepts every line.
+type lineFilter struct {
+	since   time.Time
+	until   time.Time
+	grep    *regexp.Regexp
+	exclude *regexp.Regexp
+
+	current time.Time // time of the most recent timestamped line
+}
+
+// parseFilterTime accepts an RFC 3339 timestamp or a duration before now
+func parseFilterTime(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return time.Time{}, fmt.Errorf("must be an RFC 3339 timestamp or a positive duration")
+	}
+	return now.Add(-d), nil
+}
+
+// parseLineFilter builds a filter from the since, until, grep and exclude
+// query parameters. It returns nil when none are set.
+func parseLineFilter(params url.Values) (*lineFilter, error) {
+	var f lineFilter
+	now := time.Now()
+	active := false
+
+	if since := params.Get("since"); since != "" {
+		t, err := parseFilterTime(since, now)
+		if err != nil {
+			return nil, fmt.Errorf("Since parameter %s", err)
+		}
+		f.since = t
+		active = true
+	}
+
+	if until := params.Get("until"); until != "" {
+		t, err := parseFilterTime(until, now)
+		if err != nil {
+			return nil, fmt.Errorf("Until parameter %s", err)
+		}
+		f.until = t
+		active = true
+	}
+
+	if !f.since.IsZero() && !f.until.IsZero() && f.until.Before(f.since) {
+		return nil, fmt.Errorf("Until parameter must not be before since")
+	}
+
+	if grep := params.Get("grep"); grep != "" {
+		re, err := regexp.Compile(grep)
+		if err != nil {
+			return nil, fmt.Errorf("Grep parameter is not a valid regular expression: %w", err)
+		}
+		f.grep = re
+		active = true
+	}
+
+	if exclude := params.Get("exclude"); exclude != "" {
+		re, err := regexp.Compile(exclude)
+		if err != nil {
+			return nil, fmt.Errorf("Exclude parameter is not a valid regular expression: %w", err)
+		}
+		f.exclude = re
+		active = true
+	}
+
+	if !active {
+		return nil, nil
+	}
+	return &f, nil
+}
+
+// accept records the timestamp of line, if it has one, and reports whether
+// the line passes the filter. With a time range set, lines before the first
+// timestamp in the log are rejected since their time is unknown.
+func (f *lineFilter) accept(line string) bool {
+	if f == nil {
+		return true
+	}
+
+	if ts, ok := logs.ParseLineTimestamp(line); ok {
+		f.current = ts
+	}
+
+	if !f.since.IsZero() || !f.until.IsZero() {
+		if f.current.IsZero() {
+			return false
+		}
+		if !f.since.IsZero() && f.current.Before(f.since) {
+			return false
+		}
+		if !f.until.IsZero() && f.current.After(f.until) {
+			return false
+		}
+	}
+
+	if f.grep != nil && !f.grep.MatchString(line) {
+		return false
+	}
+	if f.exclude != nil && f.exclude.MatchString(line) {
+		return false
+	}
+	return true
+}
+
+// reset starts the filter over for a log read on its own, without a
+// timestamp carried over from the log before it
+func (f *lineFilter) reset() {
+	if f != nil {
+		f.current = time.Time{}
+	}
+}
+
+// clone returns a filter with the same criteria and state
+func (f *lineFilter) clone() *lineFilter {
+	if f == nil {
+		return nil
+	}
+	clone := *f
+	return &clone
+}
+
+// expired reports whether the log has passed the end of the time range, after
+// which no further line can be accepted
+func (f *lineFilter) expired() bool {
+	return f != nil && !f.until.IsZero() && f.current.After(f.until)
+}
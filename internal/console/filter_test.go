@@ -0,0 +1,117 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package console
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testTimestampedLog = `before any timestamp
+2026-03-04 05:00:00 UTC [    0.000000] Linux version 6.1.0
+2026-03-04 05:00:01 UTC EDAC MC0: 1 CE error
+continuation without timestamp
+<ConMan> Console [x1000c0s0b0n0] log at 2026-03-04 06:00:00 UTC.
+2026-03-04 06:00:05 UTC login:
+2026-03-04 07:00:00 UTC EDAC MC0: 2 CE errors
+`
+
+func TestParseLineFilter(t *testing.T) {
+	filter, err := parseLineFilter(url.Values{})
+	require.NoError(t, err)
+	require.Nil(t, filter)
+
+	filter, err = parseLineFilter(url.Values{"since": {"2026-03-04T05:00:00Z"}, "until": {"1h"}})
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 3, 4, 5, 0, 0, 0, time.UTC), filter.since.UTC())
+	require.WithinDuration(t, time.Now().Add(-time.Hour), filter.until, time.Minute)
+
+	invalid := []url.Values{
+		{"since": {"yesterday"}},
+		{"until": {"-1h"}},
+		{"since": {"1h"}, "until": {"2h"}},
+		{"grep": {"("}},
+		{"exclude": {"["}},
+	}
+	for _, params := range invalid {
+		_, err := parseLineFilter(params)
+		require.Error(t, err, params.Encode())
+	}
+}
+
+func TestLineFilterAccept(t *testing.T) {
+	filter := &lineFilter{
+		since:   time.Date(2026, 3, 4, 5, 0, 1, 0, time.UTC),
+		until:   time.Date(2026, 3, 4, 6, 30, 0, 0, time.UTC),
+		exclude: regexp.MustCompile("login"),
+	}
+
+	var accepted []string
+	var expired bool
+	for _, line := range strings.Split(strings.TrimSuffix(testTimestampedLog, "\n"), "\n") {
+		if filter.accept(line) {
+			accepted = append(accepted, line)
+		}
+		expired = filter.expired()
+	}
+
+	require.Equal(t, []string{
+		"2026-03-04 05:00:01 UTC EDAC MC0: 1 CE error",
+		"continuation without timestamp",
+		"<ConMan> Console [x1000c0s0b0n0] log at 2026-03-04 06:00:00 UTC.",
+	}, accepted)
+	require.True(t, expired)
+
+	var nilFilter *lineFilter
+	require.True(t, nilFilter.accept("anything"))
+	require.False(t, nilFilter.expired())
+}
+
+func TestTailConsoleFiltered(t *testing.T) {
+	nodeID := "x1000c0s0b0n0"
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "console."+nodeID), []byte(testTimestampedLog), 0600))
+
+	params := url.Values{"grep": {"EDAC"}, "since": {"2026-03-04T05:00:00Z"}, "follow": {"true"}, "until": {"2026-03-04T06:30:00Z"}}
+	for _, lines := range []string{"", "10"} {
+		if lines != "" {
+			params.Set("lines", lines)
+		}
+		opts, err := parseTailOptions(params)
+		require.NoError(t, err)
+
+		out := &recordingTailOutput{done: make(chan struct{})}
+		session := newConsoleTailSession(dir, nil, nodeID, out)
+
+		// The range has ended, so the session finishes without following
+		session.tailConsole(context.Background(), opts)
+		require.Equal(t, []string{"2026-03-04 05:00:01 UTC EDAC MC0: 1 CE error\n"}, out.lines, "lines=%q", lines)
+	}
+}
+
+// recordingTailOutput collects the lines sent by a tail session
+type recordingTailOutput struct {
+	lines []string
+	done  chan struct{}
+}
+
+func (o *recordingTailOutput) writeLine(line string, offset int64) error {
+	o.lines = append(o.lines, line)
+	return nil
+}
+
+func (o *recordingTailOutput) writeNotice(text string) error { return nil }
+
+func (o *recordingTailOutput) close(reason sessionCloseReason, message string) { close(o.done) }
+
+func (o *recordingTailOutput) Done() <-chan struct{} { return o.done }
@@ -12,13 +12,34 @@ import (
 	"log/slog"
 	"net/http"
 
+	"github.com/OpenCHAMI/remote-console/internal/creds"
+	"github.com/OpenCHAMI/remote-console/internal/logs"
+	"github.com/OpenCHAMI/remote-console/internal/metrics"
 	"github.com/OpenCHAMI/remote-console/internal/nodes"
 )
 
 // HealthResponse - used to report service health stats
 type HealthResponse struct {
-	NumberConsoles     string `json:"consoles"`
-	LastHardwareUpdate string `json:"hardwareupdate"`
+	NumberConsoles     string           `json:"consoles"`
+	LastHardwareUpdate string           `json:"hardwareupdate"`
+	Disk               *logs.DiskStatus `json:"disk,omitempty"` // omitted until the first disk check
+
+	// The outcome of the last credential check, omitted until the first one
+	Credentials *creds.CredentialChanges `json:"credentials,omitempty"`
+	// The SSH certificates in use by key, omitted when there are none
+	Certificates map[string]creds.CertificateStatus `json:"certificates,omitempty"`
+}
+
+// diskReporter reports the disk space used and left for the console logs
+type diskReporter interface {
+	DiskStatus() (logs.DiskStatus, bool)
+}
+
+// credentialCheckReporter reports the outcome of the last credential check
+// and the SSH certificates in use
+type credentialCheckReporter interface {
+	LastCredentialCheck() (creds.CredentialChanges, bool)
+	Certificates() map[string]creds.CertificateStatus
 }
 
 type errorResponse struct {
@@ -39,7 +60,7 @@ func sendJSONError(w http.ResponseWriter, ecode int, message string) {
 }
 
 // Debugging information query
-func doHealth(w http.ResponseWriter, r *http.Request) {
+func doHealth(disk diskReporter, credentials credentialCheckReporter, w http.ResponseWriter, r *http.Request) {
 	// NOTE: this is provided as a quick check of the internal status for
 	//  administrators to aid in determining the health of this service.
 
@@ -52,23 +73,40 @@ func doHealth(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// get the current health status
-	stats := getCurrentHealth()
+	stats := getCurrentHealth(disk, credentials)
 
 	// log the query
-	slog.Debug("Health check", "consoles", stats.NumberConsoles, "lastUpdate", stats.LastHardwareUpdate)
+	slog.Debug("Health check", "consoles", stats.NumberConsoles, "lastUpdate", stats.LastHardwareUpdate, "lowSpace", stats.Disk != nil && stats.Disk.LowSpace)
 
 	// write the output
 	sendResponseJSON(w, http.StatusOK, stats)
 }
 
 // Fill out the current status of a HealthResponse object
-func getCurrentHealth() HealthResponse {
+func getCurrentHealth(disk diskReporter, credentials credentialCheckReporter) HealthResponse {
 	var stats HealthResponse
 	stats.LastHardwareUpdate = nodes.GetHardwareUpdateTime()
 	stats.NumberConsoles = fmt.Sprintf("%d", len(nodes.CurrentNodes()))
+	if status, ok := disk.DiskStatus(); ok {
+		stats.Disk = &status
+	}
+	if check, ok := credentials.LastCredentialCheck(); ok {
+		stats.Credentials = &check
+	}
+	if certificates := credentials.Certificates(); len(certificates) > 0 {
+		stats.Certificates = certificates
+	}
 	return stats
 }
 
+// Prometheus metrics of the registered collectors
+func doMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := metrics.Write(w); err != nil {
+		slog.Warn("Failed to write metrics", "error", err)
+	}
+}
+
 // Basic liveness probe
 func doLiveness(w http.ResponseWriter, r *http.Request) {
 	// NOTE: this is coded in accordance with kubernetes best practices
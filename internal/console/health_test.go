@@ -0,0 +1,109 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package console
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	compcreds "github.com/Cray-HPE/hms-compcredentials"
+	"github.com/stretchr/testify/require"
+
+	"github.com/OpenCHAMI/remote-console/internal/creds"
+	"github.com/OpenCHAMI/remote-console/internal/logs"
+	"github.com/OpenCHAMI/remote-console/internal/metrics"
+)
+
+// fixedDiskReporter reports a fixed disk status
+type fixedDiskReporter struct {
+	status  logs.DiskStatus
+	checked bool
+}
+
+func (d fixedDiskReporter) DiskStatus() (logs.DiskStatus, bool) {
+	return d.status, d.checked
+}
+
+// fixedCredentialCheck reports a fixed credential check and certificates
+type fixedCredentialCheck struct {
+	changes      creds.CredentialChanges
+	checked      bool
+	certificates map[string]creds.CertificateStatus
+}
+
+func (c fixedCredentialCheck) LastCredentialCheck() (creds.CredentialChanges, bool) {
+	return c.changes, c.checked
+}
+
+func (c fixedCredentialCheck) Certificates() map[string]creds.CertificateStatus {
+	return c.certificates
+}
+
+func TestDoHealthDisk(t *testing.T) {
+	rec := httptest.NewRecorder()
+	doHealth(fixedDiskReporter{}, fixedCredentialCheck{}, rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NotContains(t, rec.Body.String(), `"disk"`)
+
+	reporter := fixedDiskReporter{status: logs.DiskStatus{UsedBytes: 4096, LowSpace: true}, checked: true}
+	rec = httptest.NewRecorder()
+	doHealth(reporter, fixedCredentialCheck{}, rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp HealthResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.NotNil(t, resp.Disk)
+	require.True(t, resp.Disk.LowSpace)
+	require.Equal(t, int64(4096), resp.Disk.UsedBytes)
+}
+
+func TestDoHealthCredentials(t *testing.T) {
+	rec := httptest.NewRecorder()
+	doHealth(fixedDiskReporter{}, fixedCredentialCheck{}, rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NotContains(t, rec.Body.String(), `"credentials"`)
+	require.NotContains(t, rec.Body.String(), `"certificates"`)
+
+	expiresAt := time.Date(2026, 1, 3, 3, 4, 5, 0, time.UTC)
+	check := fixedCredentialCheck{changes: creds.CredentialChanges{
+		CheckedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Checked:   3,
+		Nodes:     []string{"x0c0s1b0"},
+		SharedKey: true,
+		Passwords: map[string]compcreds.CompCredentials{"x0c0s1b0": {Username: "admin", Password: "secret"}},
+	}, checked: true, certificates: map[string]creds.CertificateStatus{
+		creds.SharedCertificate: {KeyID: "console", Principals: []string{"root"}, ExpiresAt: &expiresAt, ExpiresSoon: true},
+	}}
+	rec = httptest.NewRecorder()
+	doHealth(fixedDiskReporter{}, check, rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NotContains(t, rec.Body.String(), "secret")
+
+	var resp HealthResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.NotNil(t, resp.Credentials)
+	require.Equal(t, 3, resp.Credentials.Checked)
+	require.Equal(t, []string{"x0c0s1b0"}, resp.Credentials.Nodes)
+	require.True(t, resp.Credentials.SharedKey)
+	require.Equal(t, check.changes.CheckedAt, resp.Credentials.CheckedAt)
+	require.Equal(t, []string{"root"}, resp.Certificates[creds.SharedCertificate].Principals)
+	require.Equal(t, expiresAt, *resp.Certificates[creds.SharedCertificate].ExpiresAt)
+	require.True(t, resp.Certificates[creds.SharedCertificate].ExpiresSoon)
+}
+
+func TestDoMetrics(t *testing.T) {
+	metrics.Register(func() []metrics.Metric {
+		return []metrics.Metric{{Name: "remote_console_test_up", Help: "Test metric.", Type: metrics.TypeGauge, Samples: []metrics.Sample{{Value: 1}}}}
+	})
+
+	rec := httptest.NewRecorder()
+	doMetrics(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Header().Get("Content-Type"), "text/plain")
+	require.Contains(t, rec.Body.String(), "remote_console_test_up 1\n")
+}
@@ -0,0 +1,47 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// This file contains the endpoint verifying console logs against their hash chains
+
+package console
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/OpenCHAMI/remote-console/internal/logs"
+)
+
+// integrityVerifier checks console logs against their hash chains
+type integrityVerifier interface {
+	VerifyIntegrity(consoleLogsPath, xname string) (logs.IntegrityReport, error)
+}
+
+// doVerifyIntegrity handles GET /consoles/{nodeID}/integrity
+func doVerifyIntegrity(consoleLogsPath string, verifier integrityVerifier, w http.ResponseWriter, r *http.Request) {
+	nodeID, ok := checkLogAccess(w, r)
+	if !ok {
+		return
+	}
+
+	report, err := verifier.VerifyIntegrity(consoleLogsPath, nodeID)
+	switch {
+	case errors.Is(err, logs.ErrIntegrityDisabled):
+		sendJSONError(w, http.StatusNotImplemented, err.Error())
+		return
+	case errors.Is(err, logs.ErrNoIntegrityChain):
+		sendJSONError(w, http.StatusNotFound, err.Error())
+		return
+	case err != nil:
+		slog.Error("Failed to verify console log integrity", "nodeID", nodeID, "error", err)
+		sendJSONError(w, http.StatusInternalServerError, "failed to verify console logs")
+		return
+	}
+
+	if !report.Verified {
+		slog.Warn("Console log failed integrity verification", "nodeID", nodeID, "problems", len(report.Problems))
+	}
+	sendResponseJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,37 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package console
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/OpenCHAMI/remote-console/internal/logs"
+)
+
+// unusedVerifier fails the test if the handler reaches it
+type unusedVerifier struct{ t *testing.T }
+
+func (v unusedVerifier) VerifyIntegrity(consoleLogsPath, xname string) (logs.IntegrityReport, error) {
+	v.t.Fatal("unexpected verification")
+	return logs.IntegrityReport{}, nil
+}
+
+func TestIntegrityRejectsUnknownNodes(t *testing.T) {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("nodeID", "x1000c0s0b0n0")
+
+	req := httptest.NewRequest(http.MethodGet, "/consoles/x1000c0s0b0n0/integrity", nil)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	rec := httptest.NewRecorder()
+	doVerifyIntegrity("", unusedVerifier{t}, rec, req)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -13,6 +13,7 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
+	"strconv"
 	"sync"
 	"syscall"
 	"time"
@@ -25,6 +26,9 @@ import (
 	"github.com/nxadm/tail/ratelimiter"
 )
 
+// takeOverTimeout bounds how long a forced session waits for the previous session to close
+const takeOverTimeout = 5 * time.Second
+
 // interactiveSessions tracks which nodes currently have an active
 // interactive console session, ensuring at most one session per node.
 type interactiveSessions struct {
@@ -72,6 +76,7 @@ type interactiveConsoleSession struct {
 	cancel context.CancelFunc
 
 	ws            *webSocketSession        // WebSocket session
+	tracked       *trackedSession          // Registry entry used for session administration
 	rateLimiter   *ratelimiter.LeakyBucket // Rate limit console output
 	wg            sync.WaitGroup           // Tracks all goroutines
 	processExited chan struct{}            // Closed when current conman process exits
@@ -132,6 +137,15 @@ func (s *interactiveConsoleSession) closeWithReason(reason sessionCloseReason, m
 	slog.Info("Close completed for console session", "nodeID", s.nodeID)
 }
 
+// terminate notifies the user of the reason and closes the session
+func (s *interactiveConsoleSession) terminate(reason string) {
+	msg := fmt.Sprintf("\r\n[Session terminated: %s]\r\n", reason)
+	if err := s.ws.Write(websocket.TextMessage, []byte(msg)); err != nil {
+		slog.Debug("Failed to send termination notice", "nodeID", s.nodeID, "error", err)
+	}
+	s.closeWithReason(sessionCloseNormal, closeMessageText(reason))
+}
+
 // monitorProcess watches for process exit (conman) and attempts reconnection if node still exists
 // This runs in a loop, monitoring each new process after successful reconnection
 func (s *interactiveConsoleSession) monitorProcess(ctx context.Context) {
@@ -332,6 +346,7 @@ func (s *interactiveConsoleSession) streamOutput(ctx context.Context) {
 				slog.Info("WebSocket write failed", "nodeID", s.nodeID, "error", err)
 				return
 			}
+			s.tracked.addBytesOut(n)
 		}
 	}
 }
@@ -374,8 +389,9 @@ func (s *interactiveConsoleSession) streamInput(ctx context.Context) {
 				continue
 			}
 
-			_, err := s.ptmx.Write(message)
+			n, err := s.ptmx.Write(message)
 			s.ptmxMutex.RUnlock()
+			s.tracked.addBytesIn(n)
 
 			if err != nil {
 				slog.Error("Failed to write to PTY", "nodeID", s.nodeID, "error", err)
@@ -432,7 +448,33 @@ func newInteractiveConsoleSession(nodeID string, conn *websocket.Conn) *interact
 	return session
 }
 
-func doInteractiveConsole(sessions *interactiveSessions, w http.ResponseWriter, r *http.Request) {
+// reservingModes are the session modes that hold a node's single writer
+// reservation
+var reservingModes = []string{sessionModeInteractive, sessionModeBroadcast, sessionModeExec}
+
+// takeOverSession terminates the sessions holding nodeID's reservation,
+// whatever their mode, and reserves the node once it has been released. It
+// returns false if the reservation could not be obtained in time.
+func takeOverSession(sessions *interactiveSessions, registry *sessionRegistry, nodeID, user string) bool {
+	reason := "console taken over"
+	if user != "" {
+		reason = fmt.Sprintf("console taken over by %s", user)
+	}
+	slog.Info("Forcing takeover of console", "nodeID", nodeID, "user", user)
+	registry.terminateNode(nodeID, reservingModes, reason)
+
+	deadline := time.Now().Add(takeOverTimeout)
+	for time.Now().Before(deadline) {
+		if sessions.reserve(nodeID) {
+			return true
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	slog.Warn("Timed out waiting for console session to release", "nodeID", nodeID)
+	return false
+}
+
+func doInteractiveConsole(sessions *interactiveSessions, registry *sessionRegistry, w http.ResponseWriter, r *http.Request) {
 	// Make sure the request is cleaned up
 	defer drainAndCloseRequestBody(r)
 
@@ -448,10 +490,26 @@ func doInteractiveConsole(sessions *interactiveSessions, w http.ResponseWriter,
 		return
 	}
 
-	if ok := sessions.reserve(nodeID); !ok {
-		http.Error(w, fmt.Sprintf("Console %s is already in use", nodeID), http.StatusConflict)
+	force := false
+	if forceParam := r.URL.Query().Get("force"); forceParam != "" {
+		force, err = strconv.ParseBool(forceParam)
+		if err != nil {
+			http.Error(w, "Force parameter must be a boolean value", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if force && !permissionsFromContext(r.Context()).allowed(nodeID, AccessForce) {
+		http.Error(w, fmt.Sprintf("Not authorized to force access to %s", nodeID), http.StatusForbidden)
 		return
 	}
+
+	if ok := sessions.reserve(nodeID); !ok {
+		if !force || !takeOverSession(sessions, registry, nodeID, requestUser(r)) {
+			http.Error(w, fmt.Sprintf("Console %s is already in use", nodeID), http.StatusConflict)
+			return
+		}
+	}
 	defer sessions.release(nodeID)
 
 	slog.Info("Starting interactive console session", "nodeID", nodeID)
@@ -468,6 +526,9 @@ func doInteractiveConsole(sessions *interactiveSessions, w http.ResponseWriter,
 	session := newInteractiveConsoleSession(nodeID, conn)
 	defer session.close() // Ensure cleanup always happens
 
+	session.tracked = registry.register(nodeID, sessionModeInteractive, r, session.terminate)
+	defer registry.unregister(session.tracked)
+
 	// Start session (blocks until all goroutines complete)
 	session.Start(r.Context())
 
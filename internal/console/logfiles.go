@@ -0,0 +1,378 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// This file contains the console log file listing, download and export endpoints
+
+package console
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/OpenCHAMI/remote-console/internal/logs"
+	"github.com/OpenCHAMI/remote-console/internal/nodes"
+)
+
+const (
+	// maxExportNodes bounds the number of consoles in a single export bundle
+	maxExportNodes   = 1000
+	maxExportReqSize = 64 * 1024
+)
+
+type ConsoleLogsResponse struct {
+	NodeID string                    `json:"nodeID"`
+	Files  []logs.ConsoleLogFileInfo `json:"files"`
+}
+
+// ExportRequest is the body of POST /logs/export
+type ExportRequest struct {
+	Nodes string `json:"nodes"`           // hostlist expression
+	Since string `json:"since,omitempty"` // RFC 3339 timestamp or duration before now
+	Until string `json:"until,omitempty"`
+}
+
+// exportManifest is written to the bundle to describe its contents
+type exportManifest struct {
+	Created time.Time         `json:"created"`
+	User    string            `json:"user,omitempty"`
+	Since   *time.Time        `json:"since,omitempty"`
+	Until   *time.Time        `json:"until,omitempty"`
+	Files   []string          `json:"files"`
+	Skipped map[string]string `json:"skipped,omitempty"`
+}
+
+// checkLogAccess resolves the node of a log file request and checks the caller may read its logs
+func checkLogAccess(w http.ResponseWriter, r *http.Request) (string, bool) {
+	nodeID, err := extractNodeId(r)
+	if err != nil {
+		sendJSONError(w, http.StatusBadRequest, err.Error())
+		return "", false
+	}
+
+	if !nodes.IsCurrentNode(nodeID) {
+		sendJSONError(w, http.StatusNotFound, "Node not found")
+		return "", false
+	}
+
+	if !permissionsFromContext(r.Context()).allowed(nodeID, AccessTail) {
+		sendJSONError(w, http.StatusForbidden, fmt.Sprintf("Not authorized for tail access to %s", nodeID))
+		return "", false
+	}
+
+	return nodeID, true
+}
+
+// doListConsoleLogs handles GET /consoles/{nodeID}/logs
+func doListConsoleLogs(consoleLogsPath string, history logHistory, w http.ResponseWriter, r *http.Request) {
+	nodeID, ok := checkLogAccess(w, r)
+	if !ok {
+		return
+	}
+
+	files, err := history.ConsoleLogFiles(consoleLogsPath, nodeID)
+	if err != nil {
+		slog.Error("Failed to list console logs", "nodeID", nodeID, "error", err)
+		sendJSONError(w, http.StatusInternalServerError, "failed to list console logs")
+		return
+	}
+
+	resp := ConsoleLogsResponse{NodeID: nodeID, Files: []logs.ConsoleLogFileInfo{}}
+	for _, f := range files {
+		info, err := f.Info()
+		if errors.Is(err, os.ErrNotExist) {
+			// The live log doesn't exist until the console produces output
+			continue
+		}
+		if err != nil {
+			slog.Error("Failed to read console log", "file", f.Path, "error", err)
+			sendJSONError(w, http.StatusInternalServerError, "failed to read console logs")
+			return
+		}
+		resp.Files = append(resp.Files, info)
+	}
+
+	sendResponseJSON(w, http.StatusOK, resp)
+}
+
+// doDownloadConsoleLog handles GET /consoles/{nodeID}/logs/{fileName}, with Range support
+func doDownloadConsoleLog(consoleLogsPath string, history logHistory, w http.ResponseWriter, r *http.Request) {
+	nodeID, ok := checkLogAccess(w, r)
+	if !ok {
+		return
+	}
+
+	files, err := history.ConsoleLogFiles(consoleLogsPath, nodeID)
+	if err != nil {
+		slog.Error("Failed to list console logs", "nodeID", nodeID, "error", err)
+		sendJSONError(w, http.StatusInternalServerError, "failed to list console logs")
+		return
+	}
+
+	// Only files in the node's history may be served, which also rules out path traversal
+	fileName := chi.URLParam(r, "fileName")
+	idx := slices.IndexFunc(files, func(f logs.ConsoleLogFile) bool { return path.Base(f.Path) == fileName })
+	if idx < 0 {
+		sendJSONError(w, http.StatusNotFound, "Console log file not found")
+		return
+	}
+
+	file, err := os.Open(files[idx].Path)
+	if errors.Is(err, os.ErrNotExist) {
+		sendJSONError(w, http.StatusNotFound, "Console log file not found")
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to open console log", "file", files[idx].Path, "error", err)
+		sendJSONError(w, http.StatusInternalServerError, "failed to open console log")
+		return
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			slog.Warn("Failed to close console log file", "file", files[idx].Path, "error", err)
+		}
+	}()
+
+	stat, err := file.Stat()
+	if err != nil {
+		sendJSONError(w, http.StatusInternalServerError, "failed to open console log")
+		return
+	}
+
+	// Files are sent as stored so ranges and integrity seals hold: unless stored
+	// logs are redacted when rotated, they aren't redacted
+	switch {
+	case !files[idx].Compressed:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	case strings.HasSuffix(fileName, ".zst"):
+		w.Header().Set("Content-Type", "application/zstd")
+	default:
+		w.Header().Set("Content-Type", "application/gzip")
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+
+	http.ServeContent(w, r, fileName, stat.ModTime(), file)
+}
+
+// exportConsoleLog writes the lines of a node's log history accepted by filter to a
+// temporary file, since a tar entry needs its size before the content. Lines
+// are redacted before the filter sees them, as for tail sessions.
+func exportConsoleLog(f logs.ConsoleLogFile, filter *lineFilter, redact *logs.RedactStream, tmp *os.File) (int64, error) {
+	if err := tmp.Truncate(0); err != nil {
+		return 0, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	bw := bufio.NewWriter(tmp)
+	var size int64
+	_, err := scanLogFile(f, filter, redact, func(line tailLine) error {
+		n, err := bw.WriteString(line.text + "\n")
+		size += int64(n)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := bw.Flush(); err != nil {
+		return 0, err
+	}
+
+	_, err = tmp.Seek(0, io.SeekStart)
+	return size, err
+}
+
+// writeExportBundle writes a tar.gz bundle of the redacted log history of each node
+func writeExportBundle(w io.Writer, consoleLogsPath string, history logHistory, nodeIDs []string, since, until time.Time, manifest *exportManifest) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	tmp, err := os.CreateTemp("", "console-export-")
+	if err != nil {
+		return fmt.Errorf("failed to create export buffer: %w", err)
+	}
+	defer func() {
+		_ = tmp.Close()
+		if err := os.Remove(tmp.Name()); err != nil {
+			slog.Warn("Failed to remove export buffer", "file", tmp.Name(), "error", err)
+		}
+	}()
+
+	var redactor *logs.Redactor
+	if rs, ok := history.(redactorSource); ok {
+		redactor = rs.Redactor()
+	}
+
+	for _, nodeID := range nodeIDs {
+		files, err := history.ConsoleLogFiles(consoleLogsPath, nodeID)
+		if err != nil {
+			return fmt.Errorf("failed to list console logs for %s: %w", nodeID, err)
+		}
+		redact := redactor.Stream()
+
+		// One filter per node, so untimestamped lines inherit times across rotated files
+		var filter *lineFilter
+		if !since.IsZero() || !until.IsZero() {
+			filter = &lineFilter{since: since, until: until}
+		}
+
+		for _, f := range files {
+			stat, err := os.Stat(f.Path)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to export %q: %w", f.Path, err)
+			}
+
+			size, err := exportConsoleLog(f, filter, redact, tmp)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to export %q: %w", f.Path, err)
+			}
+			if size == 0 && filter != nil {
+				continue
+			}
+
+			// Rotated files are stored decompressed, the bundle itself is compressed
+			name := path.Join(nodeID, fmt.Sprintf("console.%s", nodeID))
+			if f.Rotation > 0 {
+				name = fmt.Sprintf("%s.%d", name, f.Rotation)
+			}
+
+			hdr := &tar.Header{
+				Name:    name,
+				Mode:    0644,
+				Size:    size,
+				ModTime: stat.ModTime(),
+			}
+			if err := tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+			if _, err := io.CopyN(tw, tmp, size); err != nil {
+				return err
+			}
+			manifest.Files = append(manifest.Files, name)
+		}
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: "manifest.json", Mode: 0644, Size: int64(len(data)), ModTime: manifest.Created}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(data); err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// doExportLogs handles POST /logs/export
+func doExportLogs(consoleLogsPath string, history logHistory, w http.ResponseWriter, r *http.Request) {
+	// Make sure the request is cleaned up
+	defer drainAndCloseRequestBody(r)
+
+	var req ExportRequest
+	decoder := json.NewDecoder(io.LimitReader(r.Body, maxExportReqSize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		sendJSONError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+
+	if req.Nodes == "" {
+		sendJSONError(w, http.StatusBadRequest, "nodes is required")
+		return
+	}
+	requested, err := nodes.ExpandHostlist(req.Nodes)
+	if err != nil {
+		sendJSONError(w, http.StatusBadRequest, fmt.Sprintf("Invalid nodes: %v", err))
+		return
+	}
+	if len(requested) > maxExportNodes {
+		sendJSONError(w, http.StatusBadRequest, fmt.Sprintf("Export is limited to %d consoles", maxExportNodes))
+		return
+	}
+
+	manifest := exportManifest{
+		Created: time.Now().UTC(),
+		User:    requestUser(r),
+		Files:   []string{},
+		Skipped: make(map[string]string),
+	}
+
+	var since, until time.Time
+	if req.Since != "" {
+		if since, err = parseFilterTime(req.Since, manifest.Created); err != nil {
+			sendJSONError(w, http.StatusBadRequest, fmt.Sprintf("since %v", err))
+			return
+		}
+		manifest.Since = &since
+	}
+	if req.Until != "" {
+		if until, err = parseFilterTime(req.Until, manifest.Created); err != nil {
+			sendJSONError(w, http.StatusBadRequest, fmt.Sprintf("until %v", err))
+			return
+		}
+		manifest.Until = &until
+	}
+
+	perms := permissionsFromContext(r.Context())
+	var accepted []string
+	for _, nodeID := range requested {
+		switch {
+		case !nodes.IsCurrentNode(nodeID):
+			manifest.Skipped[nodeID] = "node not found"
+		case !perms.allowed(nodeID, AccessTail):
+			manifest.Skipped[nodeID] = "not authorized"
+		default:
+			accepted = append(accepted, nodeID)
+		}
+	}
+
+	if len(accepted) == 0 {
+		status := http.StatusNotFound
+		if slices.ContainsFunc(requested, func(nodeID string) bool { return manifest.Skipped[nodeID] == "not authorized" }) {
+			status = http.StatusForbidden
+		}
+		sendResponseJSON(w, status, map[string]interface{}{"skipped": manifest.Skipped})
+		return
+	}
+
+	slog.Info("Exporting console logs", "nodes", len(accepted), "skipped", len(manifest.Skipped), "user", manifest.User)
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("console-logs-%s.tar.gz", manifest.Created.Format("20060102T150405Z"))))
+	w.WriteHeader(http.StatusOK)
+
+	// The status has been sent, so a failure can only be reported by ending the stream early
+	if err := writeExportBundle(w, consoleLogsPath, history, accepted, since, until, &manifest); err != nil {
+		slog.Error("Console log export failed", "error", err)
+		return
+	}
+
+	slog.Info("Console log export completed", "nodes", len(accepted), "files", len(manifest.Files))
+}
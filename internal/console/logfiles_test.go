@@ -0,0 +1,127 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package console
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/OpenCHAMI/remote-console/internal/logs"
+)
+
+// readBundle returns the contents of each file in a tar.gz bundle
+func readBundle(t *testing.T, data []byte) map[string]string {
+	gr, err := gzip.NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	tr := tar.NewReader(gr)
+
+	contents := make(map[string]string)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		content, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		contents[hdr.Name] = string(content)
+	}
+	return contents
+}
+
+func TestWriteExportBundle(t *testing.T) {
+	nodeID := "x1000c0s0b0n0"
+	dir, history := writeRotatedHistory(t, nodeID)
+
+	manifest := exportManifest{Created: time.Now().UTC(), Files: []string{}}
+	var buf bytes.Buffer
+	require.NoError(t, writeExportBundle(&buf, dir, history, []string{nodeID}, time.Time{}, time.Time{}, &manifest))
+
+	contents := readBundle(t, buf.Bytes())
+	require.Equal(t, "one\ntwo\n", contents["x1000c0s0b0n0/console.x1000c0s0b0n0.2"])
+	require.Equal(t, "three\n", contents["x1000c0s0b0n0/console.x1000c0s0b0n0.1"])
+	require.Equal(t, "four\nfive\n", contents["x1000c0s0b0n0/console.x1000c0s0b0n0"])
+
+	var written exportManifest
+	require.NoError(t, json.Unmarshal([]byte(contents["manifest.json"]), &written))
+	require.Len(t, written.Files, 3)
+}
+
+func TestWriteExportBundleRedaction(t *testing.T) {
+	nodeID := "x1000c0s0b0n0"
+	dir, history := writeRotatedHistory(t, nodeID)
+	require.NoError(t, os.WriteFile(history[2].Path, []byte("password=hunter2\nfive\n"), 0600))
+	redactor, err := logs.NewRedactor([]string{"password"})
+	require.NoError(t, err)
+
+	manifest := exportManifest{Created: time.Now().UTC(), Files: []string{}}
+	var buf bytes.Buffer
+	require.NoError(t, writeExportBundle(&buf, dir, redactedHistory{history, redactor}, []string{nodeID}, time.Time{}, time.Time{}, &manifest))
+
+	contents := readBundle(t, buf.Bytes())
+	require.Equal(t, "password=[REDACTED]\nfive\n", contents["x1000c0s0b0n0/console.x1000c0s0b0n0"])
+}
+
+func TestWriteExportBundleTimeRange(t *testing.T) {
+	nodeID := "x1000c0s0b0n0"
+	dir := t.TempDir()
+	live := filepath.Join(dir, "console."+nodeID)
+	require.NoError(t, os.WriteFile(live, []byte(testTimestampedLog), 0600))
+	history := staticHistory{{Path: live}}
+
+	since := time.Date(2026, 3, 4, 6, 0, 0, 0, time.UTC)
+	manifest := exportManifest{Created: time.Now().UTC(), Files: []string{}}
+	var buf bytes.Buffer
+	require.NoError(t, writeExportBundle(&buf, dir, history, []string{nodeID}, since, time.Time{}, &manifest))
+
+	contents := readBundle(t, buf.Bytes())
+	require.Equal(t, strings.Join([]string{
+		"<ConMan> Console [x1000c0s0b0n0] log at 2026-03-04 06:00:00 UTC.",
+		"2026-03-04 06:00:05 UTC login:",
+		"2026-03-04 07:00:00 UTC EDAC MC0: 2 CE errors",
+		"",
+	}, "\n"), contents["x1000c0s0b0n0/console.x1000c0s0b0n0"])
+
+	// Nothing in range means no file for the node
+	manifest = exportManifest{Created: time.Now().UTC(), Files: []string{}}
+	buf.Reset()
+	require.NoError(t, writeExportBundle(&buf, dir, history, []string{nodeID}, time.Now(), time.Time{}, &manifest))
+	require.Empty(t, manifest.Files)
+}
+
+func TestDoExportLogsRejectsRequests(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected int
+	}{
+		{"invalid json", "{", http.StatusBadRequest},
+		{"missing nodes", `{}`, http.StatusBadRequest},
+		{"invalid hostlist", `{"nodes": "x1000c0s[0-"}`, http.StatusBadRequest},
+		{"invalid since", `{"nodes": "x1000c0s0b0n0", "since": "yesterday"}`, http.StatusBadRequest},
+		{"unknown nodes", `{"nodes": "x1000c0s[0-1]b0n0"}`, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/logs/export", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			doExportLogs(t.TempDir(), staticHistory{}, rec, req)
+			require.Equal(t, tt.expected, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,219 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// This file contains console log streaming over plain HTTP, for clients that
+// can't use a WebSocket
+
+package console
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/OpenCHAMI/remote-console/internal/nodes"
+)
+
+// Log stream formats
+const (
+	logStreamFormatSSE  = "sse"
+	logStreamFormatText = "text"
+)
+
+// httpTailOutput streams tail output in the body of an HTTP response, either
+// as server-sent events or as chunked plain text
+type httpTailOutput struct {
+	w      http.ResponseWriter
+	rc     *http.ResponseController
+	sse    bool
+	mutex  sync.Mutex // Serializes writes, the session may be terminated from another goroutine
+	closed bool
+	done   chan struct{}
+}
+
+func newHTTPTailOutput(w http.ResponseWriter, sse bool) *httpTailOutput {
+	return &httpTailOutput{
+		w:    w,
+		rc:   http.NewResponseController(w),
+		sse:  sse,
+		done: make(chan struct{}),
+	}
+}
+
+// start sends the response headers so the client sees the stream open before the first line
+func (o *httpTailOutput) start() error {
+	if o.sse {
+		o.w.Header().Set("Content-Type", "text/event-stream")
+		o.w.Header().Set("Cache-Control", "no-cache")
+	} else {
+		o.w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	o.w.Header().Set("X-Content-Type-Options", "nosniff")
+	o.w.WriteHeader(http.StatusOK)
+	return o.rc.Flush()
+}
+
+// write sends data to the client and flushes it, doing nothing once the stream is closed
+func (o *httpTailOutput) write(data string) error {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if o.closed {
+		return fmt.Errorf("log stream closed")
+	}
+	if _, err := o.w.Write([]byte(data)); err != nil {
+		return err
+	}
+	return o.rc.Flush()
+}
+
+// sseEvent formats a server-sent event. Carriage returns and newlines end a
+// field in an event stream, so each segment of the data is sent as its own
+// data field and the client rejoins them with newlines.
+func sseEvent(event, id, data string) string {
+	var b strings.Builder
+	if event != "" {
+		fmt.Fprintf(&b, "event: %s\n", event)
+	}
+	if id != "" {
+		fmt.Fprintf(&b, "id: %s\n", id)
+	}
+	data = strings.TrimRight(data, "\r\n")
+	for _, segment := range strings.FieldsFunc(data, func(r rune) bool { return r == '\r' || r == '\n' }) {
+		fmt.Fprintf(&b, "data: %s\n", segment)
+	}
+	if data == "" {
+		b.WriteString("data:\n")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+func (o *httpTailOutput) writeLine(line string, offset int64) error {
+	if o.sse {
+		// Lines from rotated logs carry no ID since the stream can't resume into them
+		id := ""
+		if offset >= 0 {
+			id = strconv.FormatInt(offset, 10)
+		}
+		return o.write(sseEvent("", id, line))
+	}
+	return o.write(line)
+}
+
+func (o *httpTailOutput) writeNotice(text string) error {
+	if o.sse {
+		return o.write(sseEvent("notice", "", text))
+	}
+	return o.write(text)
+}
+
+func (o *httpTailOutput) close(reason sessionCloseReason, message string) {
+	// Only an event stream can tell the client why it ended
+	if o.sse && message != "" {
+		event := "close"
+		if reason == sessionCloseError {
+			event = "error"
+		}
+		if err := o.write(sseEvent(event, "", message)); err != nil {
+			slog.Debug("Failed to send log stream close event", "error", err)
+		}
+	}
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	if !o.closed {
+		o.closed = true
+		close(o.done)
+	}
+}
+
+func (o *httpTailOutput) Done() <-chan struct{} {
+	return o.done
+}
+
+// logStreamFormat picks the response format from the format parameter,
+// falling back to the Accept header
+func logStreamFormat(r *http.Request) (string, error) {
+	switch format := r.URL.Query().Get("format"); format {
+	case logStreamFormatSSE, logStreamFormatText:
+		return format, nil
+	case "":
+		if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+			return logStreamFormatSSE, nil
+		}
+		return logStreamFormatText, nil
+	default:
+		return "", fmt.Errorf("Invalid format parameter: %s (must be 'sse' or 'text')", format)
+	}
+}
+
+// doLogStream handles GET /consoles/{nodeID}/log
+func doLogStream(consoleLogsPath string, history logHistory, registry *sessionRegistry, w http.ResponseWriter, r *http.Request) {
+	// Make sure the request is cleaned up
+	defer drainAndCloseRequestBody(r)
+
+	nodeID, err := extractNodeId(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Make sure we are monitoring a valid node
+	if exists := nodes.IsCurrentNode(nodeID); !exists {
+		http.Error(w, "Node not found", http.StatusNotFound)
+		return
+	}
+
+	if !permissionsFromContext(r.Context()).allowed(nodeID, AccessTail) {
+		slog.Warn("Console access denied by policy", "nodeID", nodeID, "mode", AccessTail, "user", requestUser(r))
+		http.Error(w, fmt.Sprintf("Not authorized for tail access to %s", nodeID), http.StatusForbidden)
+		return
+	}
+
+	format, err := logStreamFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	opts, err := parseTailOptions(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// EventSource clients send the ID of the last event received when they reconnect
+	if lastEventID := r.Header.Get("Last-Event-ID"); lastEventID != "" && format == logStreamFormatSSE {
+		offset, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || offset < 0 {
+			http.Error(w, "Last-Event-ID must be a byte offset", http.StatusBadRequest)
+			return
+		}
+		opts.resumeOffset = offset
+	}
+
+	out := newHTTPTailOutput(w, format == logStreamFormatSSE)
+	if err := out.start(); err != nil {
+		slog.Error("Failed to start console log stream", "nodeID", nodeID, "error", err)
+		return
+	}
+
+	slog.Info("Client connected for console log stream", "remoteAddr", r.RemoteAddr, "nodeID", nodeID, "format", format)
+
+	session := newConsoleTailSession(consoleLogsPath, history, nodeID, out)
+
+	session.tracked = registry.register(nodeID, sessionModeTail, r, session.terminate)
+	defer registry.unregister(session.tracked)
+
+	// Nothing may be written to the response once the handler returns
+	defer session.close()
+
+	session.tailConsole(r.Context(), opts)
+
+	slog.Info("Console log stream ended", "nodeID", nodeID)
+}
@@ -0,0 +1,120 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package console
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/OpenCHAMI/remote-console/internal/logs"
+)
+
+const testConsoleLog = "first\nsecond\r\nthird\n"
+
+func writeTestConsoleLog(t *testing.T, nodeID string) string {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "console."+nodeID), []byte(testConsoleLog), 0600))
+	return dir
+}
+
+func TestSSEEvent(t *testing.T) {
+	require.Equal(t, "id: 6\ndata: first\n\n", sseEvent("", "6", "first\n"))
+	require.Equal(t, "event: notice\ndata: a\ndata: b\n\n", sseEvent("notice", "", "a\r\nb\n"))
+	require.Equal(t, "id: 1\ndata:\n\n", sseEvent("", "1", "\n"))
+}
+
+func TestReadLastNLinesOffsets(t *testing.T) {
+	dir := writeTestConsoleLog(t, "x1000c0s0b0n0")
+
+	lines, pos, err := readLastNLines([]logs.ConsoleLogFile{{Path: filepath.Join(dir, "console.x1000c0s0b0n0")}}, 2, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, []tailLine{
+		{text: "second", offset: 14, file: "console.x1000c0s0b0n0", start: 6},
+		{text: "third", offset: 20, file: "console.x1000c0s0b0n0", start: 14},
+	}, lines)
+	require.Equal(t, int64(len(testConsoleLog)), pos)
+}
+
+func TestLogStream(t *testing.T) {
+	nodeID := "x1000c0s0b0n0"
+	dir := writeTestConsoleLog(t, nodeID)
+
+	tests := []struct {
+		name     string
+		sse      bool
+		opts     tailOptions
+		expected string
+	}{
+		{
+			name:     "text history",
+			opts:     tailOptions{numLines: 2, resumeOffset: -1},
+			expected: "second\nthird\n",
+		},
+		{
+			name:     "sse history",
+			sse:      true,
+			opts:     tailOptions{numLines: 1, resumeOffset: -1},
+			expected: "id: 20\ndata: third\n\n",
+		},
+		{
+			name:     "sse whole log",
+			sse:      true,
+			opts:     tailOptions{numLines: -1, resumeOffset: -1},
+			expected: "id: 6\ndata: first\n\nid: 14\ndata: second\n\nid: 20\ndata: third\n\n",
+		},
+		{
+			name:     "sse resume ignores lines",
+			sse:      true,
+			opts:     tailOptions{numLines: 3, resumeOffset: 6},
+			expected: "id: 14\ndata: second\n\nid: 20\ndata: third\n\n",
+		},
+		{
+			name:     "resume past end of rotated log starts over",
+			opts:     tailOptions{numLines: -1, resumeOffset: 1000},
+			expected: "first\nsecond\r\nthird\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			out := newHTTPTailOutput(rec, tt.sse)
+			require.NoError(t, out.start())
+
+			session := newConsoleTailSession(dir, nil, nodeID, out)
+			session.tailConsole(context.Background(), tt.opts)
+
+			require.Equal(t, http.StatusOK, rec.Code)
+			require.Equal(t, tt.expected, rec.Body.String())
+			select {
+			case <-out.Done():
+			default:
+				t.Fatal("log stream was not closed")
+			}
+		})
+	}
+}
+
+func TestLogStreamFormat(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/consoles/x1000c0s0b0n0/log", nil)
+	format, err := logStreamFormat(req)
+	require.NoError(t, err)
+	require.Equal(t, logStreamFormatText, format)
+
+	req.Header.Set("Accept", "text/event-stream")
+	format, err = logStreamFormat(req)
+	require.NoError(t, err)
+	require.Equal(t, logStreamFormatSSE, format)
+
+	req = httptest.NewRequest(http.MethodGet, "/consoles/x1000c0s0b0n0/log?format=json", nil)
+	_, err = logStreamFormat(req)
+	require.Error(t, err)
+}
@@ -15,6 +15,9 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/gorilla/websocket"
 	openchami_authenticator "github.com/openchami/chi-middleware/auth"
+
+	"github.com/OpenCHAMI/remote-console/internal/creds"
+	"github.com/OpenCHAMI/remote-console/internal/logs"
 )
 
 const routePrefix = "/remote-console"
@@ -29,7 +32,7 @@ var upgrader = websocket.Upgrader{
 }
 
 // doConsole dispatches to either interactive or tail mode based on the mode query parameter
-func doConsole(consoleLogsPath string, sessions *interactiveSessions, w http.ResponseWriter, r *http.Request) {
+func doConsole(consoleLogsPath string, history logHistory, sessions *interactiveSessions, registry *sessionRegistry, w http.ResponseWriter, r *http.Request) {
 	// Parse mode parameter (defaults to "interactive")
 	params := r.URL.Query()
 	mode := params.Get("mode")
@@ -37,19 +40,31 @@ func doConsole(consoleLogsPath string, sessions *interactiveSessions, w http.Res
 		mode = "interactive"
 	}
 
+	if mode != "tail" && mode != "interactive" {
+		http.Error(w, fmt.Sprintf("Invalid mode parameter: %s (must be 'interactive' or 'tail')", mode), http.StatusBadRequest)
+		return
+	}
+
+	// Enforce the authorization policy for the requested node and mode
+	nodeID := chi.URLParam(r, "nodeID")
+	if perms := permissionsFromContext(r.Context()); !perms.allowed(nodeID, mode) {
+		slog.Warn("Console access denied by policy", "nodeID", nodeID, "mode", mode, "user", requestUser(r))
+		http.Error(w, fmt.Sprintf("Not authorized for %s access to %s", mode, nodeID), http.StatusForbidden)
+		return
+	}
+
 	switch mode {
 	case "tail":
-		doTailConsole(consoleLogsPath, w, r)
+		doTailConsole(consoleLogsPath, history, registry, w, r)
 	case "interactive":
-		doInteractiveConsole(sessions, w, r)
-	default:
-		http.Error(w, fmt.Sprintf("Invalid mode parameter: %s (must be 'interactive' or 'tail')", mode), http.StatusBadRequest)
+		doInteractiveConsole(sessions, registry, w, r)
 	}
 }
 
-func SetupRoutes(consoleLogsPath string) *chi.Mux {
+func SetupRoutes(consoleLogsPath string, logsService *logs.LogsService, credsService *creds.CredsService) *chi.Mux {
 	router := chi.NewRouter()
 	interactiveSessions := newInteractiveSessions()
+	registry := newSessionRegistry()
 
 	// Add common middleware
 	router.Use(middleware.RedirectSlashes)
@@ -58,7 +73,10 @@ func SetupRoutes(consoleLogsPath string) *chi.Mux {
 		// Public routes (no authentication required)
 		r.Get("/liveness", doLiveness)
 		r.Get("/readiness", doReadiness)
-		r.Get("/health", doHealth)
+		r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+			doHealth(logsService, credsService, w, r)
+		})
+		r.Get("/metrics", doMetrics)
 
 		// Protected routes - add to a sub-router with JWT middleware
 		r.Group(func(r chi.Router) {
@@ -72,9 +90,65 @@ func SetupRoutes(consoleLogsPath string) *chi.Mux {
 				slog.Warn("JWT authentication is disabled - all console endpoints are unprotected")
 			}
 
-			r.Get("/consoles", doConsoles)
+			// Per-node authorization, a no-op unless a policy is loaded
+			r.Use(authorize)
+
+			r.Get("/consoles", func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Query().Get("mode") == sessionModeBroadcast {
+					doBroadcastConsole(interactiveSessions, registry, w, r)
+					return
+				}
+				doConsoles(credsService, w, r)
+			})
 			r.Get("/consoles/{nodeID}", func(w http.ResponseWriter, r *http.Request) {
-				doConsole(consoleLogsPath, interactiveSessions, w, r)
+				doConsole(consoleLogsPath, logsService, interactiveSessions, registry, w, r)
+			})
+			r.Get("/consoles/{nodeID}/log", func(w http.ResponseWriter, r *http.Request) {
+				doLogStream(consoleLogsPath, logsService, registry, w, r)
+			})
+			r.Get("/consoles/{nodeID}/logs", func(w http.ResponseWriter, r *http.Request) {
+				doListConsoleLogs(consoleLogsPath, logsService, w, r)
+			})
+			r.Get("/consoles/{nodeID}/logs/{fileName}", func(w http.ResponseWriter, r *http.Request) {
+				doDownloadConsoleLog(consoleLogsPath, logsService, w, r)
+			})
+			r.Get("/consoles/{nodeID}/boots", func(w http.ResponseWriter, r *http.Request) {
+				doListBoots(consoleLogsPath, logsService, w, r)
+			})
+			r.Get("/consoles/{nodeID}/boots/{bootID}", func(w http.ResponseWriter, r *http.Request) {
+				doReadBoot(consoleLogsPath, logsService, w, r)
+			})
+			r.Get("/consoles/{nodeID}/integrity", func(w http.ResponseWriter, r *http.Request) {
+				doVerifyIntegrity(consoleLogsPath, logsService, w, r)
+			})
+			r.Get("/logs/stream", func(w http.ResponseWriter, r *http.Request) {
+				doAggregateStream(logsService, registry, w, r)
+			})
+			r.Post("/logs/export", func(w http.ResponseWriter, r *http.Request) {
+				doExportLogs(consoleLogsPath, logsService, w, r)
+			})
+			r.Get("/search", func(w http.ResponseWriter, r *http.Request) {
+				doSearch(consoleLogsPath, logsService, w, r)
+			})
+			r.Get("/events", func(w http.ResponseWriter, r *http.Request) {
+				doEvents(logsService, w, r)
+			})
+			r.Post("/consoles/{nodeID}/exec", func(w http.ResponseWriter, r *http.Request) {
+				doExecConsole(interactiveSessions, registry, w, r)
+			})
+
+			// Session and host key administration requires the admin claim
+			r.Group(func(r chi.Router) {
+				r.Use(requireAdmin)
+				r.Get("/sessions", func(w http.ResponseWriter, r *http.Request) {
+					doListSessions(registry, w, r)
+				})
+				r.Delete("/sessions/{sessionID}", func(w http.ResponseWriter, r *http.Request) {
+					doTerminateSession(registry, w, r)
+				})
+				r.Delete("/consoles/{nodeID}/hostkey", func(w http.ResponseWriter, r *http.Request) {
+					doForgetHostKey(credsService, w, r)
+				})
 			})
 		})
 	})
@@ -0,0 +1,102 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// This file contains the console log search endpoint
+
+package console
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/OpenCHAMI/remote-console/internal/logs"
+	"github.com/OpenCHAMI/remote-console/internal/nodes"
+)
+
+const (
+	defaultSearchLimit = 100
+	maxSearchLimit     = 1000
+)
+
+type SearchResponse struct {
+	Results   []logs.SearchResult `json:"results"`
+	Truncated bool                `json:"truncated"` // more lines matched than the limit
+}
+
+// logSearcher searches the indexed console logs
+type logSearcher interface {
+	Search(consoleLogsPath string, query logs.SearchQuery) ([]logs.SearchResult, bool, error)
+}
+
+// doSearch handles GET /search
+func doSearch(consoleLogsPath string, searcher logSearcher, w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	query := logs.SearchQuery{Text: params.Get("q"), Limit: defaultSearchLimit}
+	if query.Text == "" {
+		sendJSONError(w, http.StatusBadRequest, "Q parameter is required")
+		return
+	}
+
+	now := time.Now()
+	for _, p := range []struct {
+		name, label string
+		target      *time.Time
+	}{{"since", "Since", &query.Since}, {"until", "Until", &query.Until}} {
+		if value := params.Get(p.name); value != "" {
+			t, err := parseFilterTime(value, now)
+			if err != nil {
+				sendJSONError(w, http.StatusBadRequest, fmt.Sprintf("%s parameter %s", p.label, err))
+				return
+			}
+			*p.target = t
+		}
+	}
+
+	if limitParam := params.Get("limit"); limitParam != "" {
+		limit, err := strconv.Atoi(limitParam)
+		if err != nil || limit <= 0 {
+			sendJSONError(w, http.StatusBadRequest, "Limit parameter must be a positive integer")
+			return
+		}
+		query.Limit = min(limit, maxSearchLimit)
+	}
+
+	// Results carry console output, so only search nodes the caller may tail
+	perms := permissionsFromContext(r.Context())
+	var selected map[string]bool
+	if nodesParam := params.Get("nodes"); nodesParam != "" {
+		nodeIDs, err := nodes.ExpandHostlist(nodesParam)
+		if err != nil {
+			sendJSONError(w, http.StatusBadRequest, fmt.Sprintf("Invalid nodes parameter: %s", err))
+			return
+		}
+		selected = make(map[string]bool, len(nodeIDs))
+		for _, nodeID := range nodeIDs {
+			selected[nodeID] = true
+		}
+	}
+	query.Accept = func(xname string) bool {
+		return (selected == nil || selected[xname]) && perms.allowed(xname, AccessTail)
+	}
+
+	results, truncated, err := searcher.Search(consoleLogsPath, query)
+	switch {
+	case errors.Is(err, logs.ErrNoSearchWords):
+		sendJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	case errors.Is(err, logs.ErrSearchDisabled):
+		sendJSONError(w, http.StatusNotImplemented, err.Error())
+		return
+	case err != nil:
+		slog.Error("Console log search failed", "query", query.Text, "error", err)
+		sendJSONError(w, http.StatusInternalServerError, "Search failed")
+		return
+	}
+
+	sendResponseJSON(w, http.StatusOK, SearchResponse{Results: results, Truncated: truncated})
+}
@@ -0,0 +1,71 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package console
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/OpenCHAMI/remote-console/internal/logs"
+)
+
+// recordingSearcher records the query it was given
+type recordingSearcher struct {
+	query   logs.SearchQuery
+	results []logs.SearchResult
+	err     error
+}
+
+func (s *recordingSearcher) Search(consoleLogsPath string, query logs.SearchQuery) ([]logs.SearchResult, bool, error) {
+	s.query = query
+	return s.results, false, s.err
+}
+
+func TestDoSearch(t *testing.T) {
+	searcher := &recordingSearcher{results: []logs.SearchResult{{Node: "x1000c0s0b0n0", File: "console.x1000c0s0b0n0", Offset: 42, Text: "EDAC MC0"}}}
+	req := httptest.NewRequest(http.MethodGet, "/search?q=EDAC&nodes=x1000c0s[0-1]b0n0&since=168h&limit=5000", nil)
+	rec := httptest.NewRecorder()
+	doSearch("", searcher, rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp SearchResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, searcher.results, resp.Results)
+
+	require.Equal(t, "EDAC", searcher.query.Text)
+	require.Equal(t, maxSearchLimit, searcher.query.Limit)
+	require.False(t, searcher.query.Since.IsZero())
+	require.True(t, searcher.query.Accept("x1000c0s1b0n0"))
+	require.False(t, searcher.query.Accept("x1000c0s2b0n0"))
+}
+
+func TestDoSearchRejectsRequests(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		err      error
+		expected int
+	}{
+		{"missing query", "", nil, http.StatusBadRequest},
+		{"invalid since", "?q=edac&since=yesterday", nil, http.StatusBadRequest},
+		{"invalid limit", "?q=edac&limit=0", nil, http.StatusBadRequest},
+		{"invalid hostlist", "?q=edac&nodes=x1000c0s[0-", nil, http.StatusBadRequest},
+		{"no words", "?q=a", logs.ErrNoSearchWords, http.StatusBadRequest},
+		{"disabled", "?q=edac", logs.ErrSearchDisabled, http.StatusNotImplemented},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/search"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			doSearch("", &recordingSearcher{err: tt.err}, rec, req)
+			require.Equal(t, tt.expected, rec.Code)
+		})
+	}
+}
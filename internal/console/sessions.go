@@ -0,0 +1,281 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// This file contains the session tracking and session administration endpoints
+
+package console
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/OpenCHAMI/jwtauth/v5"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	sessionModeInteractive = "interactive"
+	sessionModeTail        = "tail"
+
+	defaultTerminateReason = "terminated by administrator"
+)
+
+// AdminScope is the scope or role a JWT must carry to use the session
+// administration endpoints
+var AdminScope = "admin"
+
+// SessionInfo describes an active console session
+type SessionInfo struct {
+	ID         string    `json:"id"`
+	NodeID     string    `json:"nodeID"`
+	Mode       string    `json:"mode"`
+	User       string    `json:"user"`
+	RemoteAddr string    `json:"remoteAddr"`
+	StartTime  time.Time `json:"startTime"`
+	BytesIn    int64     `json:"bytesIn"`
+	BytesOut   int64     `json:"bytesOut"`
+}
+
+type SessionsResponse struct {
+	Sessions []SessionInfo `json:"sessions"`
+}
+
+// trackedSession is the registry entry for a single active session
+type trackedSession struct {
+	id         string
+	nodeID     string
+	mode       string
+	user       string
+	remoteAddr string
+	startTime  time.Time
+	bytesIn    atomic.Int64
+	bytesOut   atomic.Int64
+	terminate  func(reason string) // ends the session on request
+}
+
+// addBytesIn records bytes received from the client. Safe to call on a nil session.
+func (ts *trackedSession) addBytesIn(n int) {
+	if ts != nil {
+		ts.bytesIn.Add(int64(n))
+	}
+}
+
+// addBytesOut records bytes sent to the client. Safe to call on a nil session.
+func (ts *trackedSession) addBytesOut(n int) {
+	if ts != nil {
+		ts.bytesOut.Add(int64(n))
+	}
+}
+
+func (ts *trackedSession) info() SessionInfo {
+	return SessionInfo{
+		ID:         ts.id,
+		NodeID:     ts.nodeID,
+		Mode:       ts.mode,
+		User:       ts.user,
+		RemoteAddr: ts.remoteAddr,
+		StartTime:  ts.startTime,
+		BytesIn:    ts.bytesIn.Load(),
+		BytesOut:   ts.bytesOut.Load(),
+	}
+}
+
+// sessionRegistry tracks every active interactive and tail session
+type sessionRegistry struct {
+	mu       sync.Mutex
+	sessions map[string]*trackedSession
+}
+
+func newSessionRegistry() *sessionRegistry {
+	return &sessionRegistry{
+		sessions: make(map[string]*trackedSession),
+	}
+}
+
+func newSessionID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand never fails on supported platforms, but fall back to a time based id
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// requestUser returns the subject of the JWT attached to the request, if any
+func requestUser(r *http.Request) string {
+	_, claims, err := jwtauth.FromContext(r.Context())
+	if err != nil || claims == nil {
+		return ""
+	}
+	if sub, ok := claims["sub"].(string); ok {
+		return sub
+	}
+	return ""
+}
+
+// register adds a new session for the request, ended on request by calling
+// terminate. The session can be terminated as soon as it is registered, so
+// terminate must be able to close it by then. Each register must be paired
+// with a call to unregister.
+func (sr *sessionRegistry) register(nodeID, mode string, r *http.Request, terminate func(reason string)) *trackedSession {
+	ts := &trackedSession{
+		id:         newSessionID(),
+		nodeID:     nodeID,
+		mode:       mode,
+		user:       requestUser(r),
+		remoteAddr: r.RemoteAddr,
+		startTime:  time.Now().UTC(),
+		terminate:  terminate,
+	}
+
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	sr.sessions[ts.id] = ts
+
+	slog.Debug("Registered console session", "sessionID", ts.id, "nodeID", nodeID, "mode", mode, "user", ts.user)
+	return ts
+}
+
+// unregister removes a session from the registry. It is a no-op if the
+// session is not registered.
+func (sr *sessionRegistry) unregister(ts *trackedSession) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	delete(sr.sessions, ts.id)
+}
+
+// list returns a snapshot of all sessions ordered by start time
+func (sr *sessionRegistry) list() []SessionInfo {
+	sr.mu.Lock()
+	infos := make([]SessionInfo, 0, len(sr.sessions))
+	for _, ts := range sr.sessions {
+		infos = append(infos, ts.info())
+	}
+	sr.mu.Unlock()
+
+	slices.SortFunc(infos, func(a, b SessionInfo) int {
+		if c := a.StartTime.Compare(b.StartTime); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return infos
+}
+
+// terminate ends the session with the given id, returning false if there is
+// no such session
+func (sr *sessionRegistry) terminate(id, reason string) bool {
+	sr.mu.Lock()
+	ts, ok := sr.sessions[id]
+	sr.mu.Unlock()
+	if !ok {
+		return false
+	}
+
+	slog.Info("Terminating console session", "sessionID", id, "nodeID", ts.nodeID, "mode", ts.mode, "reason", reason)
+	ts.terminate(reason)
+	return true
+}
+
+// terminateNode ends every session of the given modes on nodeID and returns
+// the number of sessions terminated
+func (sr *sessionRegistry) terminateNode(nodeID string, modes []string, reason string) int {
+	sr.mu.Lock()
+	var ids []string
+	for id, ts := range sr.sessions {
+		if ts.nodeID == nodeID && slices.Contains(modes, ts.mode) {
+			ids = append(ids, id)
+		}
+	}
+	sr.mu.Unlock()
+
+	count := 0
+	for _, id := range ids {
+		if sr.terminate(id, reason) {
+			count++
+		}
+	}
+	return count
+}
+
+// claimContains reports whether a claim holds value, either as a space
+// separated string (OAuth2 scope style) or as a list of strings
+func claimContains(claims map[string]interface{}, name, value string) bool {
+	switch v := claims[name].(type) {
+	case string:
+		return slices.Contains(strings.Fields(v), value)
+	case []string:
+		return slices.Contains(v, value)
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok && s == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// requireAdmin only allows requests whose token carries the admin scope or role
+func requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Without token authentication there is nothing to check, matching the other console endpoints
+		if TokenAuth == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		_, claims, err := jwtauth.FromContext(r.Context())
+		if err != nil {
+			sendJSONError(w, http.StatusUnauthorized, "invalid token")
+			return
+		}
+
+		for _, name := range []string{"scope", "scp", "roles"} {
+			if claimContains(claims, name, AdminScope) {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		slog.Warn("Rejected session administration request without admin claim", "user", claims["sub"], "path", r.URL.Path)
+		sendJSONError(w, http.StatusForbidden, "admin privileges required")
+	})
+}
+
+// doListSessions handles GET /sessions
+func doListSessions(registry *sessionRegistry, w http.ResponseWriter, r *http.Request) {
+	sendResponseJSON(w, http.StatusOK, SessionsResponse{Sessions: registry.list()})
+}
+
+// doTerminateSession handles DELETE /sessions/{sessionID}
+func doTerminateSession(registry *sessionRegistry, w http.ResponseWriter, r *http.Request) {
+	defer drainAndCloseRequestBody(r)
+
+	id := chi.URLParam(r, "sessionID")
+	if id == "" {
+		sendJSONError(w, http.StatusBadRequest, "unable to extract session ID")
+		return
+	}
+
+	reason := r.URL.Query().Get("reason")
+	if reason == "" {
+		reason = defaultTerminateReason
+	}
+
+	if !registry.terminate(id, reason) {
+		sendJSONError(w, http.StatusNotFound, fmt.Sprintf("session %s not found", id))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
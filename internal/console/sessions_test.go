@@ -0,0 +1,122 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package console
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/OpenCHAMI/jwtauth/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionRegistry(t *testing.T) {
+	registry := newSessionRegistry()
+	req := httptest.NewRequest(http.MethodGet, "/consoles/x0c0s1b0n0", nil)
+
+	var terminatedWith string
+	first := registry.register("x0c0s1b0n0", sessionModeInteractive, req, func(reason string) { terminatedWith = reason })
+	second := registry.register("x0c0s2b0n0", sessionModeTail, req, func(string) {})
+	require.NotEqual(t, first.id, second.id)
+
+	first.addBytesIn(3)
+	first.addBytesOut(10)
+
+	sessions := registry.list()
+	require.Len(t, sessions, 2)
+	require.Equal(t, first.id, sessions[0].ID)
+	require.Equal(t, "x0c0s1b0n0", sessions[0].NodeID)
+	require.Equal(t, sessionModeInteractive, sessions[0].Mode)
+	require.Equal(t, int64(3), sessions[0].BytesIn)
+	require.Equal(t, int64(10), sessions[0].BytesOut)
+	require.Equal(t, sessionModeTail, sessions[1].Mode)
+
+	require.True(t, registry.terminate(first.id, "maintenance"))
+	require.Equal(t, "maintenance", terminatedWith)
+	require.False(t, registry.terminate("unknown", "maintenance"))
+
+	registry.unregister(first)
+	registry.unregister(second)
+	require.Empty(t, registry.list())
+}
+
+func TestTakeOverSessionTerminatesAnyWriter(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/consoles/x0c0s1b0n0", nil)
+
+	for _, mode := range []string{sessionModeInteractive, sessionModeBroadcast, sessionModeExec} {
+		t.Run(mode, func(t *testing.T) {
+			sessions := newInteractiveSessions()
+			registry := newSessionRegistry()
+
+			require.True(t, sessions.reserve("x0c0s1b0n0"))
+			var writer *trackedSession
+			var terminatedWith string
+			writer = registry.register("x0c0s1b0n0", mode, req, func(reason string) {
+				terminatedWith = reason
+				registry.unregister(writer)
+				sessions.release("x0c0s1b0n0")
+			})
+
+			// Readers don't hold the reservation and are left alone
+			registry.register("x0c0s1b0n0", sessionModeTail, req, func(reason string) {
+				t.Errorf("tail session terminated: %s", reason)
+			})
+
+			require.True(t, takeOverSession(sessions, registry, "x0c0s1b0n0", "alice"))
+			require.Equal(t, "console taken over by alice", terminatedWith)
+			require.Len(t, registry.list(), 1)
+			require.False(t, sessions.reserve("x0c0s1b0n0"))
+		})
+	}
+}
+
+func TestClaimContains(t *testing.T) {
+	claims := map[string]interface{}{
+		"scope": "read write admin",
+		"roles": []interface{}{"operator", "admin"},
+		"scp":   []string{"read"},
+	}
+
+	require.True(t, claimContains(claims, "scope", "admin"))
+	require.False(t, claimContains(claims, "scope", "adm"))
+	require.True(t, claimContains(claims, "roles", "admin"))
+	require.True(t, claimContains(claims, "scp", "read"))
+	require.False(t, claimContains(claims, "missing", "admin"))
+}
+
+func TestRequireAdmin(t *testing.T) {
+	previous := TokenAuth
+	TokenAuth = jwtauth.New("HS256", []byte("test-secret"), nil)
+	defer func() { TokenAuth = previous }()
+
+	handler := jwtauth.Verifier(TokenAuth)(requireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})))
+
+	tests := []struct {
+		name     string
+		claims   map[string]interface{}
+		expected int
+	}{
+		{"admin scope", map[string]interface{}{"sub": "alice", "scope": "admin"}, http.StatusNoContent},
+		{"admin role", map[string]interface{}{"sub": "alice", "roles": []string{"admin"}}, http.StatusNoContent},
+		{"no admin claim", map[string]interface{}{"sub": "bob", "scope": "read"}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, token, err := TokenAuth.Encode(tt.claims)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "/sessions", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.expected, rec.Code)
+		})
+	}
+}
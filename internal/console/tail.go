@@ -8,12 +8,15 @@ import (
 	"bufio"
 	"container/ring"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
 	"strconv"
 	"sync"
 	"time"
@@ -22,29 +25,110 @@ import (
 	"github.com/nxadm/tail"
 	"github.com/nxadm/tail/ratelimiter"
 
+	"github.com/OpenCHAMI/remote-console/internal/logs"
 	"github.com/OpenCHAMI/remote-console/internal/nodes"
 )
 
+// tailOutput is where a tail session sends console log lines. It is
+// implemented for WebSocket clients and for plain HTTP streams.
+type tailOutput interface {
+	// writeLine sends one line of console log, offset is the byte offset of the end of the line
+	writeLine(line string, offset int64) error
+	// writeNotice sends a message that is not part of the console log
+	writeNotice(text string) error
+	close(reason sessionCloseReason, message string)
+	Done() <-chan struct{}
+}
+
+// logHistory lists the retained log files of a console
+type logHistory interface {
+	ConsoleLogFiles(consoleLogsPath, xname string) ([]logs.ConsoleLogFile, error)
+}
+
+// redactorSource provides the redaction applied to tail output
+type redactorSource interface {
+	Redactor() *logs.Redactor
+}
+
+// tailOptions holds the query parameters that control a tail session
+type tailOptions struct {
+	follow       bool
+	numLines     int
+	resumeOffset int64       // byte offset to resume from, negative when not resuming
+	filter       *lineFilter // time range and pattern filter, nil when not filtering
+	fromStart    bool        // replay the whole retained history, including rotated logs
+	jsonFraming  bool        // send each line as a JSON logs.LineFrame
+}
+
+// parseTailOptions validates the tail query parameters
+func parseTailOptions(params url.Values) (tailOptions, error) {
+	opts := tailOptions{numLines: -1, resumeOffset: -1}
+
+	if followParam := params.Get("follow"); followParam != "" {
+		follow, err := strconv.ParseBool(followParam)
+		if err != nil {
+			return opts, errors.New("Follow parameter must be a boolean value")
+		}
+		opts.follow = follow
+	}
+
+	if numLinesParam := params.Get("lines"); numLinesParam != "" {
+		numLines, err := strconv.Atoi(numLinesParam)
+		if err != nil {
+			return opts, errors.New("Lines parameter must be a valid integer")
+		}
+		opts.numLines = numLines
+	}
+
+	switch from := params.Get("from"); from {
+	case "":
+	case "start":
+		opts.fromStart = true
+	default:
+		return opts, fmt.Errorf("Invalid from parameter: %s (must be 'start')", from)
+	}
+
+	switch framing := params.Get("framing"); framing {
+	case "", "text":
+	case "json":
+		opts.jsonFraming = true
+	default:
+		return opts, fmt.Errorf("Invalid framing parameter: %s (must be 'text' or 'json')", framing)
+	}
+
+	filter, err := parseLineFilter(params)
+	if err != nil {
+		return opts, err
+	}
+	opts.filter = filter
+
+	return opts, nil
+}
+
 type consoleTailSession struct {
 	nodeID          string
 	tail            *tail.Tail
 	consoleLogsPath string
+	liveFile        string     // Name of the live log file
+	history         logHistory // Rotated log files, nil to read the live log only
 	closeOnce       sync.Once
-	ws              *webSocketSession
+	out             tailOutput
+	filter          *lineFilter
+	redact          *logs.RedactStream // nil when no redaction rules are configured
+	jsonFraming     bool
+	seq             uint64                   // Sequence number of the last JSON framed line
+	tracked         *trackedSession          // Registry entry used for session administration
 	rateLimiter     *ratelimiter.LeakyBucket // Rate limit console output
 }
 
-func newConsoleTailSession(consoleLogsPath string, nodeID string, conn *websocket.Conn) *consoleTailSession {
-	cts := &consoleTailSession{
+func newConsoleTailSession(consoleLogsPath string, history logHistory, nodeID string, out tailOutput) *consoleTailSession {
+	return &consoleTailSession{
 		nodeID:          nodeID,
 		consoleLogsPath: consoleLogsPath,
+		history:         history,
+		out:             out,
 		rateLimiter:     ratelimiter.NewLeakyBucket(rateLimitBurstKB, rateLimitInterval),
 	}
-
-	cts.ws = newWebSocketSession(conn, fmt.Sprintf("tail session %s", nodeID))
-	cts.ws.Start()
-
-	return cts
 }
 
 func (cts *consoleTailSession) close() {
@@ -65,16 +149,67 @@ func (cts *consoleTailSession) closeWithReason(reason sessionCloseReason, messag
 
 		slog.Info("Closing console tail session", "nodeID", cts.nodeID)
 
-		cts.ws.close(reason, message)
+		cts.out.close(reason, message)
 
 		slog.Info("Close completed for console tail session", "nodeID", cts.nodeID)
 	})
 }
 
-func (cts *consoleTailSession) waitForClientClose() {
+// terminate notifies the user of the reason and closes the session
+func (cts *consoleTailSession) terminate(reason string) {
+	msg := fmt.Sprintf("\n[Session terminated: %s]\n", reason)
+	if err := cts.out.writeNotice(msg); err != nil {
+		slog.Debug("Failed to send termination notice", "nodeID", cts.nodeID, "error", err)
+	}
+	cts.closeWithReason(sessionCloseNormal, closeMessageText(reason))
+}
+
+// sendLine rate limits and writes a single console log line read at readTime
+func (cts *consoleTailSession) sendLine(line tailLine, readTime time.Time) error {
+	// Add newline back (tail library strips it)
+	lineText := line.text + "\n"
+
+	if cts.jsonFraming {
+		cts.seq++
+		frame := logs.NewConsoleLine(cts.nodeID, line.file, line.text, line.start, readTime).Frame(cts.seq)
+		data, err := json.Marshal(frame)
+		if err != nil {
+			return fmt.Errorf("failed to encode console log line: %w", err)
+		}
+		lineText = string(data) + "\n"
+	}
+
+	// Apply rate limiting (convert bytes to KB, rounded up)
+	kb := uint16((len(lineText) + 1023) / 1024)
+	for !cts.rateLimiter.Pour(kb) {
+		slog.Debug("Rate limit reached for tail, waiting for capacity", "nodeID", cts.nodeID)
+		time.Sleep(100 * time.Millisecond) // Wait for bucket to drain
+	}
+
+	if err := cts.out.writeLine(lineText, line.offset); err != nil {
+		return err
+	}
+	cts.tracked.addBytesOut(len(lineText))
+	return nil
+}
+
+// wsTailOutput sends tail output as WebSocket text messages
+type wsTailOutput struct {
+	*webSocketSession
+}
+
+func (o wsTailOutput) writeLine(line string, offset int64) error {
+	return o.Write(websocket.TextMessage, []byte(line))
+}
+
+func (o wsTailOutput) writeNotice(text string) error {
+	return o.Write(websocket.TextMessage, []byte(text))
+}
+
+func (cts *consoleTailSession) waitForClientClose(ws *webSocketSession) {
 	slog.Debug("Waiting for client close on tail session", "nodeID", cts.nodeID)
 	for {
-		_, _, err := cts.ws.Read()
+		_, _, err := ws.Read()
 		if err != nil {
 			if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				slog.Info("WebSocket closed normally for tail session", "nodeID", cts.nodeID)
@@ -88,7 +223,11 @@ func (cts *consoleTailSession) waitForClientClose() {
 	}
 }
 
-func (cts *consoleTailSession) streamConsoleTail(ctx context.Context, follow bool) {
+// streamConsoleTail sends the lines of the live log read by the tail, which
+// started reading at offset start
+func (cts *consoleTailSession) streamConsoleTail(ctx context.Context, follow bool, start int64) {
+	reading := false
+
 	// Read the lines of the tail output while looking for a cancel signal
 	for {
 		select {
@@ -96,8 +235,8 @@ func (cts *consoleTailSession) streamConsoleTail(ctx context.Context, follow boo
 			slog.Debug("Context canceled, stopping tail", "nodeID", cts.nodeID)
 			cts.closeWithReason(sessionCloseCanceled, "session ended")
 			return
-		case <-cts.ws.Done():
-			slog.Debug("WebSocket closed, stopping tail", "nodeID", cts.nodeID)
+		case <-cts.out.Done():
+			slog.Debug("Client closed, stopping tail", "nodeID", cts.nodeID)
 			return
 		case line, ok := <-cts.tail.Lines:
 			if !ok {
@@ -106,112 +245,270 @@ func (cts *consoleTailSession) streamConsoleTail(ctx context.Context, follow boo
 				return
 			}
 
-			// Add newline back (tail library strips it)
-			lineText := line.Text + "\n"
-
-			// Apply rate limiting (convert bytes to KB, rounded up)
-			kb := uint16((len(lineText) + 1023) / 1024)
-			for !cts.rateLimiter.Pour(kb) {
-				slog.Debug("Rate limit reached for tail, waiting for capacity", "nodeID", cts.nodeID)
-				time.Sleep(100 * time.Millisecond) // Wait for bucket to drain
+			// Each line starts where the one before it ended, or at the
+			// beginning of the log when the tail reopened it and counts
+			// lines from the start again
+			lineStart := start
+			if line.Num == 1 && reading {
+				lineStart = 0
+			}
+			start = line.SeekInfo.Offset
+			reading = true
+
+			text := cts.redact.Line(line.Text)
+			if !cts.filter.accept(text) {
+				// Lines are in time order, so nothing after the end of the range can match
+				if cts.filter.expired() {
+					slog.Info("Console log passed end of requested time range", "nodeID", cts.nodeID)
+					cts.close()
+					return
+				}
+				continue
 			}
 
-			err := cts.ws.Write(websocket.TextMessage, []byte(lineText))
+			tl := tailLine{
+				text:   text,
+				offset: line.SeekInfo.Offset,
+				file:   cts.liveFile,
+				start:  lineStart,
+			}
+			err := cts.sendLine(tl, line.Time)
 			if err != nil {
-				slog.Error("Failed to write message to websocket", "error", err, "nodeID", cts.nodeID)
+				slog.Error("Failed to write console log line", "error", err, "nodeID", cts.nodeID)
 				cts.closeWithReason(sessionCloseError, "error sending console log")
-				cts.tail.Poll = false
-				cts.tail.Cleanup()
-				if err := cts.tail.Stop(); err != nil {
-					slog.Error("Error stopping tail", "error", err, "nodeID", cts.nodeID)
-				}
 				return
 			}
 		}
 	}
 }
 
-// readLastNLines reads the last numLines lines from the specified file and returns them along with the file position
-func readLastNLines(filename string, numLines int) ([]string, int64, error) {
-	file, err := os.Open(filename)
+// tailLine is a console log line along with the byte offset of its end in the
+// live log file. Lines from rotated files have a negative offset since a
+// client can't resume from them.
+type tailLine struct {
+	text   string
+	offset int64
+	file   string // name of the log file the line was read from
+	start  int64  // start of the line in the file
+}
+
+// scanLogFile calls handle for each line of a console log file accepted by
+// filter, after redaction, and returns the offset reached in the file
+func scanLogFile(f logs.ConsoleLogFile, filter *lineFilter, redact *logs.RedactStream, handle func(line tailLine) error) (int64, error) {
+	file, err := f.Open()
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to open file: %w", err)
+		return 0, fmt.Errorf("failed to open file: %w", err)
 	}
 	defer func() {
 		if err := file.Close(); err != nil {
-			slog.Warn("Failed to close console log file", "file", filename, "error", err)
+			slog.Warn("Failed to close console log file", "file", f.Path, "error", err)
 		}
 	}()
 
-	// Use a ring buffer to store the last numLines lines
-	r := ring.New(numLines)
-	count := 0
-
 	scanner := bufio.NewScanner(file)
 	// Set max line length to 1MB (well below the 10MB bucket capacity)
 	const maxLineLength = 1024 * 1024
 	buf := make([]byte, 0, 64*1024)
 	scanner.Buffer(buf, maxLineLength)
 
+	// Track the offset of the end of each line so clients can resume from it
+	var offset int64
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := bufio.ScanLines(data, atEOF)
+		offset += int64(advance)
+		return advance, token, err
+	})
+
 	// Read through the file line by line
-	for scanner.Scan() {
-		r.Value = scanner.Text()
-		r = r.Next()
-		count++
+	var start int64
+	for ; scanner.Scan(); start = offset {
+		text := redact.Line(scanner.Text())
+		if !filter.accept(text) {
+			continue
+		}
+		line := tailLine{text: text, offset: offset, file: filepath.Base(f.Path), start: start}
+		if f.Rotation > 0 {
+			line.offset = -1
+		}
+		if err := handle(line); err != nil {
+			return offset, err
+		}
 	}
 
 	if err := scanner.Err(); err != nil {
-		return nil, 0, fmt.Errorf("error reading file: %w", err)
+		return offset, fmt.Errorf("error reading file: %w", err)
 	}
 
-	// Get current position in file (where we stopped reading)
-	currentPos, err := file.Seek(0, io.SeekCurrent)
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to get file position: %w", err)
+	return offset, nil
+}
+
+// readLastNLines reads the last numLines lines accepted by filter from the
+// console log files, which are given oldest first and end with the live log.
+// Files are read newest first until enough lines are found. Each file is read
+// on its own, so lines before the first timestamp of a file don't take the
+// time of the file before it; conman starts each log with a timestamped line.
+// filter and redact are left as they were at the end of the live log. It
+// returns the lines along with the position reached in the live log.
+func readLastNLines(files []logs.ConsoleLogFile, numLines int, filter *lineFilter, redact *logs.RedactStream) ([]tailLine, int64, error) {
+	var lines []tailLine
+	var currentPos int64
+	found := false
+	for i := len(files) - 1; i >= 0 && len(lines) < numLines; i-- {
+		// Only the state of the live log carries on to following it
+		fileFilter, fileRedact := filter, redact
+		if i < len(files)-1 {
+			fileFilter, fileRedact = filter.clone(), redact.Clone()
+		}
+		fileLines, pos, err := readFileLastLines(files[i], numLines-len(lines), fileFilter, fileRedact)
+		if errors.Is(err, os.ErrNotExist) {
+			// Rotation may have removed the file since it was listed
+			continue
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		found = true
+
+		if i == len(files)-1 {
+			currentPos = pos
+		}
+		lines = append(fileLines, lines...)
 	}
 
-	// Return lines in order
-	var lines []string
-	linesToReturn := numLines
-	if count < numLines {
-		linesToReturn = count
-		// Move back to start of actual data
-		r = r.Move(-count)
+	if !found {
+		return nil, 0, fmt.Errorf("no console log history: %w", os.ErrNotExist)
 	}
+	return lines, currentPos, nil
+}
+
+// readFileLastLines reads the last numLines lines of one console log file
+// accepted by filter. A file ending a private key block begun in the file
+// before it is read again from inside the block, so the key isn't sent.
+func readFileLastLines(f logs.ConsoleLogFile, numLines int, filter *lineFilter, redact *logs.RedactStream) ([]tailLine, int64, error) {
+	for inKey := false; ; inKey = true {
+		filter.reset()
+		redact.Reset(inKey)
+
+		// Use a ring buffer to store the last numLines lines
+		r := ring.New(numLines)
+		count := 0
+		pos, err := scanLogFile(f, filter, redact, func(line tailLine) error {
+			r.Value = line
+			r = r.Next()
+			count++
+			return nil
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+		if !inKey && redact.UnmatchedKeyEnd() {
+			continue
+		}
 
-	// Iterate the ring to get the lines
-	for i := 0; i < linesToReturn; i++ {
-		if r.Value != nil {
-			lines = append(lines, r.Value.(string))
+		// Return lines in order, starting from the oldest one kept
+		count = min(count, numLines)
+		r = r.Move(-count)
+		lines := make([]tailLine, 0, count)
+		for range count {
+			lines = append(lines, r.Value.(tailLine))
+			r = r.Next()
 		}
-		r = r.Next()
+		return lines, pos, nil
 	}
+}
 
-	return lines, currentPos, nil
+// resumeLocation returns where to start reading the log file when a client
+// resumes from offset. If the file is now shorter than the offset it has been
+// rotated or truncated, so reading starts over from the beginning.
+func resumeLocation(filename string, offset int64) *tail.SeekInfo {
+	info, err := os.Stat(filename)
+	if err != nil || info.Size() < offset {
+		return nil
+	}
+	return &tail.SeekInfo{Offset: offset, Whence: io.SeekStart}
+}
+
+// logFiles returns the console log history, oldest first and ending with the live log
+func (cts *consoleTailSession) logFiles() []logs.ConsoleLogFile {
+	live := logs.ConsoleLogFile{Path: fmt.Sprintf("%s/console.%s", cts.consoleLogsPath, cts.nodeID)}
+	if cts.history == nil {
+		return []logs.ConsoleLogFile{live}
+	}
+
+	files, err := cts.history.ConsoleLogFiles(cts.consoleLogsPath, cts.nodeID)
+	if err != nil {
+		slog.Warn("Failed to list rotated console logs, using live log only", "nodeID", cts.nodeID, "error", err)
+		return []logs.ConsoleLogFile{live}
+	}
+	return files
 }
 
-func (cts *consoleTailSession) tailConsole(ctx context.Context, follow bool, numLines int) {
+// sendRotatedHistory sends every line of the rotated log files, oldest first
+func (cts *consoleTailSession) sendRotatedHistory(ctx context.Context, files []logs.ConsoleLogFile) error {
+	for _, f := range files {
+		if f.Rotation == 0 {
+			continue
+		}
+		_, err := scanLogFile(f, cts.filter, cts.redact, func(line tailLine) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			return cts.sendLine(line, time.Now())
+		})
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
 
-	slog.Info("Tail session starting", "nodeID", cts.nodeID, "follow", follow, "numLines", numLines)
+func (cts *consoleTailSession) tailConsole(ctx context.Context, opts tailOptions) {
+	follow, numLines, fromStart := opts.follow, opts.numLines, opts.fromStart
 
-	filename := fmt.Sprintf("%s/console.%s", cts.consoleLogsPath, cts.nodeID)
+	slog.Info("Tail session starting", "nodeID", cts.nodeID, "follow", follow, "numLines", numLines, "fromStart", fromStart, "resumeOffset", opts.resumeOffset)
+
+	files := cts.logFiles()
+	filename := files[len(files)-1].Path
+	cts.liveFile = filepath.Base(filename)
+	cts.jsonFraming = opts.jsonFraming
+
+	// A resumed stream continues after the last line the client received instead of sending history
+	if opts.resumeOffset >= 0 {
+		numLines = -1
+		fromStart = false
+	}
+
+	// New output can't fall in a time range that has already ended
+	cts.filter = opts.filter
+	if rs, ok := cts.history.(redactorSource); ok {
+		cts.redact = rs.Redactor().Stream()
+	}
+	if follow && opts.filter != nil && !opts.filter.until.IsZero() && opts.filter.until.Before(time.Now()) {
+		follow = false
+	}
+
+	// Replaying the whole history sends the rotated logs, then tails the live log from its beginning
+	if fromStart {
+		numLines = -1
+		if err := cts.sendRotatedHistory(ctx, files); err != nil {
+			if ctx.Err() != nil {
+				slog.Debug("Context canceled while sending history", "nodeID", cts.nodeID)
+				cts.closeWithReason(sessionCloseCanceled, "session ended")
+				return
+			}
+			slog.Error("Failed to send rotated console logs", "error", err, "nodeID", cts.nodeID)
+			cts.closeWithReason(sessionCloseError, "error sending console log")
+			return
+		}
+	}
 
 	var seekOffset int64
 	// If numLines is specified, send last N lines first
 	if numLines > 0 {
-		lines, currentPos, err := readLastNLines(filename, numLines)
+		lines, currentPos, err := readLastNLines(files, numLines, cts.filter, cts.redact)
 
 		if err == nil {
 			for _, line := range lines {
-				lineText := line + "\n"
-
-				// Apply rate limiting (convert bytes to KB, rounded up)
-				kb := uint16((len(lineText) + 1023) / 1024)
-				for !cts.rateLimiter.Pour(kb) {
-					slog.Debug("Rate limit reached for tail (history), waiting for capacity", "nodeID", cts.nodeID)
-					time.Sleep(100 * time.Millisecond) // Wait for bucket to drain
-				}
-
 				select {
 				case <-ctx.Done():
 					slog.Debug("Context canceled while sending history", "nodeID", cts.nodeID)
@@ -220,7 +517,7 @@ func (cts *consoleTailSession) tailConsole(ctx context.Context, follow bool, num
 				default:
 				}
 
-				err := cts.ws.Write(websocket.TextMessage, []byte(lineText))
+				err := cts.sendLine(line, time.Now())
 				if err != nil {
 					slog.Error("Failed to send lines", "error", err, "nodeID", cts.nodeID)
 					cts.closeWithReason(sessionCloseError, "error sending console log")
@@ -270,6 +567,8 @@ func (cts *consoleTailSession) tailConsole(ctx context.Context, follow bool, num
 
 	if numLines > 0 && follow && seekOffset > 0 {
 		conf.Location = &tail.SeekInfo{Offset: seekOffset, Whence: io.SeekStart}
+	} else if opts.resumeOffset > 0 {
+		conf.Location = resumeLocation(filename, opts.resumeOffset)
 	}
 
 	var err error
@@ -282,10 +581,14 @@ func (cts *consoleTailSession) tailConsole(ctx context.Context, follow bool, num
 
 	slog.Info("Tailing console file", "filename", filename, "nodeID", cts.nodeID)
 
-	cts.streamConsoleTail(ctx, follow)
+	var start int64
+	if conf.Location != nil {
+		start = conf.Location.Offset
+	}
+	cts.streamConsoleTail(ctx, follow, start)
 }
 
-func doTailConsole(consoleLogsPath string, w http.ResponseWriter, r *http.Request) {
+func doTailConsole(consoleLogsPath string, history logHistory, registry *sessionRegistry, w http.ResponseWriter, r *http.Request) {
 	// Make sure the request is cleaned up
 	defer drainAndCloseRequestBody(r)
 
@@ -304,24 +607,10 @@ func doTailConsole(consoleLogsPath string, w http.ResponseWriter, r *http.Reques
 	}
 
 	// Parse and validate query parameters before upgrading
-	params := r.URL.Query()
-
-	follow := false
-	if followParam := params.Get("follow"); followParam != "" {
-		follow, err = strconv.ParseBool(followParam)
-		if err != nil {
-			http.Error(w, "Follow parameter must be a boolean value", http.StatusBadRequest)
-			return
-		}
-	}
-
-	numLines := -1
-	if numLinesParam := params.Get("lines"); numLinesParam != "" {
-		numLines, err = strconv.Atoi(numLinesParam)
-		if err != nil {
-			http.Error(w, "Lines parameter must be a valid integer", http.StatusBadRequest)
-			return
-		}
+	opts, err := parseTailOptions(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
 	// Upgrade HTTP connection to WebSocket
@@ -335,14 +624,19 @@ func doTailConsole(consoleLogsPath string, w http.ResponseWriter, r *http.Reques
 	slog.Info("Client connected for node tail", "remoteAddr", conn.RemoteAddr().String(), "nodeID", nodeID)
 
 	// Create new console tail session
-	session := newConsoleTailSession(consoleLogsPath, nodeID, conn)
+	ws := newWebSocketSession(conn, fmt.Sprintf("tail session %s", nodeID))
+	ws.Start()
+	session := newConsoleTailSession(consoleLogsPath, history, nodeID, wsTailOutput{ws})
+
+	session.tracked = registry.register(nodeID, sessionModeTail, r, session.terminate)
+	defer registry.unregister(session.tracked)
 
-	go session.waitForClientClose()
+	go session.waitForClientClose(ws)
 
 	slog.Info("Started tailing console log", "nodeID", nodeID)
 
 	// Start streaming the console output
-	session.tailConsole(r.Context(), follow, numLines)
+	session.tailConsole(r.Context(), opts)
 
 	slog.Info("Console tail session ended", "nodeID", nodeID)
 }
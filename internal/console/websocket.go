@@ -167,6 +167,16 @@ func (ws *webSocketSession) writePump() {
 	}
 }
 
+// closeMessageText truncates a close reason so it fits in a WebSocket close
+// frame, which is limited to 125 bytes including the 2 byte close code
+func closeMessageText(reason string) string {
+	const maxCloseReasonLen = 123
+	if len(reason) <= maxCloseReasonLen {
+		return reason
+	}
+	return reason[:maxCloseReasonLen]
+}
+
 // mapCloseReason maps internal session close reasons to WebSocket close codes
 func mapCloseReason(reason sessionCloseReason) int {
 	switch reason {
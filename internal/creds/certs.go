@@ -0,0 +1,336 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// This file contains the functions to track the validity of the SSH
+// certificates and renew them before they expire
+
+package creds
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/OpenCHAMI/remote-console/internal/metrics"
+)
+
+// SharedCertificate names the certificate of the shared console SSH key.
+// The certificates of per-node keys are named by their xname.
+const SharedCertificate = "shared"
+
+const certSigningTimeout = 30 * time.Second
+
+// CertificateStatus describes an SSH certificate in use
+type CertificateStatus struct {
+	KeyID       string     `json:"keyId"`
+	Principals  []string   `json:"principals"`
+	ValidAfter  time.Time  `json:"validAfter"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"` // nil for a certificate valid forever
+	ExpiresSoon bool       `json:"expiresSoon"`
+	Expired     bool       `json:"expired"`
+	Signed      bool       `json:"signed"`          // issued by the signing endpoint rather than read from secure storage
+	Error       string     `json:"error,omitempty"` // why the certificate couldn't be parsed
+}
+
+// trackedCertificate is a certificate in use and when it should be renewed
+type trackedCertificate struct {
+	status  CertificateStatus
+	cert    *ssh.Certificate // nil when it couldn't be parsed
+	renewAt time.Time        // zero for a certificate valid forever
+}
+
+// certTracker keeps the certificates in use, and those issued by the signing
+// endpoint that are used in place of the ones in secure storage until those
+// are renewed. Issued certificates are deliberately kept in memory only: the
+// service never writes to secure storage, which several adapters can't be
+// written to, so after a restart the stored certificate is used again and
+// renewed again if it is due.
+type certTracker struct {
+	mutex  sync.Mutex
+	certs  map[string]trackedCertificate
+	signed map[string]string
+}
+
+func newCertTracker() *certTracker {
+	return &certTracker{certs: make(map[string]trackedCertificate), signed: make(map[string]string)}
+}
+
+// parseCertificate parses an OpenSSH certificate in authorized_keys format
+func parseCertificate(data string) (*ssh.Certificate, error) {
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(data))
+	if err != nil {
+		return nil, err
+	}
+	cert, ok := key.(*ssh.Certificate)
+	if !ok {
+		return nil, fmt.Errorf("not an SSH certificate: %s", key.Type())
+	}
+	return cert, nil
+}
+
+// certTime converts a certificate validity bound, returning false for one
+// that is unbounded
+func certTime(t uint64) (time.Time, bool) {
+	if t == ssh.CertTimeInfinity || t > math.MaxInt64 {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(t), 0).UTC(), true
+}
+
+// renewBefore returns how long before a certificate expires it is renewed:
+// the configured window, or a third of its lifetime when that is shorter so
+// short-lived certificates aren't renewed as soon as they are issued
+func (cs *CredsService) renewBefore(validAfter, expiresAt time.Time) time.Duration {
+	window := time.Duration(cs.config.SshCertRenewBefore) * time.Second
+	if lifetime := expiresAt.Sub(validAfter); lifetime > 0 && lifetime/3 < window {
+		window = lifetime / 3
+	}
+	return window
+}
+
+// currentCertificate returns the certificate to write for a key: the one in
+// secure storage, or the one issued by the signing endpoint while it is for
+// the same key and valid longer
+func (cs *CredsService) currentCertificate(name string, stored *string) *string {
+	ct := cs.certs
+	ct.mutex.Lock()
+	defer ct.mutex.Unlock()
+
+	signed, ok := ct.signed[name]
+	if !ok {
+		return stored
+	}
+	if stored != nil {
+		storedCert, storedErr := parseCertificate(*stored)
+		signedCert, signedErr := parseCertificate(signed)
+		if storedErr == nil && signedErr == nil && bytes.Equal(storedCert.Key.Marshal(), signedCert.Key.Marshal()) &&
+			signedCert.ValidBefore > storedCert.ValidBefore {
+			return &signed
+		}
+	}
+	// Secure storage caught up, or the key changed
+	delete(ct.signed, name)
+	return stored
+}
+
+// trackCertificate records the certificate written for a key, warning when
+// it is about to expire. A nil certificate stops tracking the key.
+func (cs *CredsService) trackCertificate(name string, certificate *string) {
+	ct := cs.certs
+	ct.mutex.Lock()
+	defer ct.mutex.Unlock()
+
+	if certificate == nil {
+		delete(ct.certs, name)
+		delete(ct.signed, name)
+		return
+	}
+
+	tracked := trackedCertificate{}
+	cert, err := parseCertificate(*certificate)
+	if err != nil {
+		slog.Warn("Unable to parse SSH certificate", "key", name, "error", err)
+		tracked.status.Error = err.Error()
+		ct.certs[name] = tracked
+		return
+	}
+	tracked.cert = cert
+	tracked.status.KeyID = cert.KeyId
+	tracked.status.Principals = cert.ValidPrincipals
+	tracked.status.ValidAfter, _ = certTime(cert.ValidAfter)
+	tracked.status.Signed = ct.signed[name] == *certificate
+	if expiresAt, ok := certTime(cert.ValidBefore); ok {
+		tracked.status.ExpiresAt = &expiresAt
+		tracked.renewAt = expiresAt.Add(-cs.renewBefore(tracked.status.ValidAfter, expiresAt))
+	}
+	ct.certs[name] = tracked
+
+	now := time.Now()
+	switch {
+	case tracked.renewAt.IsZero():
+	case !now.Before(*tracked.status.ExpiresAt):
+		slog.Error("SSH certificate has expired", "key", name, "keyID", cert.KeyId, "expiresAt", tracked.status.ExpiresAt)
+	case !now.Before(tracked.renewAt):
+		slog.Warn("SSH certificate expires soon", "key", name, "keyID", cert.KeyId, "expiresAt", tracked.status.ExpiresAt)
+	}
+}
+
+// Certificates returns the status of the SSH certificates in use by key,
+// SharedCertificate for the shared key and the xname for per-node keys
+func (cs *CredsService) Certificates() map[string]CertificateStatus {
+	ct := cs.certs
+	ct.mutex.Lock()
+	defer ct.mutex.Unlock()
+
+	now := time.Now()
+	statuses := make(map[string]CertificateStatus, len(ct.certs))
+	for name, tracked := range ct.certs {
+		status := tracked.status
+		if !tracked.renewAt.IsZero() {
+			status.Expired = !now.Before(*status.ExpiresAt)
+			status.ExpiresSoon = !now.Before(tracked.renewAt)
+		}
+		statuses[name] = status
+	}
+	return statuses
+}
+
+// dueCertificates returns the certificates due for renewal
+func (ct *certTracker) dueCertificates() map[string]*ssh.Certificate {
+	now := time.Now()
+	due := make(map[string]*ssh.Certificate)
+	for name, tracked := range ct.certs {
+		if tracked.cert != nil && !tracked.renewAt.IsZero() && !now.Before(tracked.renewAt) {
+			due[name] = tracked.cert
+		}
+	}
+	return due
+}
+
+// CertificateRenewalDue reports whether any SSH certificate is about to
+// expire and should be renewed
+func (cs *CredsService) CertificateRenewalDue() bool {
+	cs.certs.mutex.Lock()
+	defer cs.certs.mutex.Unlock()
+	return len(cs.certs.dueCertificates()) > 0
+}
+
+// certSigningRequest asks the signing endpoint for a certificate of the key
+type certSigningRequest struct {
+	PublicKey  string   `json:"publicKey"`
+	KeyID      string   `json:"keyId"`
+	Principals []string `json:"principals"`
+}
+
+type certSigningResponse struct {
+	Certificate string `json:"certificate"`
+}
+
+// RenewCertificates asks the signing endpoint, when one is configured, for
+// new certificates for the keys whose certificates are about to expire. The
+// new certificates are written by the next credential check. It returns the
+// keys renewed.
+func (cs *CredsService) RenewCertificates() ([]string, error) {
+	if cs.config.SshCertSigningURL == "" {
+		return nil, nil
+	}
+
+	cs.certs.mutex.Lock()
+	due := cs.certs.dueCertificates()
+	cs.certs.mutex.Unlock()
+
+	var renewed []string
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(due)) {
+		certificate, err := cs.requestCertificate(due[name])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to renew SSH certificate of %s: %w", name, err))
+			continue
+		}
+		cs.certs.mutex.Lock()
+		cs.certs.signed[name] = certificate
+		cs.certs.mutex.Unlock()
+		slog.Info("SSH certificate renewed by the signing endpoint", "key", name)
+		renewed = append(renewed, name)
+	}
+	return renewed, errors.Join(errs...)
+}
+
+// requestCertificate asks the signing endpoint for a certificate of the same
+// key and principals, valid longer than the current one
+func (cs *CredsService) requestCertificate(current *ssh.Certificate) (string, error) {
+	body, err := json.Marshal(certSigningRequest{
+		PublicKey:  strings.TrimSpace(string(ssh.MarshalAuthorizedKey(current.Key))),
+		KeyID:      current.KeyId,
+		Principals: current.ValidPrincipals,
+	})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest(http.MethodPost, cs.config.SshCertSigningURL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if cs.config.SshCertSigningTokenFile != "" {
+		token, err := readTrimmedFile(cs.config.SshCertSigningTokenFile)
+		if err != nil {
+			return "", fmt.Errorf("unable to read signing token file: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	client := &http.Client{Timeout: certSigningTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("signing endpoint returned %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	var signed certSigningResponse
+	if err := json.NewDecoder(resp.Body).Decode(&signed); err != nil {
+		return "", fmt.Errorf("invalid signing endpoint response: %w", err)
+	}
+	cert, err := parseCertificate(signed.Certificate)
+	if err != nil {
+		return "", fmt.Errorf("invalid certificate from signing endpoint: %w", err)
+	}
+	if !bytes.Equal(cert.Key.Marshal(), current.Key.Marshal()) {
+		return "", errors.New("signing endpoint certified a different key")
+	}
+	if cert.ValidBefore <= current.ValidBefore {
+		return "", errors.New("signing endpoint returned a certificate expiring no later than the current one")
+	}
+	return strings.TrimSpace(signed.Certificate) + "\n", nil
+}
+
+// certificateMetrics returns the expiry of each SSH certificate and how many
+// are about to expire
+func (cs *CredsService) certificateMetrics() []metrics.Metric {
+	expiring := 0
+	var samples []metrics.Sample
+	for name, status := range cs.Certificates() {
+		if status.ExpiresAt == nil {
+			continue
+		}
+		if status.ExpiresSoon {
+			expiring++
+		}
+		samples = append(samples, metrics.Sample{Labels: map[string]string{"key": name}, Value: float64(status.ExpiresAt.Unix())})
+	}
+	slices.SortFunc(samples, func(a, b metrics.Sample) int { return strings.Compare(a.Labels["key"], b.Labels["key"]) })
+
+	return []metrics.Metric{
+		{
+			Name:    "remote_console_ssh_certificate_expiry_timestamp_seconds",
+			Help:    "Time the SSH certificate of each console key expires, in seconds since the epoch.",
+			Type:    metrics.TypeGauge,
+			Samples: samples,
+		},
+		{
+			Name:    "remote_console_ssh_certificates_expiring",
+			Help:    "SSH certificates expired or due for renewal.",
+			Type:    metrics.TypeGauge,
+			Samples: []metrics.Sample{{Value: float64(expiring)}},
+		},
+	}
+}
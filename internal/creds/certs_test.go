@@ -0,0 +1,178 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package creds
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+// signTestCertificate returns a certificate of the key valid for the window
+func signTestCertificate(t *testing.T, ca ssh.Signer, key ssh.PublicKey, validAfter, validBefore time.Time) string {
+	cert := &ssh.Certificate{
+		Key:             key,
+		KeyId:           "console",
+		CertType:        ssh.UserCert,
+		ValidPrincipals: []string{"root", "admin"},
+		ValidAfter:      uint64(validAfter.Unix()),
+		ValidBefore:     uint64(validBefore.Unix()),
+	}
+	require.NoError(t, cert.SignCert(rand.Reader, ca))
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(cert)))
+}
+
+func testCertConfig(t *testing.T) CredsConfig {
+	tempDir := t.TempDir()
+	config := DefaultCredsConfig()
+	config.SecureStorageAdapter = StorageAdapterFile
+	config.CredentialsFilePath = filepath.Join(tempDir, "credentials.yaml")
+	config.SshConsoleKeyPath = filepath.Join(tempDir, "conman.key")
+	config.SecureStorageSshKeysPath = "console-keys"
+	return config
+}
+
+func writeConsoleCertificate(t *testing.T, config CredsConfig, certificate string) {
+	data := "sshKeys:\n  privateKey: test-key\n  certificate: " + certificate + "\n"
+	require.NoError(t, os.WriteFile(config.CredentialsFilePath, []byte(data), 0600))
+}
+
+func TestTrackCertificate(t *testing.T) {
+	ca := newTestSigner(t)
+	key := newTestSigner(t).PublicKey()
+	config := testCertConfig(t)
+	now := time.Now()
+
+	// A certificate far from expiry isn't due
+	writeConsoleCertificate(t, config, signTestCertificate(t, ca, key, now.Add(-time.Hour), now.Add(24*time.Hour)))
+	service := NewCredsService(config)
+	_, err := service.EnsureConsoleKeysPresent()
+	require.NoError(t, err)
+	status := service.Certificates()[SharedCertificate]
+	require.Equal(t, "console", status.KeyID)
+	require.Equal(t, []string{"root", "admin"}, status.Principals)
+	require.Equal(t, now.Add(24*time.Hour).Unix(), status.ExpiresAt.Unix())
+	require.False(t, status.ExpiresSoon)
+	require.False(t, status.Expired)
+	require.False(t, service.CertificateRenewalDue())
+
+	// Within the renewal window it is reported and due
+	writeConsoleCertificate(t, config, signTestCertificate(t, ca, key, now.Add(-time.Hour), now.Add(30*time.Minute)))
+	_, err = service.EnsureConsoleKeysPresent()
+	require.NoError(t, err)
+	require.True(t, service.Certificates()[SharedCertificate].ExpiresSoon)
+	require.True(t, service.CertificateRenewalDue())
+	metrics := service.Metrics()
+	require.Equal(t, "remote_console_ssh_certificate_expiry_timestamp_seconds", metrics[2].Name)
+	require.Equal(t, map[string]string{"key": SharedCertificate}, metrics[2].Samples[0].Labels)
+	require.Equal(t, float64(now.Add(30*time.Minute).Unix()), metrics[2].Samples[0].Value)
+	require.Equal(t, float64(1), metrics[3].Samples[0].Value)
+
+	// A short-lived certificate is renewed a third of its lifetime ahead
+	writeConsoleCertificate(t, config, signTestCertificate(t, ca, key, now.Add(-5*time.Minute), now.Add(25*time.Minute)))
+	_, err = service.EnsureConsoleKeysPresent()
+	require.NoError(t, err)
+	require.False(t, service.CertificateRenewalDue())
+
+	// As is an expired one
+	writeConsoleCertificate(t, config, signTestCertificate(t, ca, key, now.Add(-2*time.Hour), now.Add(-time.Minute)))
+	_, err = service.EnsureConsoleKeysPresent()
+	require.NoError(t, err)
+	require.True(t, service.Certificates()[SharedCertificate].Expired)
+	require.True(t, service.CertificateRenewalDue())
+
+	// Something other than a certificate is reported as such
+	writeConsoleCertificate(t, config, "not-a-certificate")
+	_, err = service.EnsureConsoleKeysPresent()
+	require.NoError(t, err)
+	require.NotEmpty(t, service.Certificates()[SharedCertificate].Error)
+	require.False(t, service.CertificateRenewalDue())
+}
+
+func TestRenewCertificates(t *testing.T) {
+	ca := newTestSigner(t)
+	key := newTestSigner(t).PublicKey()
+	config := testCertConfig(t)
+	config.SshCertSigningTokenFile = filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(config.SshCertSigningTokenFile, []byte("signing-token\n"), 0600))
+	now := time.Now()
+	signedBefore := now.Add(2 * time.Hour)
+
+	// A stand-in signing endpoint
+	signingKey := key
+	signer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer signing-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req certSigningRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PublicKey != strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key))) ||
+			strings.Join(req.Principals, ",") != "root,admin" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(certSigningResponse{Certificate: signTestCertificate(t, ca, signingKey, now, signedBefore)})
+	}))
+	t.Cleanup(signer.Close)
+
+	stored := signTestCertificate(t, ca, key, now.Add(-time.Hour), now.Add(10*time.Minute))
+	writeConsoleCertificate(t, config, stored)
+	service := NewCredsService(config)
+	_, err := service.EnsureConsoleKeysPresent()
+	require.NoError(t, err)
+
+	// Without an endpoint the certificate is only looked up again
+	renewed, err := service.RenewCertificates()
+	require.NoError(t, err)
+	require.Empty(t, renewed)
+
+	// A certificate for another key is refused
+	config.SshCertSigningURL = signer.URL
+	service.config = config
+	signingKey = newTestSigner(t).PublicKey()
+	_, err = service.RenewCertificates()
+	require.ErrorContains(t, err, "certified a different key")
+
+	// The new certificate is used until secure storage has a newer one
+	signingKey = key
+	renewed, err = service.RenewCertificates()
+	require.NoError(t, err)
+	require.Equal(t, []string{SharedCertificate}, renewed)
+	changed, err := service.EnsureConsoleKeysPresent()
+	require.NoError(t, err)
+	require.True(t, changed)
+	data, err := os.ReadFile(config.SshConsoleKeyPath + "-cert.pub")
+	require.NoError(t, err)
+	cert, err := parseCertificate(string(data))
+	require.NoError(t, err)
+	require.Equal(t, uint64(signedBefore.Unix()), cert.ValidBefore)
+	status := service.Certificates()[SharedCertificate]
+	require.True(t, status.Signed)
+	require.False(t, status.ExpiresSoon)
+	require.False(t, service.CertificateRenewalDue())
+
+	changed, err = service.EnsureConsoleKeysPresent()
+	require.NoError(t, err)
+	require.False(t, changed)
+
+	renewedStored := signTestCertificate(t, ca, key, now, now.Add(24*time.Hour))
+	writeConsoleCertificate(t, config, renewedStored)
+	changed, err = service.EnsureConsoleKeysPresent()
+	require.NoError(t, err)
+	require.True(t, changed)
+	data, err = os.ReadFile(config.SshConsoleKeyPath + "-cert.pub")
+	require.NoError(t, err)
+	require.Equal(t, renewedStored, string(data))
+	require.False(t, service.Certificates()[SharedCertificate].Signed)
+}
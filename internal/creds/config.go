@@ -7,30 +7,84 @@ package creds
 type StorageAdapter string
 
 const (
-	StorageAdapterVault StorageAdapter = "vault"
-	StorageAdapterLocal StorageAdapter = "local"
+	StorageAdapterVault      StorageAdapter = "vault"
+	StorageAdapterLocal      StorageAdapter = "local"
+	StorageAdapterFile       StorageAdapter = "file"
+	StorageAdapterSecretsDir StorageAdapter = "secrets-dir"
+	StorageAdapterEnv        StorageAdapter = "env"
 )
 
 type CredsConfig struct {
-	SshConsoleKeyPath          string         `desc:"Path where the SSH private key file for console access will be writen to."`
-	SecureStorageAdapter       StorageAdapter `desc:"Type of secure storage adapter to use for credentials retrieval."`
-	VaultBasePath              string         `desc:"Base path in Vault where credentials are stored."`
-	VaultRole                  string         `desc:"Vault role to use when authenticating to Vault."`
-	LocalStoreFilePath         string         `desc:"Path to local secure storage file."`
-	LocalStoreKey              string         `desc:"Key to use for local secure storage decryption."`
-	SecureStorageSshKeysPath   string         `desc:"Path where the SSH keys can be found in secure storage. Leave empty to skip SSH key management."`
-	SecureStoragePasswordsPath string         `desc:"Path where the console credentials access can be found in secure storage."`
+	SshConsoleKeyPath            string         `desc:"Path where the SSH private key file for console access will be writen to."`
+	SecureStorageAdapter         StorageAdapter `desc:"Type of secure storage adapter to use for credentials retrieval (vault, local, file, secrets-dir or env)."`
+	VaultBasePath                string         `desc:"Base path in Vault where credentials are stored."`
+	VaultRole                    string         `desc:"Vault role to use when authenticating to Vault."`
+	VaultAuthMethod              string         `desc:"Vault authentication method: kubernetes, token, approle or cert."`
+	VaultAuthMount               string         `desc:"Mount path of the Vault auth method, when not the method's default."`
+	VaultTokenFile               string         `desc:"File holding the Vault token for token authentication. Reread on expiry so an agent can rotate it."`
+	VaultAppRoleID               string         `desc:"AppRole role ID for approle authentication."`
+	VaultAppRoleSecretIDFile     string         `desc:"File holding the AppRole secret ID for approle authentication."`
+	VaultClientCertFile          string         `desc:"Client certificate file for cert authentication."`
+	VaultClientKeyFile           string         `desc:"Client private key file for cert authentication."`
+	VaultSecretCacheTTL          int            `desc:"Seconds a secret read from Vault is reused before it is read again, or its lease when shorter. 0 reads secrets on every use."`
+	LocalStoreFilePath           string         `desc:"Path to local secure storage file."`
+	LocalStoreKey                string         `desc:"Key to use for local secure storage decryption."`
+	SecureStorageSshKeysPath     string         `desc:"Path where the SSH keys can be found in secure storage. Leave empty to skip SSH key management."`
+	SecureStoragePasswordsPath   string         `desc:"Path where the console credentials access can be found in secure storage."`
+	SecureStorageNodeSshKeysPath string         `desc:"Path in secure storage under which each node's own SSH keys are stored as <path>/<xname>. Leave empty to skip per-node keys."`
+	SshNodeKeysDir               string         `desc:"Directory where the per-node SSH private keys and certificates are written."`
+	CredentialsFilePath          string         `desc:"Path to the YAML credentials file used by the file adapter. It must not be accessible by group or others."`
+	SecretsDirPath               string         `desc:"Directory of mounted secrets, one file per xname, used by the secrets-dir adapter."`
+	CredentialRulesFilePath      string         `desc:"Path to the YAML file of pattern and vendor credentials for nodes without credentials of their own."`
+	DefaultUsername              string         `desc:"Default console username for nodes without credentials of their own."`
+	DefaultPasswordFile          string         `desc:"File holding the default console password for nodes without credentials of their own. Reread on every credential check."`
+	HostKeyVerification          string         `desc:"SSH host key verification for BMC consoles: off, known-hosts or tofu."`
+	SecureStorageKnownHostsPath  string         `desc:"Path where the trusted BMC host keys, in known_hosts format, can be found in secure storage."`
+	KnownHostsPath               string         `desc:"Path where the known_hosts file used by the SSH console helpers is written."`
+	HostKeyPinsPath              string         `desc:"Path of the file keeping the host keys pinned on first use."`
+	HostKeyScanInterval          int            `desc:"Interval in seconds between checks of each BMC's SSH host key."`
+	SshCertRenewBefore           int            `desc:"Seconds before an SSH certificate expires to warn and renew it, or a third of its lifetime when shorter."`
+	SshCertSigningURL            string         `desc:"URL of an SSH certificate signing endpoint asked for a new certificate when one is about to expire. Leave empty to only warn."`
+	SshCertSigningTokenFile      string         `desc:"File holding the bearer token sent to the SSH certificate signing endpoint."`
+
+	// DefaultPassword is only read from the RCS_CREDS_DEFAULT_PASSWORD
+	// environment variable, as a flag would show it in the process list
+	DefaultPassword string `flag:"-"`
 }
 
 func DefaultCredsConfig() CredsConfig {
 	return CredsConfig{
-		SshConsoleKeyPath:          "/app/conman.key",
-		VaultBasePath:              "",
-		VaultRole:                  "",
-		SecureStorageAdapter:       StorageAdapterVault,
-		LocalStoreFilePath:         "",
-		LocalStoreKey:              "",
-		SecureStorageSshKeysPath:   "",
-		SecureStoragePasswordsPath: "hms-creds",
+		SshConsoleKeyPath:            "/app/conman.key",
+		VaultBasePath:                "",
+		VaultRole:                    "",
+		VaultAuthMethod:              VaultAuthKubernetes,
+		VaultAuthMount:               "",
+		VaultTokenFile:               "",
+		VaultAppRoleID:               "",
+		VaultAppRoleSecretIDFile:     "",
+		VaultClientCertFile:          "",
+		VaultClientKeyFile:           "",
+		VaultSecretCacheTTL:          300,
+		SecureStorageAdapter:         StorageAdapterVault,
+		LocalStoreFilePath:           "",
+		LocalStoreKey:                "",
+		SecureStorageSshKeysPath:     "",
+		SecureStoragePasswordsPath:   "hms-creds",
+		SecureStorageNodeSshKeysPath: "",
+		SshNodeKeysDir:               "/app/conman-keys",
+		CredentialsFilePath:          "",
+		SecretsDirPath:               "",
+		CredentialRulesFilePath:      "",
+		DefaultUsername:              "",
+		DefaultPasswordFile:          "",
+		DefaultPassword:              "",
+		HostKeyVerification:          HostKeyVerificationOff,
+		SecureStorageKnownHostsPath:  "",
+		KnownHostsPath:               "/app/conman.known_hosts",
+		HostKeyPinsPath:              "/app/conman.known_hosts.pinned",
+		HostKeyScanInterval:          300,
+		SshCertRenewBefore:           3600,
+		SshCertSigningURL:            "",
+		SshCertSigningTokenFile:      "",
 	}
 }
@@ -14,6 +14,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"sync"
 	"time"
 
 	compcreds "github.com/Cray-HPE/hms-compcredentials"
@@ -25,6 +26,18 @@ type CredsService struct {
 	previousPasswords      map[string]compcreds.CompCredentials
 	previousPrivateKeyHash []byte
 	previousCertHash       []byte
+
+	sourcesMutex sync.RWMutex
+	sources      map[string]CredentialSource // nil until conman is first configured
+
+	keysMutex     sync.Mutex
+	nodeKeyHashes map[string][]byte // hash of each node's key and certificate, nil until first written
+
+	hostKeys *hostKeyVerifier
+	certs    *certTracker
+
+	checkMutex sync.Mutex
+	lastCheck  *CredentialChanges // nil until credentials are first checked
 }
 
 func NewCredsService(config CredsConfig) *CredsService {
@@ -33,6 +46,8 @@ func NewCredsService(config CredsConfig) *CredsService {
 		previousPasswords:      nil,
 		previousPrivateKeyHash: nil,
 		previousCertHash:       nil,
+		hostKeys:               newHostKeyVerifier(config),
+		certs:                  newCertTracker(),
 	}
 }
 
@@ -46,16 +61,29 @@ func NewStorageAdapter(value string) (StorageAdapter, error) {
 
 func (s StorageAdapter) Validate() error {
 	switch s {
-	case StorageAdapterVault, StorageAdapterLocal:
+	case StorageAdapterVault, StorageAdapterLocal, StorageAdapterFile, StorageAdapterSecretsDir, StorageAdapterEnv:
 		return nil
 	default:
 		return fmt.Errorf("invalid storage adapter %q", s)
 	}
 }
 
-type sshKeys struct {
-	PrivateKey  string  `json:"privateKey"`
-	Certificate *string `json:"certificate"`
+// String, Set and Type let the adapter be given as a flag
+func (s *StorageAdapter) String() string {
+	return string(*s)
+}
+
+func (s *StorageAdapter) Set(value string) error {
+	adapter, err := NewStorageAdapter(value)
+	if err != nil {
+		return err
+	}
+	*s = adapter
+	return nil
+}
+
+func (s *StorageAdapter) Type() string {
+	return "string"
 }
 
 func createSecureStorage(config CredsConfig) (sstorage.SecureStorage, error) {
@@ -64,7 +92,7 @@ func createSecureStorage(config CredsConfig) (sstorage.SecureStorage, error) {
 
 	switch config.SecureStorageAdapter {
 	case StorageAdapterVault:
-		ss, err = sstorage.NewVaultAdapterAs(config.VaultBasePath, config.VaultRole)
+		ss, err = getVaultStorage(config)
 		if err != nil {
 			return nil, fmt.Errorf("unable to create vault secure storage adapter: %w", err)
 		}
@@ -82,23 +110,14 @@ func createSecureStorage(config CredsConfig) (sstorage.SecureStorage, error) {
 
 // Look up the creds for the input endpoints
 func getPasswords(config CredsConfig, bmcXNames []string) (map[string]compcreds.CompCredentials, error) {
-	ss, err := createSecureStorage(config)
-	if err != nil {
-		return nil, fmt.Errorf("error creating secure storage adapter: %w", err)
-	}
-
-	ccs := compcreds.NewCompCredStore(config.SecureStoragePasswordsPath, ss)
-	ccreds, err := ccs.GetCompCreds(bmcXNames)
-	if err != nil {
-		return nil, fmt.Errorf("error creating comp creds store: %w", err)
-	}
-
-	return ccreds, nil
+	passwords, _, err := resolveCredentials(config, bmcXNames)
+	return passwords, err
 }
 
 // Look up the creds for the input endpoints with retries
 func (cs *CredsService) GetPasswordsWithRetries(ctx context.Context, bmcXNames []string, maxTries, waitSecs int) (map[string]compcreds.CompCredentials, error) {
 	var passwords map[string]compcreds.CompCredentials = nil
+	var sources map[string]CredentialSource
 	var err error = nil
 	for numTries := 0; numTries < maxTries; numTries++ {
 		select {
@@ -109,7 +128,7 @@ func (cs *CredsService) GetPasswordsWithRetries(ctx context.Context, bmcXNames [
 		}
 
 		slog.Debug("Get passwords with retry", "attempt", numTries)
-		passwords, err = getPasswords(cs.config, bmcXNames)
+		passwords, sources, err = resolveCredentials(cs.config, bmcXNames)
 
 		slog.Debug("Passwords retrieved", "count", len(passwords))
 
@@ -142,10 +161,45 @@ func (cs *CredsService) GetPasswordsWithRetries(ctx context.Context, bmcXNames [
 	slog.Warn("Maximum password attempts reached, configuring conman with what we have")
 
 	cs.previousPasswords = passwords
+	cs.setSources(bmcXNames, sources)
 
 	return passwords, err
 }
 
+// setSources records and logs the level each node's credentials came from
+func (cs *CredsService) setSources(xnames []string, sources map[string]CredentialSource) {
+	counts := make(map[CredentialSource]int)
+	for _, xname := range xnames {
+		source, ok := sources[xname]
+		if !ok {
+			slog.Warn("No credentials found for node", "xname", xname)
+			continue
+		}
+		slog.Debug("Resolved credentials", "xname", xname, "source", source)
+		counts[source]++
+	}
+	slog.Info("Credential resolution complete",
+		"node", counts[CredentialSourceNode], "pattern", counts[CredentialSourcePattern],
+		"vendor", counts[CredentialSourceVendor], "default", counts[CredentialSourceDefault],
+		"missing", len(xnames)-len(sources))
+
+	if sources == nil {
+		sources = make(map[string]CredentialSource)
+	}
+	cs.sourcesMutex.Lock()
+	defer cs.sourcesMutex.Unlock()
+	cs.sources = sources
+}
+
+// CredentialSources returns the level each node's credentials came from when
+// conman was last configured. Nodes without credentials are left out. It
+// reports false until credentials have been resolved.
+func (cs *CredsService) CredentialSources() (map[string]CredentialSource, bool) {
+	cs.sourcesMutex.RLock()
+	defer cs.sourcesMutex.RUnlock()
+	return cs.sources, cs.sources != nil
+}
+
 func hashString(s string) ([]byte, error) {
 	hasher := sha256.New()
 	if _, err := hasher.Write([]byte(s)); err != nil {
@@ -162,12 +216,11 @@ func (cs *CredsService) EnsureConsoleKeysPresent() (bool, error) {
 
 	retVal := false
 
-	ss, err := createSecureStorage(cs.config)
+	provider, err := newCredentialsProvider(cs.config)
 	if err != nil {
-		return false, fmt.Errorf("unable to create secure storage adapter: %w", err)
+		return false, err
 	}
-	var consoleKeys sshKeys
-	err = ss.Lookup(cs.config.SecureStorageSshKeysPath, &consoleKeys)
+	consoleKeys, err := provider.SSHKeys(cs.config.SecureStorageSshKeysPath)
 	if err != nil {
 		return false, fmt.Errorf("unable to lookup private key: %w", err)
 	}
@@ -189,8 +242,11 @@ func (cs *CredsService) EnsureConsoleKeysPresent() (bool, error) {
 		slog.Warn("Console ssh cert file already exists")
 	}
 
-	if consoleKeys.Certificate != nil {
-		newHash, err = hashString(*consoleKeys.Certificate)
+	// A certificate from the signing endpoint is kept until secure storage has a newer one
+	certificate := cs.currentCertificate(SharedCertificate, consoleKeys.Certificate)
+
+	if certificate != nil {
+		newHash, err = hashString(*certificate)
 		if err != nil {
 			slog.Error("Failed to hash the public ssh cert received from Vault", "error", err)
 		}
@@ -199,7 +255,7 @@ func (cs *CredsService) EnsureConsoleKeysPresent() (bool, error) {
 			retVal = true
 			cs.previousCertHash = newHash
 			sshConsoleCertPath := cs.config.SshConsoleKeyPath + "-cert.pub"
-			err = os.WriteFile(sshConsoleCertPath, []byte(*consoleKeys.Certificate), 0644)
+			err = os.WriteFile(sshConsoleCertPath, []byte(*certificate), 0644)
 			if err != nil {
 				return false, fmt.Errorf("failed to write public ssh cert: %w", err)
 			}
@@ -208,6 +264,7 @@ func (cs *CredsService) EnsureConsoleKeysPresent() (bool, error) {
 			slog.Warn("Console ssh cert file already exists")
 		}
 	}
+	cs.trackCertificate(SharedCertificate, certificate)
 
 	return retVal, nil
 }
@@ -0,0 +1,625 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// This file contains the functions to verify the SSH host keys of the BMCs
+// and keep the known_hosts file used by the SSH console helpers
+
+package creds
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/OpenCHAMI/remote-console/internal/metrics"
+	"github.com/OpenCHAMI/remote-console/internal/nodes"
+)
+
+// Host key verification modes
+const (
+	HostKeyVerificationOff        = "off"         // ssh accepts any host key
+	HostKeyVerificationKnownHosts = "known-hosts" // only keys from the trusted source are accepted
+	HostKeyVerificationTOFU       = "tofu"        // hosts without a trusted key are pinned on first use
+)
+
+// Where the expected host key of a BMC came from
+const (
+	HostKeySourceTrusted = "trusted"
+	HostKeySourcePinned  = "pinned"
+)
+
+const (
+	hostKeyScanTimeout     = 10 * time.Second
+	hostKeyScanConcurrency = 16
+)
+
+// errHostKeyScanned ends a scan once the host key has been seen
+var errHostKeyScanned = errors.New("host key scanned")
+
+// HostKeyMismatch is a console whose BMC presented a host key other than the expected one
+type HostKeyMismatch struct {
+	Host       string    `json:"host"`
+	Source     string    `json:"source"`    // trusted or pinned
+	Presented  string    `json:"presented"` // SHA256 fingerprint of the key presented
+	Expected   []string  `json:"expected"`  // SHA256 fingerprints of the keys expected
+	DetectedAt time.Time `json:"detectedAt"`
+}
+
+// hostKeyVerifier keeps the known_hosts file of the BMC consoles
+type hostKeyVerifier struct {
+	config     CredsConfig
+	mutex      sync.Mutex
+	loaded     bool                       // whether the pins have been read
+	trusted    string                     // the last trusted known_hosts read
+	pins       map[string]ssh.PublicKey   // known_hosts address -> key pinned on first use
+	scanned    map[string]time.Time       // known_hosts address -> last scan
+	mismatches map[string]HostKeyMismatch // xname -> mismatch
+	scanning   bool                       // whether a round of scans is running
+	scansDone  sync.WaitGroup
+}
+
+func newHostKeyVerifier(config CredsConfig) *hostKeyVerifier {
+	return &hostKeyVerifier{
+		config:     config,
+		pins:       make(map[string]ssh.PublicKey),
+		scanned:    make(map[string]time.Time),
+		mismatches: make(map[string]HostKeyMismatch),
+	}
+}
+
+// hostKeyAddress returns the address ssh connects to for the console
+func hostKeyAddress(nci *nodes.NodeConsoleInfo) string {
+	port := nci.ConnectionPort
+	// The helpers default to port 22 too
+	if port == 0 {
+		port = 22
+	}
+	return net.JoinHostPort(nci.ConnectionHost, strconv.Itoa(port))
+}
+
+// knownHosts is known_hosts text parsed in memory
+type knownHosts struct {
+	lines   []knownHostsLine
+	revoked map[string]bool // marshaled keys
+}
+
+type knownHostsLine struct {
+	patterns []string
+	key      ssh.PublicKey
+}
+
+// parseKnownHosts parses known_hosts text. Certificate authorities are
+// skipped, as the BMCs present plain host keys.
+func parseKnownHosts(text string) (*knownHosts, error) {
+	kh := &knownHosts{revoked: make(map[string]bool)}
+	data := []byte(text)
+	for len(data) > 0 {
+		marker, hosts, key, _, rest, err := ssh.ParseKnownHosts(data)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch marker {
+		case "revoked":
+			kh.revoked[string(key.Marshal())] = true
+		case "":
+			kh.lines = append(kh.lines, knownHostsLine{patterns: hosts, key: key})
+		}
+		data = rest
+	}
+	return kh, nil
+}
+
+// keys returns the keys expected of the host at address
+func (kh *knownHosts) keys(address string) []ssh.PublicKey {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil
+	}
+	var keys []ssh.PublicKey
+	for _, line := range kh.lines {
+		if !kh.revoked[string(line.key.Marshal())] && hostPatternsMatch(line.patterns, host, port) {
+			keys = append(keys, line.key)
+		}
+	}
+	return keys
+}
+
+// check accepts a key that is expected of the host at address
+func (kh *knownHosts) check(address string, key ssh.PublicKey) error {
+	if kh.revoked[string(key.Marshal())] {
+		return errors.New("host key revoked")
+	}
+	for _, expected := range kh.keys(address) {
+		if bytes.Equal(expected.Marshal(), key.Marshal()) {
+			return nil
+		}
+	}
+	return errors.New("host key mismatch")
+}
+
+// hostPatternsMatch reports whether the host patterns of a known_hosts line
+// match the host and port. As with ssh, a matching negated pattern rules the
+// line out.
+func hostPatternsMatch(patterns []string, host, port string) bool {
+	normalized := knownhosts.Normalize(net.JoinHostPort(host, port))
+	matched := false
+	for _, pattern := range patterns {
+		negated := strings.HasPrefix(pattern, "!")
+		if !hostPatternMatches(strings.TrimPrefix(pattern, "!"), host, port, normalized) {
+			continue
+		}
+		if negated {
+			return false
+		}
+		matched = true
+	}
+	return matched
+}
+
+// hostPatternMatches matches a hashed host, or a host or [host]:port with
+// optional wildcards
+func hostPatternMatches(pattern, host, port, normalized string) bool {
+	if strings.HasPrefix(pattern, "|1|") {
+		return hashedHostMatches(pattern, normalized)
+	}
+	patternPort := "22"
+	if strings.HasPrefix(pattern, "[") {
+		var err error
+		if pattern, patternPort, err = net.SplitHostPort(pattern); err != nil {
+			return false
+		}
+	}
+	ok, err := path.Match(pattern, host)
+	return err == nil && ok && patternPort == port
+}
+
+// hashedHostMatches matches a |1|salt|hash host, the HMAC-SHA1 of the
+// normalized address keyed with the salt
+func hashedHostMatches(pattern, normalized string) bool {
+	parts := strings.Split(pattern, "|")
+	if len(parts) != 4 {
+		return false
+	}
+	salt, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	hash, err := base64.StdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha1.New, salt)
+	mac.Write([]byte(normalized))
+	return hmac.Equal(mac.Sum(nil), hash)
+}
+
+// hostKeyAlgorithms returns the algorithms that negotiate keys of the same
+// types as the expected ones, so the key scanned can be compared with them
+func hostKeyAlgorithms(keys []ssh.PublicKey) []string {
+	var algorithms []string
+	for _, key := range keys {
+		types := []string{key.Type()}
+		if key.Type() == ssh.KeyAlgoRSA {
+			types = []string{ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA}
+		}
+		for _, t := range types {
+			if !slices.Contains(algorithms, t) {
+				algorithms = append(algorithms, t)
+			}
+		}
+	}
+	return algorithms
+}
+
+// scanHostKey returns the host key the SSH server at address presents
+func scanHostKey(address string, algorithms []string) (ssh.PublicKey, error) {
+	conn, err := net.DialTimeout("tcp", address, hostKeyScanTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	if err := conn.SetDeadline(time.Now().Add(hostKeyScanTimeout)); err != nil {
+		return nil, err
+	}
+
+	var key ssh.PublicKey
+	config := &ssh.ClientConfig{
+		User:              "remote-console",
+		HostKeyAlgorithms: algorithms,
+		HostKeyCallback: func(hostname string, remote net.Addr, presented ssh.PublicKey) error {
+			key = presented
+			return errHostKeyScanned
+		},
+	}
+	_, _, _, err = ssh.NewClientConn(conn, address, config)
+	if key == nil {
+		return nil, fmt.Errorf("failed to get the host key of %s: %w", address, err)
+	}
+	return key, nil
+}
+
+func fingerprints(keys []ssh.PublicKey) []string {
+	prints := make([]string, 0, len(keys))
+	for _, key := range keys {
+		prints = append(prints, ssh.FingerprintSHA256(key))
+	}
+	return prints
+}
+
+// loadPins reads the keys pinned on first use
+func (v *hostKeyVerifier) loadPins() error {
+	data, err := os.ReadFile(v.config.HostKeyPinsPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read pinned host keys: %w", err)
+	}
+	for len(data) > 0 {
+		_, hosts, key, _, rest, err := ssh.ParseKnownHosts(data)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse pinned host keys: %w", err)
+		}
+		for _, host := range hosts {
+			v.pins[host] = key
+		}
+		data = rest
+	}
+	return nil
+}
+
+// savePins writes the keys pinned on first use
+func (v *hostKeyVerifier) savePins() error {
+	addresses := make([]string, 0, len(v.pins))
+	for address := range v.pins {
+		addresses = append(addresses, address)
+	}
+	slices.Sort(addresses)
+
+	var b strings.Builder
+	for _, address := range addresses {
+		b.WriteString(knownhosts.Line([]string{address}, v.pins[address]))
+		b.WriteByte('\n')
+	}
+	if err := os.MkdirAll(filepath.Dir(v.config.HostKeyPinsPath), 0700); err != nil {
+		return err
+	}
+	if err := writePrivateFile(v.config.HostKeyPinsPath, []byte(b.String())); err != nil {
+		return fmt.Errorf("failed to write pinned host keys: %w", err)
+	}
+	return nil
+}
+
+// hostKeyScan is the result of scanning the host key of one BMC
+type hostKeyScan struct {
+	address  string
+	xnames   []string
+	expected []ssh.PublicKey
+	source   string
+	key      ssh.PublicKey
+	err      error
+}
+
+// EnsureKnownHosts writes the known_hosts file of the trusted and pinned
+// keys and starts checking, in the background, the host keys of the SSH
+// consoles due a check. It returns the path of the file, or an empty path
+// when host keys aren't verified. The scans don't hold up conman, ssh reads
+// the keys they pin from the file on its next connection.
+func (cs *CredsService) EnsureKnownHosts(consoles map[string]*nodes.NodeConsoleInfo) (string, error) {
+	v := cs.hostKeys
+	if v.config.HostKeyVerification == "" || v.config.HostKeyVerification == HostKeyVerificationOff {
+		return "", nil
+	}
+
+	// The trusted keys are read before locking, secure storage may be slow
+	var errs []error
+	var trustedText *string
+	if v.config.SecureStorageKnownHostsPath != "" {
+		provider, err := newCredentialsProvider(v.config)
+		if err == nil {
+			var text string
+			text, err = provider.KnownHosts(v.config.SecureStorageKnownHostsPath)
+			if errors.Is(err, ErrNoKnownHosts) {
+				text, err = "", nil
+			}
+			if err == nil {
+				trustedText = &text
+			}
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to read trusted host keys: %w", err))
+		}
+	}
+
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	if !v.loaded && v.config.HostKeyVerification == HostKeyVerificationTOFU {
+		if err := v.loadPins(); err != nil {
+			return v.config.KnownHostsPath, errors.Join(append(errs, err)...)
+		}
+		v.loaded = true
+	}
+
+	// The last trusted keys read are kept while the source can't be read
+	if trustedText != nil {
+		v.trusted = *trustedText
+	}
+	trusted, err := parseKnownHosts(v.trusted)
+	if err != nil {
+		return v.config.KnownHostsPath, errors.Join(append(errs, fmt.Errorf("invalid trusted host keys: %w", err))...)
+	}
+
+	// Consoles sharing a BMC are checked once
+	consolesByAddress := make(map[string][]string)
+	for _, nci := range consoles {
+		if nci.ConnectionType != nodes.SSH {
+			continue
+		}
+		address := hostKeyAddress(nci)
+		consolesByAddress[address] = append(consolesByAddress[address], nci.ID)
+	}
+	for xname := range v.mismatches {
+		if nci, ok := consoles[xname]; !ok || nci.ConnectionType != nodes.SSH {
+			delete(v.mismatches, xname)
+		}
+	}
+
+	// Only one round of scans runs at a time
+	var scans []*hostKeyScan
+	if !v.scanning {
+		interval := time.Duration(v.config.HostKeyScanInterval) * time.Second
+		for address, xnames := range consolesByAddress {
+			if last, ok := v.scanned[address]; ok && time.Since(last) < interval {
+				continue
+			}
+			v.scanned[address] = time.Now()
+			scan := &hostKeyScan{address: address, xnames: xnames}
+			v.expect(scan, trusted)
+			scans = append(scans, scan)
+		}
+	}
+
+	if err := v.writeKnownHosts(trusted); err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(scans) > 0 {
+		v.scanning = true
+		v.scansDone.Add(1)
+		go v.scan(scans, trusted)
+	}
+	return v.config.KnownHostsPath, errors.Join(errs...)
+}
+
+// expect sets the keys the scanned host is expected to present
+func (v *hostKeyVerifier) expect(scan *hostKeyScan, trusted *knownHosts) {
+	scan.expected, scan.source = trusted.keys(scan.address), HostKeySourceTrusted
+	if pin, ok := v.pins[knownhosts.Normalize(scan.address)]; ok && len(scan.expected) == 0 {
+		scan.expected, scan.source = []ssh.PublicKey{pin}, HostKeySourcePinned
+	}
+}
+
+// scan scans the host keys without holding the lock, then checks them
+// against the keys expected by then, as pins may have been forgotten meanwhile
+func (v *hostKeyVerifier) scan(scans []*hostKeyScan, trusted *knownHosts) {
+	defer v.scansDone.Done()
+
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, hostKeyScanConcurrency)
+	for _, scan := range scans {
+		wg.Add(1)
+		limit <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-limit }()
+			scan.key, scan.err = scanHostKey(scan.address, hostKeyAlgorithms(scan.expected))
+		}()
+	}
+	wg.Wait()
+
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	v.scanning = false
+
+	pinsChanged := false
+	for _, scan := range scans {
+		if scan.err != nil {
+			slog.Debug("Unable to check SSH host key", "address", scan.address, "error", scan.err)
+			continue
+		}
+		v.expect(scan, trusted)
+		if v.checkScan(scan, trusted) {
+			pinsChanged = true
+		}
+	}
+	if !pinsChanged {
+		return
+	}
+	if err := v.savePins(); err != nil {
+		slog.Error("Failed to save pinned SSH host keys", "error", err)
+	}
+	if err := v.writeKnownHosts(trusted); err != nil {
+		slog.Error("Failed to write known hosts file", "error", err)
+	}
+}
+
+// waitForScans waits for the host key scans started to finish
+func (v *hostKeyVerifier) waitForScans() {
+	v.scansDone.Wait()
+}
+
+// checkScan compares a scanned host key with the expected ones, pinning it
+// when none are expected. It reports whether a key was pinned.
+func (v *hostKeyVerifier) checkScan(scan *hostKeyScan, trusted *knownHosts) bool {
+	fingerprint := ssh.FingerprintSHA256(scan.key)
+	if len(scan.expected) == 0 {
+		if v.config.HostKeyVerification != HostKeyVerificationTOFU {
+			slog.Warn("No trusted SSH host key for BMC, its consoles can't connect", "address", scan.address, "xnames", scan.xnames, "fingerprint", fingerprint)
+			return false
+		}
+		v.pins[knownhosts.Normalize(scan.address)] = scan.key
+		slog.Info("Pinned SSH host key on first use", "address", scan.address, "xnames", scan.xnames, "fingerprint", fingerprint)
+		return true
+	}
+
+	var err error
+	if scan.source == HostKeySourceTrusted {
+		err = trusted.check(scan.address, scan.key)
+	} else if !bytes.Equal(scan.key.Marshal(), scan.expected[0].Marshal()) {
+		err = errors.New("host key changed")
+	}
+	for _, xname := range scan.xnames {
+		if err == nil {
+			if _, ok := v.mismatches[xname]; ok {
+				slog.Info("SSH host key matches again", "xname", xname, "address", scan.address)
+				delete(v.mismatches, xname)
+			}
+			continue
+		}
+		if previous, ok := v.mismatches[xname]; ok && previous.Presented == fingerprint {
+			continue
+		}
+		mismatch := HostKeyMismatch{
+			Host:       scan.address,
+			Source:     scan.source,
+			Presented:  fingerprint,
+			Expected:   fingerprints(scan.expected),
+			DetectedAt: time.Now().UTC(),
+		}
+		v.mismatches[xname] = mismatch
+		slog.Error("SSH host key mismatch, the console won't connect", "xname", xname, "address", scan.address,
+			"source", scan.source, "presented", fingerprint, "expected", mismatch.Expected)
+	}
+	return false
+}
+
+// writeKnownHosts writes the trusted keys and the keys pinned for hosts
+// without trusted keys
+func (v *hostKeyVerifier) writeKnownHosts(trusted *knownHosts) error {
+	var b strings.Builder
+	b.WriteString(v.trusted)
+	if v.trusted != "" && !strings.HasSuffix(v.trusted, "\n") {
+		b.WriteByte('\n')
+	}
+	addresses := make([]string, 0, len(v.pins))
+	for address := range v.pins {
+		addresses = append(addresses, address)
+	}
+	slices.Sort(addresses)
+	for _, address := range addresses {
+		// Trusted keys replace a pin
+		if len(trusted.keys(denormalize(address))) > 0 {
+			continue
+		}
+		b.WriteString(knownhosts.Line([]string{address}, v.pins[address]))
+		b.WriteByte('\n')
+	}
+
+	data := []byte(b.String())
+	if current, err := os.ReadFile(v.config.KnownHostsPath); err == nil && bytes.Equal(current, data) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(v.config.KnownHostsPath), 0700); err != nil {
+		return err
+	}
+	if err := writePrivateFile(v.config.KnownHostsPath, data); err != nil {
+		return fmt.Errorf("failed to write known hosts file: %w", err)
+	}
+	slog.Info("Known hosts file written", "path", v.config.KnownHostsPath)
+	return nil
+}
+
+// denormalize turns a known_hosts address back into a host and port
+func denormalize(address string) string {
+	if host, port, err := net.SplitHostPort(address); err == nil {
+		return net.JoinHostPort(strings.Trim(host, "[]"), port)
+	}
+	return net.JoinHostPort(address, "22")
+}
+
+// HostKeyMismatches returns the consoles whose BMC presented an unexpected host key
+func (cs *CredsService) HostKeyMismatches() map[string]HostKeyMismatch {
+	v := cs.hostKeys
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	mismatches := make(map[string]HostKeyMismatch, len(v.mismatches))
+	for xname, mismatch := range v.mismatches {
+		mismatches[xname] = mismatch
+	}
+	return mismatches
+}
+
+// ForgetHostKey removes the key pinned for the console's BMC, so the key it
+// presents is pinned at the next check. It reports whether a key was pinned.
+func (cs *CredsService) ForgetHostKey(nci *nodes.NodeConsoleInfo) (bool, error) {
+	v := cs.hostKeys
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	address := hostKeyAddress(nci)
+	normalized := knownhosts.Normalize(address)
+	if _, ok := v.pins[normalized]; !ok {
+		return false, nil
+	}
+	delete(v.pins, normalized)
+	delete(v.scanned, address)
+	for xname, mismatch := range v.mismatches {
+		if mismatch.Host == address {
+			delete(v.mismatches, xname)
+		}
+	}
+	slog.Info("Forgot pinned SSH host key", "xname", nci.ID, "address", address)
+	return true, v.savePins()
+}
+
+// Metrics reports the host key mismatches and the expiry of the SSH
+// certificates
+func (cs *CredsService) Metrics() []metrics.Metric {
+	v := cs.hostKeys
+	v.mutex.Lock()
+	hostKeyMetrics := []metrics.Metric{
+		{
+			Name:    "remote_console_host_key_mismatches",
+			Help:    "Consoles whose BMC presented an unexpected SSH host key.",
+			Type:    metrics.TypeGauge,
+			Samples: []metrics.Sample{{Value: float64(len(v.mismatches))}},
+		},
+		{
+			Name:    "remote_console_host_keys_pinned",
+			Help:    "BMC SSH host keys pinned on first use.",
+			Type:    metrics.TypeGauge,
+			Samples: []metrics.Sample{{Value: float64(len(v.pins))}},
+		},
+	}
+	v.mutex.Unlock()
+
+	return append(hostKeyMetrics, cs.certificateMetrics()...)
+}
@@ -0,0 +1,285 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package creds
+
+import (
+	"crypto/ed25519"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/OpenCHAMI/remote-console/internal/nodes"
+)
+
+// testSSHServer is an SSH server presenting a host key that can be replaced
+type testSSHServer struct {
+	mutex  sync.Mutex
+	signer ssh.Signer
+	port   int
+}
+
+func newTestSigner(t *testing.T) ssh.Signer {
+	_, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(priv)
+	require.NoError(t, err)
+	return signer
+}
+
+func startTestSSHServer(t *testing.T) *testSSHServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+
+	server := &testSSHServer{signer: newTestSigner(t), port: listener.Addr().(*net.TCPAddr).Port}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			config := &ssh.ServerConfig{NoClientAuth: true}
+			server.mutex.Lock()
+			config.AddHostKey(server.signer)
+			server.mutex.Unlock()
+			go func() {
+				defer func() {
+					_ = conn.Close()
+				}()
+				_, _, _, _ = ssh.NewServerConn(conn, config)
+			}()
+		}
+	}()
+	return server
+}
+
+func (s *testSSHServer) rekey(t *testing.T) ssh.PublicKey {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.signer = newTestSigner(t)
+	return s.signer.PublicKey()
+}
+
+func (s *testSSHServer) hostKey() ssh.PublicKey {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.signer.PublicKey()
+}
+
+func (s *testSSHServer) consoles() map[string]*nodes.NodeConsoleInfo {
+	return map[string]*nodes.NodeConsoleInfo{
+		"x1000c0s1b0": {ID: "x1000c0s1b0", ConnectionType: nodes.SSH, ConnectionHost: "127.0.0.1", ConnectionPort: s.port},
+		"x1000c0s2b0": {ID: "x1000c0s2b0", ConnectionType: nodes.IPMI, ConnectionHost: "127.0.0.1"},
+	}
+}
+
+func testHostKeyConfig(t *testing.T, mode string) CredsConfig {
+	tempDir := t.TempDir()
+	config := DefaultCredsConfig()
+	config.SecureStorageAdapter = StorageAdapterFile
+	config.CredentialsFilePath = filepath.Join(tempDir, "credentials.yaml")
+	config.HostKeyVerification = mode
+	config.KnownHostsPath = filepath.Join(tempDir, "known_hosts")
+	config.HostKeyPinsPath = filepath.Join(tempDir, "pins", "known_hosts")
+	config.HostKeyScanInterval = 0
+	return config
+}
+
+func TestKnownHosts(t *testing.T) {
+	plain := newTestSigner(t).PublicKey()
+	ported := newTestSigner(t).PublicKey()
+	wildcard := newTestSigner(t).PublicKey()
+	hashed := newTestSigner(t).PublicKey()
+	revoked := newTestSigner(t).PublicKey()
+
+	text := knownhosts.Line([]string{"bmc1"}, plain) + "\n" +
+		knownhosts.Line([]string{"[bmc1]:2222"}, ported) + "\n" +
+		"# BMCs of the first chassis, but the one with its own key\n" +
+		knownhosts.Line([]string{"x1000c0s*b0", "!x1000c0s9b0"}, wildcard) + "\n" +
+		knownhosts.Line([]string{knownhosts.HashHostname("[10.0.0.5]:2222")}, hashed) + "\n" +
+		knownhosts.Line([]string{"bmc2"}, revoked) + "\n" +
+		"@revoked * " + string(ssh.MarshalAuthorizedKey(revoked))
+	kh, err := parseKnownHosts(text)
+	require.NoError(t, err)
+
+	require.Equal(t, []ssh.PublicKey{plain}, kh.keys("bmc1:22"))
+	require.Equal(t, []ssh.PublicKey{ported}, kh.keys("bmc1:2222"))
+	require.Equal(t, []ssh.PublicKey{wildcard}, kh.keys("x1000c0s1b0:22"))
+	require.Empty(t, kh.keys("x1000c0s9b0:22"))
+	require.Equal(t, []ssh.PublicKey{hashed}, kh.keys("10.0.0.5:2222"))
+	require.Empty(t, kh.keys("10.0.0.5:22"))
+	require.Empty(t, kh.keys("bmc2:22"))
+
+	require.NoError(t, kh.check("bmc1:22", plain))
+	require.ErrorContains(t, kh.check("bmc1:22", ported), "mismatch")
+	require.ErrorContains(t, kh.check("bmc2:22", revoked), "revoked")
+
+	_, err = parseKnownHosts("bmc1 ssh-ed25519 not-a-key\n")
+	require.Error(t, err)
+}
+
+func TestEnsureKnownHostsTOFU(t *testing.T) {
+	server := startTestSSHServer(t)
+	config := testHostKeyConfig(t, HostKeyVerificationTOFU)
+	address := knownhosts.Normalize(net.JoinHostPort("127.0.0.1", strconv.Itoa(server.port)))
+
+	// Verification is off by default
+	service := NewCredsService(DefaultCredsConfig())
+	path, err := service.EnsureKnownHosts(server.consoles())
+	require.NoError(t, err)
+	service.hostKeys.waitForScans()
+	require.Empty(t, path)
+
+	// The first key seen is pinned and written for ssh
+	service = NewCredsService(config)
+	path, err = service.EnsureKnownHosts(server.consoles())
+	require.NoError(t, err)
+	service.hostKeys.waitForScans()
+	require.Equal(t, config.KnownHostsPath, path)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, knownhosts.Line([]string{address}, server.hostKey())+"\n", string(data))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	require.Empty(t, service.HostKeyMismatches())
+
+	// A changed key is reported against the pin, which is kept
+	pinned := server.hostKey()
+	presented := server.rekey(t)
+	_, err = service.EnsureKnownHosts(server.consoles())
+	require.NoError(t, err)
+	service.hostKeys.waitForScans()
+	mismatches := service.HostKeyMismatches()
+	require.Len(t, mismatches, 1)
+	require.Equal(t, HostKeySourcePinned, mismatches["x1000c0s1b0"].Source)
+	require.Equal(t, ssh.FingerprintSHA256(presented), mismatches["x1000c0s1b0"].Presented)
+	require.Equal(t, []string{ssh.FingerprintSHA256(pinned)}, mismatches["x1000c0s1b0"].Expected)
+	require.Equal(t, float64(1), service.Metrics()[0].Samples[0].Value)
+
+	// The pins outlive the service
+	restarted := NewCredsService(config)
+	_, err = restarted.EnsureKnownHosts(server.consoles())
+	require.NoError(t, err)
+	restarted.hostKeys.waitForScans()
+	require.Contains(t, restarted.HostKeyMismatches(), "x1000c0s1b0")
+
+	// Forgetting the pin lets the new key be pinned
+	forgotten, err := restarted.ForgetHostKey(server.consoles()["x1000c0s1b0"])
+	require.NoError(t, err)
+	require.True(t, forgotten)
+	require.Empty(t, restarted.HostKeyMismatches())
+	_, err = restarted.EnsureKnownHosts(server.consoles())
+	require.NoError(t, err)
+	restarted.hostKeys.waitForScans()
+	require.Empty(t, restarted.HostKeyMismatches())
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, knownhosts.Line([]string{address}, presented)+"\n", string(data))
+
+	forgotten, err = restarted.ForgetHostKey(server.consoles()["x1000c0s2b0"])
+	require.NoError(t, err)
+	require.False(t, forgotten)
+}
+
+func TestEnsureKnownHostsTrusted(t *testing.T) {
+	server := startTestSSHServer(t)
+	config := testHostKeyConfig(t, HostKeyVerificationKnownHosts)
+	config.SecureStorageKnownHostsPath = "known-hosts"
+	address := knownhosts.Normalize(net.JoinHostPort("127.0.0.1", strconv.Itoa(server.port)))
+	writeTrusted := func(key ssh.PublicKey) string {
+		line := knownhosts.Line([]string{address}, key)
+		require.NoError(t, os.WriteFile(config.CredentialsFilePath, []byte("knownHosts: |\n  "+line+"\n"), 0600))
+		return line
+	}
+
+	// Hosts without a trusted key aren't pinned
+	require.NoError(t, os.WriteFile(config.CredentialsFilePath, []byte("credentials: {}\n"), 0600))
+	service := NewCredsService(config)
+	_, err := service.EnsureKnownHosts(server.consoles())
+	require.NoError(t, err)
+	service.hostKeys.waitForScans()
+	data, err := os.ReadFile(config.KnownHostsPath)
+	require.NoError(t, err)
+	require.Empty(t, data)
+	require.NoFileExists(t, config.HostKeyPinsPath)
+
+	// A key other than the trusted one is a mismatch
+	trusted := server.hostKey()
+	writeTrusted(trusted)
+	server.rekey(t)
+	_, err = service.EnsureKnownHosts(server.consoles())
+	require.NoError(t, err)
+	service.hostKeys.waitForScans()
+	mismatches := service.HostKeyMismatches()
+	require.Equal(t, HostKeySourceTrusted, mismatches["x1000c0s1b0"].Source)
+	require.Equal(t, []string{ssh.FingerprintSHA256(trusted)}, mismatches["x1000c0s1b0"].Expected)
+
+	// Trusting the new key clears it, and the trusted keys are kept while
+	// the source can't be read
+	line := writeTrusted(server.hostKey())
+	_, err = service.EnsureKnownHosts(server.consoles())
+	require.NoError(t, err)
+	service.hostKeys.waitForScans()
+	require.Empty(t, service.HostKeyMismatches())
+	require.NoError(t, os.Chmod(config.CredentialsFilePath, 0644))
+	_, err = service.EnsureKnownHosts(server.consoles())
+	require.ErrorContains(t, err, "unable to read trusted host keys")
+	service.hostKeys.waitForScans()
+	data, err = os.ReadFile(config.KnownHostsPath)
+	require.NoError(t, err)
+	require.Equal(t, line+"\n", string(data))
+	require.Empty(t, service.HostKeyMismatches())
+}
+
+func TestEnsureKnownHostsDoesNotWaitForScans(t *testing.T) {
+	// A BMC that accepts connections but never answers
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() {
+				_ = conn.Close()
+			})
+		}
+	}()
+	consoles := map[string]*nodes.NodeConsoleInfo{
+		"x1000c0s1b0": {ID: "x1000c0s1b0", ConnectionType: nodes.SSH, ConnectionHost: "127.0.0.1", ConnectionPort: listener.Addr().(*net.TCPAddr).Port},
+	}
+
+	service := NewCredsService(testHostKeyConfig(t, HostKeyVerificationTOFU))
+	done := make(chan error, 1)
+	go func() {
+		_, err := service.EnsureKnownHosts(consoles)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(hostKeyScanTimeout / 2):
+		require.Fail(t, "the host key scan held up the caller")
+	}
+
+	// Nor does it hold up the mismatches and metrics while it runs
+	require.Empty(t, service.HostKeyMismatches())
+	require.NotEmpty(t, service.Metrics())
+}
@@ -9,65 +9,198 @@ package creds
 
 import (
 	"log/slog"
+	"maps"
+	"slices"
+	"time"
+
+	compcreds "github.com/Cray-HPE/hms-compcredentials"
 
 	"github.com/OpenCHAMI/remote-console/internal/nodes"
 )
 
 type SignalConmanTERM func()
 
+// CredentialChanges is the outcome of one credential check
+type CredentialChanges struct {
+	CheckedAt time.Time `json:"checkedAt"`
+	Checked   int       `json:"checked"`            // nodes whose credentials were checked
+	Nodes     []string  `json:"nodes,omitempty"`    // nodes whose username or password changed
+	NodeKeys  []string  `json:"nodeKeys,omitempty"` // nodes whose own SSH key or certificate changed
+	SharedKey bool      `json:"sharedKey"`          // the shared console SSH key or certificate changed
+	Error     string    `json:"error,omitempty"`
+
+	// The credentials after the check, to reconfigure the consoles that changed
+	Passwords      map[string]compcreds.CompCredentials `json:"-"`
+	NodeKeyPaths   map[string]string                    `json:"-"`
+	KnownHostsPath string                               `json:"-"`
+}
+
+// Changed reports whether any console credentials changed
+func (c CredentialChanges) Changed() bool {
+	return len(c.Nodes) > 0 || len(c.NodeKeys) > 0 || c.SharedKey
+}
+
+// Consoles returns the consoles whose credentials changed, including the SSH
+// consoles using the shared key when it changed
+func (c CredentialChanges) Consoles(consoles map[string]*nodes.NodeConsoleInfo) []string {
+	changed := make(map[string]struct{})
+	for _, xname := range c.Nodes {
+		changed[xname] = struct{}{}
+	}
+	for _, xname := range c.NodeKeys {
+		changed[xname] = struct{}{}
+	}
+	if c.SharedKey {
+		for _, nci := range consoles {
+			if _, ok := c.NodeKeyPaths[nci.ID]; nci.ConnectionType == nodes.SSH && !ok {
+				changed[nci.ID] = struct{}{}
+			}
+		}
+	}
+	return slices.Sorted(maps.Keys(changed))
+}
+
 // function to do check for credential changes
-func (cs *CredsService) CheckForUpdates() (bool, error) {
+func (cs *CredsService) CheckForUpdates() (CredentialChanges, error) {
 	currentNodes := nodes.CurrentNodes()
 	ids := make([]string, 0, len(currentNodes))
 	for _, nci := range currentNodes {
 		ids = append(ids, nci.ID)
 	}
+	changes := CredentialChanges{CheckedAt: time.Now().UTC(), Checked: len(ids)}
+
+	fail := func(err error) (CredentialChanges, error) {
+		changes.Error = err.Error()
+		cs.setLastCheck(changes)
+		return changes, err
+	}
 
-	keysChanged := false
 	// Only check keys if SecureStorageSshKeysPath is configured
 	if cs.config.SecureStorageSshKeysPath != "" {
 		var err error
-		keysChanged, err = cs.checkIfKeysChanged()
+		changes.SharedKey, err = cs.checkIfKeysChanged()
 		if err != nil {
-			return false, err
+			return fail(err)
 		}
 	}
 
-	passwordsChanged, err := cs.checkIfPasswordsChanged(ids)
+	var err error
+	changes.NodeKeyPaths, changes.NodeKeys, err = cs.checkIfNodeKeysChanged(ids)
+	if err != nil {
+		slog.Error("Error checking per-node SSH keys", "error", err)
+	}
+
+	// ssh reads the known_hosts file on each connection, so conman needn't restart
+	changes.KnownHostsPath, err = cs.EnsureKnownHosts(currentNodes)
+	if err != nil {
+		slog.Error("Error checking SSH host keys", "error", err)
+	}
+
+	// The nodes that resolved are still updated when others failed
+	changes.Passwords, changes.Nodes, err = cs.checkIfPasswordsChanged(ids)
 	if err != nil {
-		return false, err
+		changes.Error = err.Error()
 	}
 
-	return (len(ids) > 0 && passwordsChanged) || keysChanged, nil
+	slog.Info("Credential check complete", "checked", changes.Checked, "changed", len(changes.Nodes),
+		"nodeKeysChanged", len(changes.NodeKeys), "sharedKeyChanged", changes.SharedKey)
+	cs.setLastCheck(changes)
+	return changes, err
 }
 
-func (cs *CredsService) checkIfPasswordsChanged(xnames []string) (bool, error) {
-	if cs.previousPasswords == nil {
-		return false, nil
+// setLastCheck records the outcome of the last credential check
+func (cs *CredsService) setLastCheck(changes CredentialChanges) {
+	cs.checkMutex.Lock()
+	defer cs.checkMutex.Unlock()
+	cs.lastCheck = &changes
+}
+
+// LastCredentialCheck returns the outcome of the last credential check, and
+// false when credentials haven't been checked yet
+func (cs *CredsService) LastCredentialCheck() (CredentialChanges, bool) {
+	cs.checkMutex.Lock()
+	defer cs.checkMutex.Unlock()
+	if cs.lastCheck == nil {
+		return CredentialChanges{}, false
 	}
-	currentPasswords, err := getPasswords(cs.config, xnames)
+	return *cs.lastCheck, true
+}
 
+// checkIfPasswordsChanged looks the credentials up again and returns them
+// along with the nodes whose username or password changed. Nodes whose
+// credentials can't be found keep their previous ones. When the lookup
+// partly fails, the nodes it resolved are still returned with the error.
+func (cs *CredsService) checkIfPasswordsChanged(xnames []string) (map[string]compcreds.CompCredentials, []string, error) {
+	if cs.previousPasswords == nil {
+		return nil, nil, nil
+	}
+	currentPasswords, sources, err := resolveCredentials(cs.config, xnames)
 	if err != nil {
 		slog.Error("Error retrieving passwords while checking for credential changes", "error", err)
-		return false, err
 	}
+	previousSources, _ := cs.CredentialSources()
+
+	passwords := make(map[string]compcreds.CompCredentials, len(xnames))
+	var changed []string
 	for _, xname := range xnames {
+		previousCreds, hadCreds := cs.previousPasswords[xname]
 		currentCreds, ok := currentPasswords[xname]
+		// The level a node's credentials came from may be the one failing,
+		// so don't fall back to another level until it answers again
+		if ok && err != nil && hadCreds && sources[xname] != CredentialSourceNode && sources[xname] != previousSources[xname] {
+			ok = false
+		}
 		if !ok {
 			slog.Warn("Missing credentials detected while checking for credential changes", "xname", xname)
+			if hadCreds {
+				passwords[xname] = previousCreds
+			}
 			continue
 		}
-		previousCreds := cs.previousPasswords[xname]
+		passwords[xname] = currentCreds
 
-		if (currentCreds.Username != previousCreds.Username) || (currentCreds.Password != previousCreds.Password) {
-			slog.Info("Change detected in the passwords. Conman will be reconfigured.")
-			return true, nil
+		if !hadCreds || (currentCreds.Username != previousCreds.Username) || (currentCreds.Password != previousCreds.Password) {
+			slog.Info("Change detected in the credentials", "xname", xname, "source", sources[xname])
+			changed = append(changed, xname)
 		}
 	}
+	slices.Sort(changed)
 
-	return false, nil
+	cs.previousPasswords = passwords
+	if len(changed) > 0 {
+		cs.sourcesMutex.Lock()
+		updated := maps.Clone(cs.sources)
+		if updated == nil {
+			updated = make(map[string]CredentialSource)
+		}
+		for _, xname := range changed {
+			updated[xname] = sources[xname]
+		}
+		cs.sources = updated
+		cs.sourcesMutex.Unlock()
+	}
+	return passwords, changed, err
 }
 
 func (cs *CredsService) checkIfKeysChanged() (bool, error) {
 	return cs.EnsureConsoleKeysPresent()
 }
+
+// checkIfNodeKeysChanged writes the per-node SSH keys that changed and
+// returns the key file of each node that has one and the nodes whose keys
+// changed
+func (cs *CredsService) checkIfNodeKeysChanged(xnames []string) (map[string]string, []string, error) {
+	cs.keysMutex.Lock()
+	written := cs.nodeKeyHashes != nil
+	cs.keysMutex.Unlock()
+	// The keys are first written when conman is configured
+	if !written {
+		return nil, nil, nil
+	}
+
+	files, changed, err := cs.EnsureNodeKeysPresent(xnames)
+	if len(changed) > 0 {
+		slog.Info("Change detected in the per-node SSH keys", "xnames", changed)
+	}
+	return files, changed, err
+}
@@ -7,12 +7,16 @@ package creds
 import (
 	"context"
 	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/Cray-HPE/hms-securestorage"
+
+	"github.com/OpenCHAMI/remote-console/internal/nodes"
 )
 
 func TestCheckIfPasswordsChanged(t *testing.T) {
@@ -49,12 +53,12 @@ func TestCheckIfPasswordsChanged(t *testing.T) {
 
 	service := NewCredsService(config)
 
-	changed, err := service.checkIfPasswordsChanged(nodes)
+	_, changed, err := service.checkIfPasswordsChanged(nodes)
 	if err != nil {
 		t.Fatalf("Error checking if passwords changed: %v", err)
 	}
 
-	require.False(t, changed, "Passwords should not have changed")
+	require.Empty(t, changed, "Passwords should not have changed")
 
 	// Call GetPasswordsWithRetry to set previousPasswords
 	_, err = service.GetPasswordsWithRetries(context.Background(), nodes, 3, 1)
@@ -70,12 +74,49 @@ func TestCheckIfPasswordsChanged(t *testing.T) {
 	err = ss.Store("hms-creds/x0c0s1b0", value)
 	require.NoError(t, err)
 
-	changed, err = service.checkIfPasswordsChanged(nodes)
+	passwords, changed, err := service.checkIfPasswordsChanged(nodes)
 	if err != nil {
 		t.Fatalf("Error checking if passwords changed: %v", err)
 	}
 
-	require.True(t, changed, "Passwords should have changed")
+	require.Equal(t, []string{"x0c0s1b0"}, changed, "Only the changed password should be reported")
+	require.Equal(t, "newpassword", passwords["x0c0s1b0"].Password)
+	require.Equal(t, "password2", passwords["x0c0s1b1"].Password)
+
+	// The change is only reported once
+	_, changed, err = service.checkIfPasswordsChanged(nodes)
+	require.NoError(t, err)
+	require.Empty(t, changed)
+}
+
+func TestCheckIfPasswordsChangedPartialFailure(t *testing.T) {
+	tempDir := t.TempDir()
+	config := DefaultCredsConfig()
+	config.SecureStorageAdapter = StorageAdapterFile
+	config.CredentialsFilePath = filepath.Join(tempDir, "credentials.yaml")
+	config.CredentialRulesFilePath = filepath.Join(tempDir, "rules.yaml")
+	config.DefaultUsername = "root"
+	require.NoError(t, os.WriteFile(config.CredentialsFilePath, []byte("credentials:\n  x1000c0s1b0:\n    username: admin\n    password: node1\n"), 0600))
+	require.NoError(t, os.WriteFile(config.CredentialRulesFilePath, []byte(testCredentialRules), 0600))
+	xnames := []string{"x1000c0s1b0", "x1000c0s2b0"}
+
+	service := NewCredsService(config)
+	_, err := service.GetPasswordsWithRetries(context.Background(), xnames, 1, 0)
+	require.NoError(t, err)
+
+	// The secure storage fails while a rule changes
+	require.NoError(t, os.WriteFile(config.CredentialsFilePath, []byte("credentials: ["), 0600))
+	rules := strings.Replace(testCredentialRules, "cabinet1", "cabinet2", 1)
+	require.NoError(t, os.WriteFile(config.CredentialRulesFilePath, []byte(rules), 0600))
+
+	passwords, changed, err := service.checkIfPasswordsChanged(xnames)
+	require.Error(t, err)
+	require.Equal(t, []string{"x1000c0s2b0"}, changed)
+	require.Equal(t, "cabinet2", passwords["x1000c0s2b0"].Password)
+	// The node doesn't fall back to the default while its own lookup fails
+	require.Equal(t, "node1", passwords["x1000c0s1b0"].Password)
+	sources, _ := service.CredentialSources()
+	require.Equal(t, CredentialSourceNode, sources["x1000c0s1b0"])
 }
 
 func TestCheckIfKeysChanged(t *testing.T) {
@@ -126,3 +167,43 @@ func TestCheckIfKeysChanged(t *testing.T) {
 	require.NoError(t, err)
 	require.True(t, changed, "Keys should have changed after update")
 }
+
+func TestCredentialChangesConsoles(t *testing.T) {
+	consoles := map[string]*nodes.NodeConsoleInfo{
+		"x0c0s1b0": {ID: "x0c0s1b0", ConnectionType: nodes.IPMI},
+		"x0c0s2b0": {ID: "x0c0s2b0", ConnectionType: nodes.SSH},
+		"x0c0s3b0": {ID: "x0c0s3b0", ConnectionType: nodes.SSH},
+		"x0c0s4b0": {ID: "x0c0s4b0", ConnectionType: nodes.SSH},
+	}
+	changes := CredentialChanges{
+		Nodes:        []string{"x0c0s1b0"},
+		NodeKeys:     []string{"x0c0s3b0"},
+		NodeKeyPaths: map[string]string{"x0c0s3b0": "/app/conman-keys/x0c0s3b0", "x0c0s4b0": "/app/conman-keys/x0c0s4b0"},
+	}
+	require.True(t, changes.Changed())
+	require.Equal(t, []string{"x0c0s1b0", "x0c0s3b0"}, changes.Consoles(consoles))
+
+	// The shared key only reaches the SSH consoles without keys of their own
+	changes.SharedKey = true
+	require.Equal(t, []string{"x0c0s1b0", "x0c0s2b0", "x0c0s3b0"}, changes.Consoles(consoles))
+
+	require.False(t, CredentialChanges{}.Changed())
+}
+
+func TestCheckForUpdatesRecordsLastCheck(t *testing.T) {
+	config := DefaultCredsConfig()
+	config.SecureStorageAdapter = StorageAdapterFile
+	config.CredentialsFilePath = filepath.Join(t.TempDir(), "credentials.yaml")
+	service := NewCredsService(config)
+
+	_, checked := service.LastCredentialCheck()
+	require.False(t, checked)
+
+	changes, err := service.CheckForUpdates()
+	require.NoError(t, err)
+	require.False(t, changes.Changed())
+	last, checked := service.LastCredentialCheck()
+	require.True(t, checked)
+	require.Equal(t, changes.CheckedAt, last.CheckedAt)
+	require.Empty(t, last.Error)
+}
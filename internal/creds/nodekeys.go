@@ -0,0 +1,203 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// This file contains the functions to resolve and write the SSH keys of
+// each node
+
+package creds
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// nodeKeyFile returns where the private key of the node is written. Its
+// certificate is written next to it, where ssh looks for it.
+func (cs *CredsService) nodeKeyFile(xname string) string {
+	return filepath.Join(cs.config.SshNodeKeysDir, xname)
+}
+
+// lookupNodeKeys returns the node's own SSH keys, or else those of the
+// first SSH key rule it matches. Lookups are cached by path so keys shared
+// by a zone are read once.
+func lookupNodeKeys(provider CredentialsProvider, config CredsConfig, rules credentialRules, xname string, cache map[string]SSHKeys) (SSHKeys, error) {
+	var paths []string
+	if config.SecureStorageNodeSshKeysPath != "" {
+		paths = append(paths, config.SecureStorageNodeSshKeysPath+"/"+xname)
+	}
+	for _, rule := range rules.SSHKeys {
+		if ok, _ := matchXname(rule.Match, xname); ok {
+			paths = append(paths, rule.Path)
+			break
+		}
+	}
+
+	for _, path := range paths {
+		keys, ok := cache[path]
+		if !ok {
+			var err error
+			keys, err = provider.SSHKeys(path)
+			if errors.Is(err, ErrNoSSHKeys) {
+				cache[path] = SSHKeys{}
+				continue
+			}
+			if err != nil {
+				return SSHKeys{}, fmt.Errorf("unable to lookup SSH keys at %q: %w", path, err)
+			}
+			cache[path] = keys
+		}
+		if keys.PrivateKey != "" {
+			return keys, nil
+		}
+	}
+	return SSHKeys{}, ErrNoSSHKeys
+}
+
+// writePrivateFile replaces a file with one only the owner can read, so a
+// console connecting meanwhile reads the old or the new file whole
+func writePrivateFile(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	}()
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// writeNodeKeys writes the private key and certificate of the node
+func (cs *CredsService) writeNodeKeys(xname string, keys SSHKeys) error {
+	keyFile := cs.nodeKeyFile(xname)
+	// The certificate is written first, so the new key isn't used with the old one
+	if keys.Certificate != nil {
+		if err := writePrivateFile(keyFile+"-cert.pub", []byte(*keys.Certificate)); err != nil {
+			return fmt.Errorf("failed to write SSH certificate of %s: %w", xname, err)
+		}
+	} else if err := os.Remove(keyFile + "-cert.pub"); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove SSH certificate of %s: %w", xname, err)
+	}
+	if err := writePrivateFile(keyFile, []byte(keys.PrivateKey)); err != nil {
+		return fmt.Errorf("failed to write SSH key of %s: %w", xname, err)
+	}
+	return nil
+}
+
+// removeNodeKeys removes the key files of a node left without keys of its own
+func (cs *CredsService) removeNodeKeys(xname string) {
+	keyFile := cs.nodeKeyFile(xname)
+	for _, path := range []string{keyFile, keyFile + "-cert.pub"} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("Failed to remove SSH key file", "path", path, "error", err)
+		}
+	}
+	delete(cs.nodeKeyHashes, xname)
+	cs.trackCertificate(xname, nil)
+}
+
+// nodeKeyFiles returns the key file of each of the nodes that has one
+func (cs *CredsService) nodeKeyFiles(xnames []string) map[string]string {
+	files := make(map[string]string)
+	for _, xname := range xnames {
+		if _, ok := cs.nodeKeyHashes[xname]; ok {
+			files[xname] = cs.nodeKeyFile(xname)
+		}
+	}
+	return files
+}
+
+// EnsureNodeKeysPresent writes the SSH keys of the nodes that have their
+// own, or their zone's, to private per-node files. It returns the key file of
+// each such node, for the others to use the shared key, and the nodes whose
+// keys changed. A node whose keys can't be read keeps its current file.
+func (cs *CredsService) EnsureNodeKeysPresent(xnames []string) (map[string]string, []string, error) {
+	cs.keysMutex.Lock()
+	defer cs.keysMutex.Unlock()
+
+	rules, err := loadCredentialRules(cs.config.CredentialRulesFilePath)
+	if err != nil {
+		return cs.nodeKeyFiles(xnames), nil, err
+	}
+	if cs.nodeKeyHashes == nil {
+		cs.nodeKeyHashes = make(map[string][]byte)
+	}
+	if cs.config.SecureStorageNodeSshKeysPath == "" && len(rules.SSHKeys) == 0 && len(cs.nodeKeyHashes) == 0 {
+		return nil, nil, nil
+	}
+	provider, err := newCredentialsProvider(cs.config)
+	if err != nil {
+		return cs.nodeKeyFiles(xnames), nil, err
+	}
+	if err := os.MkdirAll(cs.config.SshNodeKeysDir, 0700); err != nil {
+		return cs.nodeKeyFiles(xnames), nil, fmt.Errorf("failed to create SSH node keys directory: %w", err)
+	}
+
+	cache := make(map[string]SSHKeys)
+	var changed []string
+	var errs []error
+	for _, xname := range xnames {
+		keys, err := lookupNodeKeys(provider, cs.config, rules, xname, cache)
+		if errors.Is(err, ErrNoSSHKeys) {
+			if _, ok := cs.nodeKeyHashes[xname]; ok {
+				slog.Info("Node no longer has SSH keys of its own, using the shared key", "xname", xname)
+				cs.removeNodeKeys(xname)
+				changed = append(changed, xname)
+			}
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		keys.Certificate = cs.currentCertificate(xname, keys.Certificate)
+		certificate := ""
+		if keys.Certificate != nil {
+			certificate = *keys.Certificate
+		}
+		newHash, err := hashString(keys.PrivateKey + "\x00" + certificate)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if bytes.Equal(newHash, cs.nodeKeyHashes[xname]) {
+			cs.trackCertificate(xname, keys.Certificate)
+			continue
+		}
+		if err := cs.writeNodeKeys(xname, keys); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		cs.nodeKeyHashes[xname] = newHash
+		cs.trackCertificate(xname, keys.Certificate)
+		changed = append(changed, xname)
+		slog.Info("Node SSH key file written", "xname", xname, "certificate", keys.Certificate != nil)
+	}
+
+	// Nodes that are gone take their keys with them
+	current := make(map[string]struct{}, len(xnames))
+	for _, xname := range xnames {
+		current[xname] = struct{}{}
+	}
+	for xname := range cs.nodeKeyHashes {
+		if _, ok := current[xname]; !ok {
+			cs.removeNodeKeys(xname)
+		}
+	}
+
+	slices.Sort(changed)
+	return cs.nodeKeyFiles(xnames), changed, errors.Join(errs...)
+}
@@ -0,0 +1,106 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package creds
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnsureNodeKeysPresent(t *testing.T) {
+	tempDir := t.TempDir()
+	config := DefaultCredsConfig()
+	config.SecureStorageAdapter = StorageAdapterFile
+	config.CredentialsFilePath = filepath.Join(tempDir, "credentials.yaml")
+	config.CredentialRulesFilePath = filepath.Join(tempDir, "rules.yaml")
+	config.SecureStorageNodeSshKeysPath = "node-keys"
+	config.SshNodeKeysDir = filepath.Join(tempDir, "keys")
+	xnames := []string{"x1000c0s1b0", "x1000c0s2b0", "x1001c0s1b0"}
+
+	writeKeys := func(keys string) {
+		require.NoError(t, os.WriteFile(config.CredentialsFilePath, []byte("sshKeyPaths:\n"+keys), 0600))
+	}
+	writeKeys(`
+  node-keys/x1000c0s1b0:
+    privateKey: node-key
+    certificate: node-cert
+  zones/x1000:
+    privateKey: zone-key
+`)
+	require.NoError(t, os.WriteFile(config.CredentialRulesFilePath, []byte("sshKeys:\n  - match: x1000*\n    path: zones/x1000\n"), 0600))
+
+	service := NewCredsService(config)
+	_, changed, err := service.checkIfNodeKeysChanged(xnames)
+	require.NoError(t, err)
+	require.Empty(t, changed, "Keys are first written when conman is configured")
+
+	// Nodes get their own keys, else their zone's, else none to use the shared key
+	files, updated, err := service.EnsureNodeKeysPresent(xnames)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"x1000c0s1b0": filepath.Join(config.SshNodeKeysDir, "x1000c0s1b0"),
+		"x1000c0s2b0": filepath.Join(config.SshNodeKeysDir, "x1000c0s2b0"),
+	}, files)
+	require.Equal(t, []string{"x1000c0s1b0", "x1000c0s2b0"}, updated)
+
+	readKey := func(name string) string {
+		path := filepath.Join(config.SshNodeKeysDir, name)
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0600), info.Mode().Perm(), name)
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		return string(data)
+	}
+	require.Equal(t, "node-key", readKey("x1000c0s1b0"))
+	require.Equal(t, "node-cert", readKey("x1000c0s1b0-cert.pub"))
+	require.Equal(t, "zone-key", readKey("x1000c0s2b0"))
+	require.NoFileExists(t, filepath.Join(config.SshNodeKeysDir, "x1000c0s2b0-cert.pub"))
+	info, err := os.Stat(config.SshNodeKeysDir)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0700), info.Mode().Perm())
+
+	_, changed, err = service.checkIfNodeKeysChanged(xnames)
+	require.NoError(t, err)
+	require.Empty(t, changed)
+
+	// Each node's keys are rotated on their own
+	writeKeys(`
+  node-keys/x1000c0s1b0:
+    privateKey: node-key
+    certificate: renewed-cert
+  zones/x1000:
+    privateKey: zone-key
+`)
+	_, updated, err = service.EnsureNodeKeysPresent(xnames)
+	require.NoError(t, err)
+	require.Equal(t, []string{"x1000c0s1b0"}, updated)
+	require.Equal(t, "renewed-cert", readKey("x1000c0s1b0-cert.pub"))
+
+	// A node whose own keys are removed falls back to its zone's
+	writeKeys(`
+  zones/x1000:
+    privateKey: zone-key
+`)
+	_, changed, err = service.checkIfNodeKeysChanged(xnames)
+	require.NoError(t, err)
+	require.Equal(t, []string{"x1000c0s1b0"}, changed)
+	_, _, err = service.EnsureNodeKeysPresent(xnames)
+	require.NoError(t, err)
+	require.Equal(t, "zone-key", readKey("x1000c0s1b0"))
+	require.NoFileExists(t, filepath.Join(config.SshNodeKeysDir, "x1000c0s1b0-cert.pub"))
+
+	// Keys that can't be read are kept, and nodes that are gone lose theirs
+	require.NoError(t, os.Chmod(config.CredentialsFilePath, 0644))
+	files, updated, err = service.EnsureNodeKeysPresent(xnames[1:])
+	require.Error(t, err)
+	require.Empty(t, updated)
+	require.Equal(t, map[string]string{"x1000c0s2b0": filepath.Join(config.SshNodeKeysDir, "x1000c0s2b0")}, files)
+	require.NoFileExists(t, filepath.Join(config.SshNodeKeysDir, "x1000c0s1b0"))
+	require.FileExists(t, filepath.Join(config.SshNodeKeysDir, "x1000c0s2b0"))
+}
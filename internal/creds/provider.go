@@ -0,0 +1,331 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// This file contains the providers that console credentials and SSH keys are
+// looked up from
+
+package creds
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	compcreds "github.com/Cray-HPE/hms-compcredentials"
+	sstorage "github.com/Cray-HPE/hms-securestorage"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrNoSSHKeys is returned by providers that hold no console SSH keys
+var ErrNoSSHKeys = errors.New("no console SSH keys found")
+
+// ErrNoKnownHosts is returned by providers that hold no trusted SSH host keys
+var ErrNoKnownHosts = errors.New("no trusted SSH host keys found")
+
+// CredentialsProvider looks up console credentials and SSH keys
+type CredentialsProvider interface {
+	// Credentials returns the credentials of the xnames, leaving out those it has none for
+	Credentials(xnames []string) (map[string]compcreds.CompCredentials, error)
+	// SSHKeys returns the console SSH keys stored under path
+	SSHKeys(path string) (SSHKeys, error)
+	// KnownHosts returns the trusted BMC host keys, in known_hosts format, stored under path
+	KnownHosts(path string) (string, error)
+}
+
+// SSHKeys is the key pair used for SSH consoles
+type SSHKeys struct {
+	PrivateKey  string  `json:"privateKey" yaml:"privateKey"`
+	Certificate *string `json:"certificate" yaml:"certificate"`
+}
+
+// nodeCredentials is how a file backend stores the credentials of a node
+type nodeCredentials struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+func (nc nodeCredentials) compCredentials(xname string) compcreds.CompCredentials {
+	return compcreds.CompCredentials{Xname: xname, Username: nc.Username, Password: nc.Password}
+}
+
+// newCredentialsProvider creates the provider for the configured storage adapter
+func newCredentialsProvider(config CredsConfig) (CredentialsProvider, error) {
+	switch config.SecureStorageAdapter {
+	case StorageAdapterVault, StorageAdapterLocal:
+		ss, err := createSecureStorage(config)
+		if err != nil {
+			return nil, fmt.Errorf("error creating secure storage adapter: %w", err)
+		}
+		return secureStorageProvider{ss: ss, passwordsPath: config.SecureStoragePasswordsPath}, nil
+	case StorageAdapterFile:
+		return fileProvider{path: config.CredentialsFilePath, sshKeysPath: config.SecureStorageSshKeysPath}, nil
+	case StorageAdapterSecretsDir:
+		return secretsDirProvider{dir: config.SecretsDirPath}, nil
+	case StorageAdapterEnv:
+		return envProvider{}, nil
+	default:
+		return nil, fmt.Errorf("invalid secure storage adapter type: %s", config.SecureStorageAdapter)
+	}
+}
+
+// secureStorageProvider looks credentials up in Vault or the hms-securestorage encrypted file
+type secureStorageProvider struct {
+	ss            sstorage.SecureStorage
+	passwordsPath string
+}
+
+func (p secureStorageProvider) Credentials(xnames []string) (map[string]compcreds.CompCredentials, error) {
+	ccs := compcreds.NewCompCredStore(p.passwordsPath, p.ss)
+	ccreds, err := ccs.GetCompCreds(xnames)
+	if err != nil {
+		return nil, fmt.Errorf("error creating comp creds store: %w", err)
+	}
+	return ccreds, nil
+}
+
+func (p secureStorageProvider) SSHKeys(path string) (SSHKeys, error) {
+	var keys SSHKeys
+	if err := p.ss.Lookup(path, &keys); err != nil {
+		// The local store has no error type for a missing secret
+		if strings.HasPrefix(err.Error(), "no secret found") {
+			return SSHKeys{}, ErrNoSSHKeys
+		}
+		return SSHKeys{}, err
+	}
+	// Vault reports a missing secret as empty
+	if keys.PrivateKey == "" {
+		return SSHKeys{}, ErrNoSSHKeys
+	}
+	return keys, nil
+}
+
+func (p secureStorageProvider) KnownHosts(path string) (string, error) {
+	var secret struct {
+		KnownHosts string `json:"knownHosts"`
+	}
+	if err := p.ss.Lookup(path, &secret); err != nil {
+		if strings.HasPrefix(err.Error(), "no secret found") {
+			return "", ErrNoKnownHosts
+		}
+		return "", err
+	}
+	if secret.KnownHosts == "" {
+		return "", ErrNoKnownHosts
+	}
+	return secret.KnownHosts, nil
+}
+
+// credentialsFile is the layout of the plaintext credentials file
+type credentialsFile struct {
+	Credentials map[string]nodeCredentials `yaml:"credentials"`
+	SSHKeys     *SSHKeys                   `yaml:"sshKeys"`     // the shared console keys
+	SSHKeyPaths map[string]SSHKeys         `yaml:"sshKeyPaths"` // further keys by secure storage path
+	KnownHosts  string                     `yaml:"knownHosts"`  // trusted BMC host keys
+}
+
+// fileProvider reads a plaintext YAML credentials file that only its owner may read
+type fileProvider struct {
+	path        string
+	sshKeysPath string // the path the shared console keys are looked up under
+}
+
+// The files holding secrets are kept until they change, so each credential
+// check only reads the files that did
+var (
+	privateFilesMutex sync.Mutex
+	privateFiles      = make(map[string]privateFile)
+)
+
+type privateFile struct {
+	info os.FileInfo
+	data []byte
+}
+
+// unchanged reports whether the file is the one read before, as a mounted
+// secret is replaced by a new file rather than rewritten
+func (pf privateFile) unchanged(info os.FileInfo) bool {
+	return os.SameFile(pf.info, info) && pf.info.ModTime().Equal(info.ModTime()) && pf.info.Size() == info.Size()
+}
+
+// readPrivateFile reads a file holding secrets, refusing it when others can access it
+func readPrivateFile(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		privateFilesMutex.Lock()
+		delete(privateFiles, path)
+		privateFilesMutex.Unlock()
+		return nil, err
+	}
+	if perm := info.Mode().Perm(); perm&0077 != 0 {
+		return nil, fmt.Errorf("credentials file %q must not be accessible by group or others (mode %#o)", path, perm)
+	}
+
+	privateFilesMutex.Lock()
+	cached, ok := privateFiles[path]
+	privateFilesMutex.Unlock()
+	if ok && cached.unchanged(info) {
+		return cached.data, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	privateFilesMutex.Lock()
+	privateFiles[path] = privateFile{info: info, data: data}
+	privateFilesMutex.Unlock()
+	return data, nil
+}
+
+func (p fileProvider) load() (credentialsFile, error) {
+	data, err := readPrivateFile(p.path)
+	if err != nil {
+		return credentialsFile{}, fmt.Errorf("failed to read credentials file: %w", err)
+	}
+	var cf credentialsFile
+	if err := yaml.Unmarshal(data, &cf); err != nil {
+		return credentialsFile{}, fmt.Errorf("failed to parse credentials file %q: %w", p.path, err)
+	}
+	return cf, nil
+}
+
+func (p fileProvider) Credentials(xnames []string) (map[string]compcreds.CompCredentials, error) {
+	cf, err := p.load()
+	if err != nil {
+		return nil, err
+	}
+	ccreds := make(map[string]compcreds.CompCredentials)
+	for _, xname := range xnames {
+		if nc, ok := cf.Credentials[xname]; ok {
+			ccreds[xname] = nc.compCredentials(xname)
+		}
+	}
+	return ccreds, nil
+}
+
+func (p fileProvider) SSHKeys(path string) (SSHKeys, error) {
+	cf, err := p.load()
+	if err != nil {
+		return SSHKeys{}, err
+	}
+	if keys, ok := cf.SSHKeyPaths[path]; ok {
+		return keys, nil
+	}
+	if path != p.sshKeysPath || cf.SSHKeys == nil {
+		return SSHKeys{}, ErrNoSSHKeys
+	}
+	return *cf.SSHKeys, nil
+}
+
+func (p fileProvider) KnownHosts(path string) (string, error) {
+	cf, err := p.load()
+	if err != nil {
+		return "", err
+	}
+	if cf.KnownHosts == "" {
+		return "", ErrNoKnownHosts
+	}
+	return cf.KnownHosts, nil
+}
+
+// secretsDirProvider reads a directory of mounted secrets, such as a
+// Kubernetes secret volume, with a YAML file of credentials per xname. SSH
+// keys are looked up by their path within the directory.
+type secretsDirProvider struct {
+	dir string
+}
+
+// secretPath returns the path of a secret. Secret names can't reach outside
+// the directory or into the hidden entries of a mounted volume.
+func (p secretsDirProvider) secretPath(name string) (string, error) {
+	if !filepath.IsLocal(name) || slices.ContainsFunc(strings.Split(filepath.ToSlash(name), "/"), func(elem string) bool {
+		return strings.HasPrefix(elem, ".")
+	}) {
+		return "", fmt.Errorf("invalid secret name %q", name)
+	}
+	return filepath.Join(p.dir, name), nil
+}
+
+func (p secretsDirProvider) read(name string, out any) error {
+	path, err := p.secretPath(name)
+	if err != nil {
+		return err
+	}
+	data, err := readPrivateFile(path)
+	if err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to parse secret %q: %w", path, err)
+	}
+	return nil
+}
+
+func (p secretsDirProvider) Credentials(xnames []string) (map[string]compcreds.CompCredentials, error) {
+	if _, err := os.Stat(p.dir); err != nil {
+		return nil, fmt.Errorf("failed to read secrets directory: %w", err)
+	}
+
+	ccreds := make(map[string]compcreds.CompCredentials)
+	var errs []error
+	for _, xname := range xnames {
+		var nc nodeCredentials
+		err := p.read(xname, &nc)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		ccreds[xname] = nc.compCredentials(xname)
+	}
+	return ccreds, errors.Join(errs...)
+}
+
+func (p secretsDirProvider) SSHKeys(path string) (SSHKeys, error) {
+	var keys SSHKeys
+	if err := p.read(path, &keys); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return SSHKeys{}, ErrNoSSHKeys
+		}
+		return SSHKeys{}, err
+	}
+	return keys, nil
+}
+
+// KnownHosts reads a known_hosts file as it is, since host keys are public
+func (p secretsDirProvider) KnownHosts(path string) (string, error) {
+	secretPath, err := p.secretPath(path)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(secretPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrNoKnownHosts
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// envProvider holds no credentials of its own, so every node resolves to
+// the default credentials from the environment
+type envProvider struct{}
+
+func (p envProvider) Credentials(xnames []string) (map[string]compcreds.CompCredentials, error) {
+	return make(map[string]compcreds.CompCredentials), nil
+}
+
+func (p envProvider) SSHKeys(path string) (SSHKeys, error) {
+	return SSHKeys{}, ErrNoSSHKeys
+}
+
+func (p envProvider) KnownHosts(path string) (string, error) {
+	return "", ErrNoKnownHosts
+}
@@ -0,0 +1,163 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package creds
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileProvider(t *testing.T) {
+	tempDir := t.TempDir()
+	config := DefaultCredsConfig()
+	config.SecureStorageAdapter = StorageAdapterFile
+	config.CredentialsFilePath = filepath.Join(tempDir, "credentials.yaml")
+	config.SshConsoleKeyPath = filepath.Join(tempDir, "conman.key")
+	config.SecureStorageSshKeysPath = "console-keys"
+	nodes := []string{"x0c0s1b0", "x0c0s1b1"}
+
+	require.NoError(t, os.WriteFile(config.CredentialsFilePath, []byte(`
+credentials:
+  x0c0s1b0:
+    username: admin
+    password: password1
+sshKeys:
+  privateKey: test-key
+`), 0600))
+
+	passwords, err := getPasswords(config, nodes)
+	require.NoError(t, err)
+	require.Len(t, passwords, 1)
+	require.Equal(t, "admin", passwords["x0c0s1b0"].Username)
+	require.Equal(t, "password1", passwords["x0c0s1b0"].Password)
+
+	service := NewCredsService(config)
+	changed, err := service.checkIfKeysChanged()
+	require.NoError(t, err)
+	require.True(t, changed)
+	key, err := os.ReadFile(config.SshConsoleKeyPath)
+	require.NoError(t, err)
+	require.Equal(t, "test-key", string(key))
+
+	// Default credentials fill in the nodes missing from the file
+	config.DefaultUsername = "root"
+	config.DefaultPassword = "initial0"
+	passwords, err = getPasswords(config, nodes)
+	require.NoError(t, err)
+	require.Equal(t, "password1", passwords["x0c0s1b0"].Password)
+	require.Equal(t, "root", passwords["x0c0s1b1"].Username)
+	require.Equal(t, "initial0", passwords["x0c0s1b1"].Password)
+
+	// A file others can read is refused
+	require.NoError(t, os.Chmod(config.CredentialsFilePath, 0644))
+	_, err = getPasswords(config, nodes)
+	require.ErrorContains(t, err, "must not be accessible by group or others")
+}
+
+func TestReadPrivateFileRereadsChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(path, []byte("password1"), 0600))
+	data, err := readPrivateFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "password1", string(data))
+
+	// An unchanged file isn't read again
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, []byte("password2"), 0600))
+	require.NoError(t, os.Chtimes(path, info.ModTime(), info.ModTime()))
+	data, err = readPrivateFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "password1", string(data))
+
+	// One that was modified or replaced is
+	require.NoError(t, os.Chtimes(path, time.Now(), info.ModTime().Add(time.Second)))
+	data, err = readPrivateFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "password2", string(data))
+
+	replacement := path + ".new"
+	require.NoError(t, os.WriteFile(replacement, []byte("password3"), 0600))
+	require.NoError(t, os.Chtimes(replacement, time.Now(), info.ModTime().Add(time.Second)))
+	require.NoError(t, os.Rename(replacement, path))
+	data, err = readPrivateFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "password3", string(data))
+}
+
+func TestSecretsDirProvider(t *testing.T) {
+	secretsDir := t.TempDir()
+	config := DefaultCredsConfig()
+	config.SecureStorageAdapter = StorageAdapterSecretsDir
+	config.SecretsDirPath = secretsDir
+	nodes := []string{"x0c0s1b0", "x0c0s1b1", "../x0c0s1b2"}
+
+	// Mounted secrets are symlinks into a hidden data directory
+	dataDir := filepath.Join(secretsDir, "..data")
+	require.NoError(t, os.Mkdir(dataDir, 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "x0c0s1b0"), []byte("username: admin\npassword: password1\n"), 0600))
+	require.NoError(t, os.Symlink(filepath.Join("..data", "x0c0s1b0"), filepath.Join(secretsDir, "x0c0s1b0")))
+
+	passwords, err := getPasswords(config, nodes)
+	require.ErrorContains(t, err, `invalid secret name "../x0c0s1b2"`)
+	require.Len(t, passwords, 1)
+	require.Equal(t, "password1", passwords["x0c0s1b0"].Password)
+
+	provider, err := newCredentialsProvider(config)
+	require.NoError(t, err)
+	_, err = provider.SSHKeys("console-keys")
+	require.ErrorIs(t, err, ErrNoSSHKeys)
+
+	config.SecretsDirPath = filepath.Join(secretsDir, "missing")
+	_, err = getPasswords(config, nodes)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestEnvProvider(t *testing.T) {
+	config := DefaultCredsConfig()
+	config.SecureStorageAdapter = StorageAdapterEnv
+	config.DefaultUsername = "root"
+	config.DefaultPassword = "initial0"
+
+	passwords, err := getPasswords(config, []string{"x0c0s1b0"})
+	require.NoError(t, err)
+	require.Equal(t, "root", passwords["x0c0s1b0"].Username)
+	require.Equal(t, "x0c0s1b0", passwords["x0c0s1b0"].Xname)
+
+	var adapter StorageAdapter
+	require.NoError(t, adapter.Set("secrets-dir"))
+	require.Equal(t, StorageAdapterSecretsDir, adapter)
+	require.Error(t, adapter.Set("consul"))
+}
+
+func TestFileProviderUpdates(t *testing.T) {
+	config := DefaultCredsConfig()
+	config.SecureStorageAdapter = StorageAdapterFile
+	config.CredentialsFilePath = filepath.Join(t.TempDir(), "credentials.yaml")
+	nodes := []string{"x0c0s1b0"}
+	writeCredentials := func(password string) {
+		require.NoError(t, os.WriteFile(config.CredentialsFilePath, []byte("credentials:\n  x0c0s1b0:\n    username: admin\n    password: "+password+"\n"), 0600))
+	}
+
+	writeCredentials("password1")
+	service := NewCredsService(config)
+	_, err := service.GetPasswordsWithRetries(context.Background(), nodes, 1, 0)
+	require.NoError(t, err)
+
+	_, changed, err := service.checkIfPasswordsChanged(nodes)
+	require.NoError(t, err)
+	require.Empty(t, changed)
+
+	// The file is read again on each check, so edits are picked up
+	writeCredentials("password2")
+	_, changed, err = service.checkIfPasswordsChanged(nodes)
+	require.NoError(t, err)
+	require.Equal(t, []string{"x0c0s1b0"}, changed)
+}
@@ -0,0 +1,168 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// This file resolves the credentials of each node through the chain of
+// node, pattern, vendor and default credentials
+
+package creds
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	compcreds "github.com/Cray-HPE/hms-compcredentials"
+	"gopkg.in/yaml.v3"
+
+	"github.com/OpenCHAMI/remote-console/internal/nodes"
+)
+
+// CredentialSource is the level of the resolution chain a node's credentials came from
+type CredentialSource string
+
+const (
+	CredentialSourceNode    CredentialSource = "node"    // the node's own credentials from the provider
+	CredentialSourcePattern CredentialSource = "pattern" // an xname prefix or glob in the rules file
+	CredentialSourceVendor  CredentialSource = "vendor"  // the node's vendor in the rules file
+	CredentialSourceDefault CredentialSource = "default" // the global default credentials
+)
+
+// patternRule gives the nodes matching an xname prefix or glob the same credentials
+type patternRule struct {
+	Match           string `yaml:"match"`
+	nodeCredentials `yaml:",inline"`
+}
+
+// sshKeyRule gives the nodes matching an xname prefix or glob, such as a
+// security zone, the SSH keys stored under a path
+type sshKeyRule struct {
+	Match string `yaml:"match"`
+	Path  string `yaml:"path"`
+}
+
+// credentialRules is the layout of the credential rules file
+type credentialRules struct {
+	Patterns []patternRule              `yaml:"patterns"`
+	Vendors  map[string]nodeCredentials `yaml:"vendors"`
+	SSHKeys  []sshKeyRule               `yaml:"sshKeys"`
+}
+
+func loadCredentialRules(rulesPath string) (credentialRules, error) {
+	var rules credentialRules
+	if rulesPath == "" {
+		return rules, nil
+	}
+
+	data, err := readPrivateFile(rulesPath)
+	if err != nil {
+		return rules, fmt.Errorf("failed to read credential rules file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return rules, fmt.Errorf("failed to parse credential rules file %q: %w", rulesPath, err)
+	}
+	for i, rule := range rules.Patterns {
+		if rule.Match == "" {
+			return rules, fmt.Errorf("credential rule pattern %d has no match", i+1)
+		}
+		if _, err := matchXname(rule.Match, ""); err != nil {
+			return rules, fmt.Errorf("invalid credential rule pattern %q: %w", rule.Match, err)
+		}
+	}
+	for i, rule := range rules.SSHKeys {
+		if rule.Match == "" || rule.Path == "" {
+			return rules, fmt.Errorf("SSH key rule %d needs a match and a path", i+1)
+		}
+		if _, err := matchXname(rule.Match, ""); err != nil {
+			return rules, fmt.Errorf("invalid SSH key rule pattern %q: %w", rule.Match, err)
+		}
+	}
+	return rules, nil
+}
+
+// matchXname matches a glob against the whole xname, or a pattern without
+// glob characters as a prefix
+func matchXname(pattern, xname string) (bool, error) {
+	if !strings.ContainsAny(pattern, "*?[") {
+		return strings.HasPrefix(xname, pattern), nil
+	}
+	return path.Match(pattern, xname)
+}
+
+// match returns the first pattern, then the vendor, credentials for the node
+func (rules credentialRules) match(xname, vendor string) (nodeCredentials, CredentialSource, bool) {
+	for _, rule := range rules.Patterns {
+		if ok, _ := matchXname(rule.Match, xname); ok {
+			return rule.nodeCredentials, CredentialSourcePattern, true
+		}
+	}
+	if vendor != "" {
+		for name, nc := range rules.Vendors {
+			if strings.EqualFold(name, vendor) {
+				return nc, CredentialSourceVendor, true
+			}
+		}
+	}
+	return nodeCredentials{}, "", false
+}
+
+// resolveCredentials looks up each node's own credentials and falls back to
+// the rules file and then the default credentials for the nodes without.
+// It returns the credentials and the level each node's came from.
+func resolveCredentials(config CredsConfig, xnames []string) (map[string]compcreds.CompCredentials, map[string]CredentialSource, error) {
+	provider, err := newCredentialsProvider(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	// A provider error still leaves the nodes it found, and the rest can
+	// be resolved from the rules
+	ccreds, err := provider.Credentials(xnames)
+	if ccreds == nil {
+		ccreds = make(map[string]compcreds.CompCredentials)
+	}
+	rules, rulesErr := loadCredentialRules(config.CredentialRulesFilePath)
+	err = errors.Join(err, rulesErr)
+
+	var defaults *nodeCredentials
+	sources := make(map[string]CredentialSource, len(xnames))
+	for _, xname := range xnames {
+		if _, ok := ccreds[xname]; ok {
+			sources[xname] = CredentialSourceNode
+			continue
+		}
+		if nc, source, ok := rules.match(xname, nodes.Vendor(xname)); ok {
+			ccreds[xname] = nc.compCredentials(xname)
+			sources[xname] = source
+			continue
+		}
+		if config.DefaultUsername == "" {
+			continue
+		}
+		if defaults == nil {
+			password, passwordErr := config.defaultPassword()
+			if passwordErr != nil {
+				err = errors.Join(err, passwordErr)
+				config.DefaultUsername = ""
+				continue
+			}
+			defaults = &nodeCredentials{Username: config.DefaultUsername, Password: password}
+		}
+		ccreds[xname] = defaults.compCredentials(xname)
+		sources[xname] = CredentialSourceDefault
+	}
+	return ccreds, sources, err
+}
+
+// defaultPassword returns the default password, read from the password file
+// when one is set so it can be changed without a restart
+func (config CredsConfig) defaultPassword() (string, error) {
+	if config.DefaultPasswordFile == "" {
+		return config.DefaultPassword, nil
+	}
+	password, err := readTrimmedFile(config.DefaultPasswordFile)
+	if err != nil {
+		return "", fmt.Errorf("unable to read default password file: %w", err)
+	}
+	return password, nil
+}
@@ -0,0 +1,119 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package creds
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testCredentialRules = `
+patterns:
+  - match: x1000c0s1*
+    username: glob
+    password: glob1
+  - match: x1000c0
+    username: cabinet
+    password: cabinet1
+vendors:
+  HPE:
+    username: hpe
+    password: hpe1
+`
+
+func TestCredentialRulesMatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testCredentialRules), 0600))
+	rules, err := loadCredentialRules(path)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		xname, vendor, username string
+		source                  CredentialSource
+	}{
+		{"x1000c0s1b0", "HPE", "glob", CredentialSourcePattern},
+		{"x1000c0s2b0", "HPE", "cabinet", CredentialSourcePattern},
+		{"x1001c0s2b0", "hpe", "hpe", CredentialSourceVendor},
+		{"x1001c0s2b0", "Gigabyte", "", ""},
+		{"x1001c0s2b0", "", "", ""},
+	} {
+		nc, source, ok := rules.match(tc.xname, tc.vendor)
+		require.Equal(t, tc.source != "", ok, tc.xname)
+		require.Equal(t, tc.source, source, tc.xname)
+		require.Equal(t, tc.username, nc.Username, tc.xname)
+	}
+
+	require.NoError(t, os.WriteFile(path, []byte("patterns:\n  - match: x1000[\n"), 0600))
+	_, err = loadCredentialRules(path)
+	require.ErrorContains(t, err, "invalid credential rule pattern")
+}
+
+func TestResolveCredentials(t *testing.T) {
+	tempDir := t.TempDir()
+	config := DefaultCredsConfig()
+	config.SecureStorageAdapter = StorageAdapterFile
+	config.CredentialsFilePath = filepath.Join(tempDir, "credentials.yaml")
+	config.CredentialRulesFilePath = filepath.Join(tempDir, "rules.yaml")
+	require.NoError(t, os.WriteFile(config.CredentialsFilePath, []byte("credentials:\n  x1000c0s1b0:\n    username: admin\n    password: node1\n"), 0600))
+	require.NoError(t, os.WriteFile(config.CredentialRulesFilePath, []byte(testCredentialRules), 0600))
+	xnames := []string{"x1000c0s1b0", "x1000c0s1b1", "x1000c0s2b0", "x1001c0s2b0"}
+
+	// Each node resolves at the first level that has credentials for it
+	passwords, sources, err := resolveCredentials(config, xnames)
+	require.NoError(t, err)
+	require.Equal(t, map[string]CredentialSource{
+		"x1000c0s1b0": CredentialSourceNode,
+		"x1000c0s1b1": CredentialSourcePattern,
+		"x1000c0s2b0": CredentialSourcePattern,
+	}, sources)
+	require.Equal(t, "node1", passwords["x1000c0s1b0"].Password)
+	require.Equal(t, "glob1", passwords["x1000c0s1b1"].Password)
+	require.Equal(t, "cabinet1", passwords["x1000c0s2b0"].Password)
+	require.NotContains(t, passwords, "x1001c0s2b0")
+
+	service := NewCredsService(config)
+	_, resolved := service.CredentialSources()
+	require.False(t, resolved)
+	_, err = service.GetPasswordsWithRetries(context.Background(), xnames, 1, 0)
+	require.NoError(t, err)
+	recorded, resolved := service.CredentialSources()
+	require.True(t, resolved)
+	require.Equal(t, sources, recorded)
+
+	// The global default covers the rest
+	config.DefaultUsername = "root"
+	passwords, sources, err = resolveCredentials(config, xnames)
+	require.NoError(t, err)
+	require.Equal(t, CredentialSourceDefault, sources["x1001c0s2b0"])
+	require.Equal(t, "x1001c0s2b0", passwords["x1001c0s2b0"].Xname)
+	require.Equal(t, "root", passwords["x1001c0s2b0"].Username)
+
+	// The default password is read from its file on every resolution
+	config.DefaultPasswordFile = filepath.Join(t.TempDir(), "default-password")
+	require.NoError(t, os.WriteFile(config.DefaultPasswordFile, []byte("initial0\n"), 0600))
+	passwords, _, err = resolveCredentials(config, xnames)
+	require.NoError(t, err)
+	require.Equal(t, "initial0", passwords["x1001c0s2b0"].Password)
+	require.NoError(t, os.WriteFile(config.DefaultPasswordFile, []byte("initial1\n"), 0600))
+	passwords, _, err = resolveCredentials(config, xnames)
+	require.NoError(t, err)
+	require.Equal(t, "initial1", passwords["x1001c0s2b0"].Password)
+	require.NoError(t, os.Remove(config.DefaultPasswordFile))
+	_, sources, err = resolveCredentials(config, xnames)
+	require.ErrorContains(t, err, "unable to read default password file")
+	require.NotContains(t, sources, "x1001c0s2b0")
+	config.DefaultPasswordFile = ""
+
+	// A rules file others can read is refused, leaving the other levels
+	require.NoError(t, os.Chmod(config.CredentialRulesFilePath, 0644))
+	_, sources, err = resolveCredentials(config, xnames)
+	require.ErrorContains(t, err, "failed to read credential rules file")
+	require.Equal(t, CredentialSourceNode, sources["x1000c0s1b0"])
+	require.Equal(t, CredentialSourceDefault, sources["x1000c0s1b1"])
+}
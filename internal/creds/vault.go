@@ -0,0 +1,453 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// This file contains the Vault secure storage, which authenticates with the
+// configured method and keeps its token renewed
+
+package creds
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	sstorage "github.com/Cray-HPE/hms-securestorage"
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/mapstructure"
+)
+
+// Vault authentication methods
+const (
+	VaultAuthKubernetes = "kubernetes" // service account JWT, the hms-securestorage default
+	VaultAuthToken      = "token"      // a token read from a file or VAULT_TOKEN
+	VaultAuthAppRole    = "approle"    // role ID and a secret ID read from a file
+	VaultAuthCert       = "cert"       // TLS client certificate
+)
+
+// vaultAuthConfig is the part of the configuration that identifies a Vault login
+type vaultAuthConfig struct {
+	basePath            string
+	role                string
+	method              string
+	mount               string
+	tokenFile           string
+	appRoleID           string
+	appRoleSecretIDFile string
+	clientCertFile      string
+	clientKeyFile       string
+}
+
+func newVaultAuthConfig(config CredsConfig) vaultAuthConfig {
+	method := config.VaultAuthMethod
+	if method == "" {
+		method = VaultAuthKubernetes
+	}
+	return vaultAuthConfig{
+		basePath:            config.VaultBasePath,
+		role:                config.VaultRole,
+		method:              method,
+		mount:               config.VaultAuthMount,
+		tokenFile:           config.VaultTokenFile,
+		appRoleID:           config.VaultAppRoleID,
+		appRoleSecretIDFile: config.VaultAppRoleSecretIDFile,
+		clientCertFile:      config.VaultClientCertFile,
+		clientKeyFile:       config.VaultClientKeyFile,
+	}
+}
+
+// The Vault storages are kept across lookups so their tokens are renewed
+// rather than a new one created for every lookup
+var (
+	vaultStoragesMutex sync.Mutex
+	vaultStorages      = make(map[vaultAuthConfig]*vaultStorage)
+)
+
+// getVaultStorage returns the logged in Vault storage for the configuration
+func getVaultStorage(config CredsConfig) (*vaultStorage, error) {
+	auth := newVaultAuthConfig(config)
+
+	vaultStoragesMutex.Lock()
+	defer vaultStoragesMutex.Unlock()
+
+	vs, ok := vaultStorages[auth]
+	if !ok {
+		var err error
+		vs, err = newVaultStorage(auth)
+		if err != nil {
+			return nil, err
+		}
+		vaultStorages[auth] = vs
+	}
+	vs.setCacheTTL(time.Duration(config.VaultSecretCacheTTL) * time.Second)
+	return vs, nil
+}
+
+// vaultStorage implements the hms-securestorage interface for Vault with
+// the authentication methods it lacks. The token is renewed once two thirds
+// of its TTL have passed, and a new one requested when it can't be renewed,
+// has expired or is refused.
+type vaultStorage struct {
+	auth      vaultAuthConfig
+	client    *api.Client
+	mutex     sync.Mutex
+	renewable bool
+	renewAt   time.Time // zero when the token doesn't expire
+	expiresAt time.Time
+
+	cacheTTL time.Duration
+	secrets  map[string]cachedSecret // by key, until they expire
+	sweptAt  time.Time
+}
+
+// cachedSecret is the data of a secret read from Vault, nil when there was none
+type cachedSecret struct {
+	data      map[string]interface{}
+	expiresAt time.Time
+}
+
+func newVaultStorage(auth vaultAuthConfig) (*vaultStorage, error) {
+	// The client reads VAULT_ADDR, VAULT_CACERT and the rest of its settings
+	// from the environment, as the hms-securestorage adapter did
+	config := api.DefaultConfig()
+	if err := config.ReadEnvironment(); err != nil {
+		return nil, err
+	}
+	if auth.clientCertFile != "" {
+		if err := config.ConfigureTLS(&api.TLSConfig{ClientCert: auth.clientCertFile, ClientKey: auth.clientKeyFile}); err != nil {
+			return nil, fmt.Errorf("unable to load vault client certificate: %w", err)
+		}
+	}
+	client, err := api.NewClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	vs := &vaultStorage{auth: auth, client: client}
+	if err := vs.login(); err != nil {
+		return nil, err
+	}
+	return vs, nil
+}
+
+func readTrimmedFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// loginPath returns the login endpoint of the auth method's mount
+func (vs *vaultStorage) loginPath(defaultMount string) string {
+	mount := vs.auth.mount
+	if mount == "" {
+		mount = defaultMount
+	}
+	return "auth/" + strings.Trim(mount, "/") + "/login"
+}
+
+// login gets a new token with the configured authentication method
+func (vs *vaultStorage) login() error {
+	var secret *api.Secret
+	var err error
+
+	switch vs.auth.method {
+	case VaultAuthToken:
+		if vs.auth.tokenFile != "" {
+			var token string
+			token, err = readTrimmedFile(vs.auth.tokenFile)
+			if err != nil {
+				return fmt.Errorf("unable to read vault token file: %w", err)
+			}
+			vs.client.SetToken(token)
+		}
+		if vs.client.Token() == "" {
+			return errors.New("no vault token set")
+		}
+		// The lookup gives the token's TTL
+		secret, err = vs.client.Auth().Token().LookupSelf()
+		if err != nil {
+			return fmt.Errorf("unable to look up vault token: %w", err)
+		}
+		return vs.setLease(secret)
+	case VaultAuthKubernetes:
+		// The hms-securestorage configuration and environment still apply
+		authConfig := sstorage.DefaultAuthConfig()
+		if err := authConfig.ReadEnvironment(); err != nil {
+			return err
+		}
+		if err := authConfig.LoadRole(); err != nil {
+			return fmt.Errorf("unable to read vault role: %w", err)
+		}
+		if err := authConfig.LoadJWT(); err != nil {
+			return fmt.Errorf("unable to read vault service account token: %w", err)
+		}
+		args := authConfig.GetAuthArgs()
+		if vs.auth.role != "" {
+			args["role"] = vs.auth.role
+		}
+		path := authConfig.GetAuthPath()
+		if vs.auth.mount != "" {
+			path = vs.loginPath("")
+		}
+		secret, err = vs.client.Logical().Write(path, args)
+	case VaultAuthAppRole:
+		var secretID string
+		secretID, err = readTrimmedFile(vs.auth.appRoleSecretIDFile)
+		if err != nil {
+			return fmt.Errorf("unable to read vault approle secret ID file: %w", err)
+		}
+		secret, err = vs.client.Logical().Write(vs.loginPath("approle"), map[string]interface{}{
+			"role_id":   vs.auth.appRoleID,
+			"secret_id": secretID,
+		})
+	case VaultAuthCert:
+		args := map[string]interface{}{}
+		if vs.auth.role != "" {
+			args["name"] = vs.auth.role
+		}
+		secret, err = vs.client.Logical().Write(vs.loginPath("cert"), args)
+	default:
+		return fmt.Errorf("invalid vault auth method: %s", vs.auth.method)
+	}
+	if err != nil {
+		return fmt.Errorf("vault %s login failed: %w", vs.auth.method, err)
+	}
+
+	token, err := secret.TokenID()
+	if err != nil {
+		return fmt.Errorf("vault %s login returned no token: %w", vs.auth.method, err)
+	}
+	if token == "" {
+		return fmt.Errorf("vault %s login returned no token", vs.auth.method)
+	}
+	vs.client.SetToken(token)
+	slog.Info("Logged in to vault", "method", vs.auth.method)
+	return vs.setLease(secret)
+}
+
+// setLease schedules the renewal of the token described by the secret
+func (vs *vaultStorage) setLease(secret *api.Secret) error {
+	ttl, err := secret.TokenTTL()
+	if err != nil {
+		return fmt.Errorf("unable to read vault token TTL: %w", err)
+	}
+	renewable, err := secret.TokenIsRenewable()
+	if err != nil {
+		return fmt.Errorf("unable to read vault token renewability: %w", err)
+	}
+
+	vs.renewable = renewable
+	if ttl <= 0 {
+		vs.renewAt, vs.expiresAt = time.Time{}, time.Time{}
+		return nil
+	}
+	now := time.Now()
+	vs.renewAt = now.Add(ttl * 2 / 3)
+	vs.expiresAt = now.Add(ttl)
+	return nil
+}
+
+// ensureToken renews the token when it is due, or logs in again when it
+// can't be renewed
+func (vs *vaultStorage) ensureToken() error {
+	now := time.Now()
+	if vs.renewAt.IsZero() || now.Before(vs.renewAt) {
+		return nil
+	}
+	if vs.renewable && now.Before(vs.expiresAt) {
+		secret, err := vs.client.Auth().Token().RenewSelf(0)
+		if err == nil {
+			if err = vs.setLease(secret); err == nil {
+				slog.Debug("Renewed vault token", "method", vs.auth.method)
+				return nil
+			}
+		}
+		slog.Warn("Failed to renew vault token, logging in again", "method", vs.auth.method, "error", err)
+	}
+	return vs.login()
+}
+
+// isAuthError reports whether Vault refused the token
+func isAuthError(err error) bool {
+	var respErr *api.ResponseError
+	if errors.As(err, &respErr) && respErr.StatusCode == http.StatusForbidden {
+		return true
+	}
+	return strings.Contains(strings.ToLower(err.Error()), "missing client token")
+}
+
+// do runs a request with a current token, logging in again and retrying
+// once when the token is refused
+func (vs *vaultStorage) do(request func(logical *api.Logical) (*api.Secret, error)) (*api.Secret, error) {
+	vs.mutex.Lock()
+	defer vs.mutex.Unlock()
+
+	if err := vs.ensureToken(); err != nil {
+		return nil, err
+	}
+	secret, err := request(vs.client.Logical())
+	if err == nil || !isAuthError(err) {
+		return secret, err
+	}
+	slog.Info("Vault token refused, logging in again", "method", vs.auth.method)
+	if err := vs.login(); err != nil {
+		return nil, err
+	}
+	return request(vs.client.Logical())
+}
+
+// setCacheTTL sets how long secrets are reused, dropping those read before
+// when caching is turned off
+func (vs *vaultStorage) setCacheTTL(ttl time.Duration) {
+	vs.mutex.Lock()
+	defer vs.mutex.Unlock()
+	vs.cacheTTL = ttl
+	if ttl <= 0 {
+		vs.secrets = nil
+	}
+}
+
+// cachedSecret returns the data of a secret read before that hasn't expired
+func (vs *vaultStorage) cachedSecret(key string) (map[string]interface{}, bool) {
+	vs.mutex.Lock()
+	defer vs.mutex.Unlock()
+	cached, ok := vs.secrets[key]
+	if !ok || !time.Now().Before(cached.expiresAt) {
+		return nil, false
+	}
+	return cached.data, true
+}
+
+// cacheSecret keeps the data of a secret for the cache TTL, or its lease
+// when shorter. Expired secrets, such as those of removed nodes, are
+// dropped once per TTL.
+func (vs *vaultStorage) cacheSecret(key string, data map[string]interface{}, lease time.Duration) {
+	vs.mutex.Lock()
+	defer vs.mutex.Unlock()
+	ttl := vs.cacheTTL
+	if lease > 0 && lease < ttl {
+		ttl = lease
+	}
+	if ttl <= 0 {
+		delete(vs.secrets, key)
+		return
+	}
+
+	now := time.Now()
+	if vs.secrets == nil {
+		vs.secrets = make(map[string]cachedSecret)
+		vs.sweptAt = now
+	}
+	if now.Sub(vs.sweptAt) >= vs.cacheTTL {
+		for k, cached := range vs.secrets {
+			if !now.Before(cached.expiresAt) {
+				delete(vs.secrets, k)
+			}
+		}
+		vs.sweptAt = now
+	}
+	// Spreading the expiries keeps the secrets read together at startup
+	// from all being read again on the same credential check
+	ttl -= time.Duration(rand.Int64N(int64(ttl/4) + 1))
+	vs.secrets[key] = cachedSecret{data: data, expiresAt: now.Add(ttl)}
+}
+
+// forgetSecret drops a secret that was written or deleted
+func (vs *vaultStorage) forgetSecret(key string) {
+	vs.mutex.Lock()
+	defer vs.mutex.Unlock()
+	delete(vs.secrets, key)
+}
+
+func (vs *vaultStorage) path(key string) string {
+	return vs.auth.basePath + "/" + key
+}
+
+func (vs *vaultStorage) Store(key string, value interface{}) error {
+	return vs.StoreWithData(key, value, nil)
+}
+
+func (vs *vaultStorage) StoreWithData(key string, value interface{}, output interface{}) error {
+	var data map[string]interface{}
+	if err := mapstructure.Decode(value, &data); err != nil {
+		return err
+	}
+	secret, err := vs.do(func(logical *api.Logical) (*api.Secret, error) {
+		return logical.Write(vs.path(key), data)
+	})
+	vs.forgetSecret(key)
+	if err != nil || secret == nil || output == nil {
+		return err
+	}
+	return mapstructure.Decode(secret, output)
+}
+
+// Lookup reads the secret at key into output. A missing secret leaves the
+// output untouched without an error, as with the hms-securestorage adapter.
+// Secrets, missing ones included, are reused until they expire so each
+// credential check only reads those that did.
+func (vs *vaultStorage) Lookup(key string, output interface{}) error {
+	if output == nil {
+		return fmt.Errorf("output interface was nil")
+	}
+	data, ok := vs.cachedSecret(key)
+	if !ok {
+		secret, err := vs.do(func(logical *api.Logical) (*api.Secret, error) {
+			return logical.Read(vs.path(key))
+		})
+		if err != nil {
+			return err
+		}
+		var lease time.Duration
+		if secret != nil {
+			data = secret.Data
+			lease = time.Duration(secret.LeaseDuration) * time.Second
+		}
+		vs.cacheSecret(key, data, lease)
+	}
+	if data == nil {
+		return nil
+	}
+	return mapstructure.Decode(data, output)
+}
+
+func (vs *vaultStorage) Delete(key string) error {
+	_, err := vs.do(func(logical *api.Logical) (*api.Secret, error) {
+		return logical.Delete(vs.path(key))
+	})
+	vs.forgetSecret(key)
+	return err
+}
+
+func (vs *vaultStorage) LookupKeys(keyPath string) ([]string, error) {
+	secret, err := vs.do(func(logical *api.Logical) (*api.Secret, error) {
+		return logical.List(vs.path(keyPath))
+	})
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		return nil, nil
+	}
+	keys, ok := secret.Data["keys"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("cannot get secret data")
+	}
+	klist := make([]string, 0, len(keys))
+	for _, key := range keys {
+		name, ok := key.(string)
+		if !ok {
+			return nil, fmt.Errorf("cannot make key into string")
+		}
+		klist = append(klist, name)
+	}
+	return klist, nil
+}
@@ -0,0 +1,232 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package creds
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeVault serves the few Vault endpoints the storage uses
+type fakeVault struct {
+	mutex    sync.Mutex
+	tokens   map[string]bool
+	issued   int
+	renewals int
+	reads    int
+	ttl      int
+	lease    int // lease of the secrets
+}
+
+func startFakeVault(t *testing.T) *fakeVault {
+	fv := &fakeVault{tokens: map[string]bool{"root": true}, ttl: 60}
+	server := httptest.NewServer(fv)
+	t.Cleanup(server.Close)
+	t.Setenv("VAULT_ADDR", server.URL)
+	t.Setenv("VAULT_TOKEN", "")
+	return fv
+}
+
+func (fv *fakeVault) revokeAll() {
+	fv.mutex.Lock()
+	defer fv.mutex.Unlock()
+	fv.tokens = make(map[string]bool)
+}
+
+func (fv *fakeVault) secretReads() int {
+	fv.mutex.Lock()
+	defer fv.mutex.Unlock()
+	return fv.reads
+}
+
+func (fv *fakeVault) counts() (int, int) {
+	fv.mutex.Lock()
+	defer fv.mutex.Unlock()
+	return fv.issued, fv.renewals
+}
+
+func (fv *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fv.mutex.Lock()
+	defer fv.mutex.Unlock()
+
+	reply := func(code int, body any) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(body)
+	}
+	auth := func(token string) map[string]any {
+		return map[string]any{"auth": map[string]any{"client_token": token, "lease_duration": fv.ttl, "renewable": true}}
+	}
+
+	if r.URL.Path == "/v1/auth/approle/login" {
+		var args map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&args)
+		if args["role_id"] != "remote-console" || args["secret_id"] != "s3cret" {
+			reply(http.StatusBadRequest, map[string]any{"errors": []string{"invalid role or secret ID"}})
+			return
+		}
+		fv.issued++
+		token := fmt.Sprintf("token-%d", fv.issued)
+		fv.tokens[token] = true
+		reply(http.StatusOK, auth(token))
+		return
+	}
+
+	token := r.Header.Get("X-Vault-Token")
+	if !fv.tokens[token] {
+		reply(http.StatusForbidden, map[string]any{"errors": []string{"permission denied"}})
+		return
+	}
+	switch r.URL.Path {
+	case "/v1/auth/token/lookup-self":
+		reply(http.StatusOK, map[string]any{"data": map[string]any{"ttl": 0, "renewable": false}})
+	case "/v1/auth/token/renew-self":
+		fv.renewals++
+		reply(http.StatusOK, auth(token))
+	case "/v1/secret/hms-creds/x1000c0s1b0":
+		fv.reads++
+		reply(http.StatusOK, map[string]any{"lease_duration": fv.lease, "data": map[string]any{"Xname": "x1000c0s1b0", "Username": "admin", "Password": "vault1"}})
+	case "/v1/secret/hms-creds/x1000c0s2b0":
+		fv.reads++
+		reply(http.StatusNotFound, map[string]any{"errors": []string{}})
+	default:
+		reply(http.StatusNotFound, map[string]any{"errors": []string{}})
+	}
+}
+
+func testVaultConfig() CredsConfig {
+	config := DefaultCredsConfig()
+	config.VaultBasePath = "secret"
+	// Every lookup reaches Vault, to exercise the token handling
+	config.VaultSecretCacheTTL = 0
+	return config
+}
+
+func requireVaultCredentials(t *testing.T, config CredsConfig) {
+	passwords, _, err := resolveCredentials(config, []string{"x1000c0s1b0", "x1000c0s2b0"})
+	require.NoError(t, err)
+	require.Equal(t, "vault1", passwords["x1000c0s1b0"].Password)
+	require.NotContains(t, passwords, "x1000c0s2b0")
+}
+
+func TestVaultStorageAppRole(t *testing.T) {
+	fv := startFakeVault(t)
+	config := testVaultConfig()
+	config.VaultAuthMethod = VaultAuthAppRole
+	config.VaultAppRoleID = "remote-console"
+	config.VaultAppRoleSecretIDFile = filepath.Join(t.TempDir(), "secret-id")
+
+	// A secret ID that can't be read or is refused leaves no storage behind
+	_, err := getVaultStorage(config)
+	require.ErrorContains(t, err, "unable to read vault approle secret ID file")
+	require.NoError(t, os.WriteFile(config.VaultAppRoleSecretIDFile, []byte("wrong\n"), 0600))
+	_, err = getVaultStorage(config)
+	require.ErrorContains(t, err, "vault approle login failed")
+
+	// The login is kept across lookups
+	require.NoError(t, os.WriteFile(config.VaultAppRoleSecretIDFile, []byte("s3cret\n"), 0600))
+	requireVaultCredentials(t, config)
+	requireVaultCredentials(t, config)
+	issued, renewals := fv.counts()
+	require.Equal(t, 1, issued)
+	require.Equal(t, 0, renewals)
+	vs, err := getVaultStorage(config)
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now().Add(40*time.Second), vs.renewAt, 5*time.Second)
+
+	// The token is renewed once due
+	vs.renewAt = time.Now().Add(-time.Second)
+	requireVaultCredentials(t, config)
+	issued, renewals = fv.counts()
+	require.Equal(t, 1, issued)
+	require.Equal(t, 1, renewals)
+
+	// An expired token is replaced by a new login
+	vs.renewAt = time.Now().Add(-2 * time.Second)
+	vs.expiresAt = time.Now().Add(-time.Second)
+	requireVaultCredentials(t, config)
+	issued, renewals = fv.counts()
+	require.Equal(t, 2, issued)
+	require.Equal(t, 1, renewals)
+
+	// So is a token Vault refuses
+	fv.revokeAll()
+	requireVaultCredentials(t, config)
+	issued, _ = fv.counts()
+	require.Equal(t, 3, issued)
+}
+
+func TestVaultStorageToken(t *testing.T) {
+	fv := startFakeVault(t)
+	config := testVaultConfig()
+	config.VaultAuthMethod = VaultAuthToken
+	config.VaultTokenFile = filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(config.VaultTokenFile, []byte("root\n"), 0600))
+
+	requireVaultCredentials(t, config)
+	vs, err := getVaultStorage(config)
+	require.NoError(t, err)
+	require.True(t, vs.renewAt.IsZero(), "A token without a TTL isn't renewed")
+
+	// A token rotated in the file is picked up once the old one is refused
+	fv.revokeAll()
+	fv.mutex.Lock()
+	fv.tokens["rotated"] = true
+	fv.mutex.Unlock()
+	require.NoError(t, os.WriteFile(config.VaultTokenFile, []byte("rotated"), 0600))
+	requireVaultCredentials(t, config)
+	require.Equal(t, "rotated", vs.client.Token())
+}
+
+func TestVaultStorageCachesSecrets(t *testing.T) {
+	fv := startFakeVault(t)
+	config := testVaultConfig()
+	config.VaultSecretCacheTTL = 60
+	config.VaultAuthMethod = VaultAuthToken
+	config.VaultTokenFile = filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(config.VaultTokenFile, []byte("root\n"), 0600))
+
+	// Secrets, and the missing one, are only read again once expired
+	requireVaultCredentials(t, config)
+	require.Equal(t, 2, fv.secretReads())
+	requireVaultCredentials(t, config)
+	require.Equal(t, 2, fv.secretReads())
+
+	vs, err := getVaultStorage(config)
+	require.NoError(t, err)
+	vs.mutex.Lock()
+	cached := vs.secrets["hms-creds/x1000c0s1b0"]
+	require.WithinRange(t, cached.expiresAt, time.Now().Add(44*time.Second), time.Now().Add(60*time.Second))
+	cached.expiresAt = time.Now()
+	vs.secrets["hms-creds/x1000c0s1b0"] = cached
+	vs.mutex.Unlock()
+	requireVaultCredentials(t, config)
+	require.Equal(t, 3, fv.secretReads())
+
+	// A shorter lease is kept to
+	fv.mutex.Lock()
+	fv.lease = 8
+	fv.mutex.Unlock()
+	vs.forgetSecret("hms-creds/x1000c0s1b0")
+	requireVaultCredentials(t, config)
+	vs.mutex.Lock()
+	require.WithinRange(t, vs.secrets["hms-creds/x1000c0s1b0"].expiresAt, time.Now().Add(5*time.Second), time.Now().Add(8*time.Second))
+	vs.mutex.Unlock()
+
+	// Turning the cache off reads every lookup
+	config.VaultSecretCacheTTL = 0
+	requireVaultCredentials(t, config)
+	requireVaultCredentials(t, config)
+	require.Equal(t, 8, fv.secretReads())
+}
@@ -9,6 +9,7 @@ package logs
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"log/slog"
@@ -40,6 +41,9 @@ func (ls *LogsService) stopTailing(xname string) {
 		(*cancel)()
 		delete(ls.tailCancelByNode, xname)
 	}
+	for _, ix := range ls.indexers {
+		ix.removeNode(xname)
+	}
 }
 
 // watchConsoleLogFile tails a console log file and writes to aggregation log
@@ -47,6 +51,11 @@ func (ls *LogsService) watchConsoleLogFile(ctx context.Context, consoleLogsPath
 	filename := fmt.Sprintf("%s/console.%s", consoleLogsPath, xname)
 	slog.Info("Setting up console log tail", "filename", filename, "xname", xname)
 
+	// The live log is read from the start, so the indexes are rebuilt with it
+	ls.indexRotatedLogs(consoleLogsPath, xname)
+	var pos liveLogPosition
+	redact := ls.redactor.Stream()
+
 	// set up a tail operation on the console file
 	t, err := tail.TailFile(filename, tail.Config{
 		Follow:    true,
@@ -77,22 +86,61 @@ func (ls *LogsService) watchConsoleLogFile(ctx context.Context, consoleLogsPath
 				slog.Error("Error reading line from console", "xname", xname, "error", line.Err)
 				continue
 			}
-			ls.writeToAggLog(xname, line.Text)
+			start := max(line.SeekInfo.Offset-int64(len(line.Text))-1, 0)
+			text := redact.Line(line.Text)
+			consoleLine := NewConsoleLine(xname, filepath.Base(filename), text, start, line.Time)
+			ls.writeConsoleLine(consoleLine)
+			ls.publish(consoleLine)
+			ls.indexLiveLine(&pos, xname, text, start, line.SeekInfo.Offset)
+			if ls.integrity != nil {
+				ls.integrity.touch(xname)
+			}
+			if ls.watches != nil {
+				ls.watches.evaluate(xname, text, line.Time)
+			}
 		}
 	}
 }
 
-// writeToAggLog writes a line to the aggregation log with proper locking
-func (ls *LogsService) writeToAggLog(xname, line string) {
+// writeConsoleLine numbers a line and writes it to each aggregation log sink
+func (ls *LogsService) writeConsoleLine(line ConsoleLine) {
 	ls.conAggMutex.Lock()
 	defer ls.conAggMutex.Unlock()
 
+	ls.conAggSeq++
+	for _, sink := range ls.sinks {
+		sink.write(line, ls.conAggSeq)
+	}
+}
+
+// writeAggLogLocked writes a line to the aggregation log file in the
+// configured format, reporting whether it was written
+func (ls *LogsService) writeAggLogLocked(line ConsoleLine, seq uint64) bool {
 	if ls.conAggLogger == nil {
-		return
+		return false
 	}
 
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	ls.conAggLogger.Printf("%s [%s] %s", timestamp, xname, line)
+	if ls.config.AggLogsFormat != AggLogsFormatJSON {
+		timestamp := time.Now().Format("2006-01-02 15:04:05")
+		ls.conAggLogger.Printf("%s [%s] %s", timestamp, line.Node, line.Text)
+		return true
+	}
+
+	data, err := json.Marshal(line.Frame(seq))
+	if err != nil {
+		slog.Error("Failed to encode aggregation log line", "xname", line.Node, "error", err)
+		return false
+	}
+	ls.conAggLogger.Print(string(data))
+	return true
+}
+
+// writeToAggLog writes a line to the aggregation log with proper locking
+func (ls *LogsService) writeToAggLog(xname, line string) {
+	ls.conAggMutex.Lock()
+	defer ls.conAggMutex.Unlock()
+
+	ls.writeAggLogLocked(ConsoleLine{Node: xname, Text: line}, 0)
 }
 
 func (ls *LogsService) openAggLogLocked() {
@@ -123,12 +171,13 @@ func (ls *LogsService) openAggLogLocked() {
 	ls.conAggLogger.Print("Starting aggregation log")
 }
 
-// EnsureAggLog opens the aggregation log file if not already open.
+// EnsureAggLog opens the aggregation log file if not already open and the
+// file sink is selected.
 func (ls *LogsService) EnsureAggLog() {
 	ls.conAggMutex.Lock()
 	defer ls.conAggMutex.Unlock()
 
-	if ls.conAggLogger != nil {
+	if ls.conAggLogger != nil || !ls.aggLogFileEnabled() {
 		return
 	}
 
@@ -10,6 +10,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -286,3 +287,37 @@ func TestAggregateFilesTailCleanup(t *testing.T) {
 		t.Fatalf("Tail thread for x0c0s0b0 should be cleaned up")
 	}
 }
+
+func TestWriteConsoleLineJSON(t *testing.T) {
+	tempDir := t.TempDir()
+
+	config := DefaultLogConfig()
+	config.AggLogsPath = tempDir
+	config.AggLogsFormat = AggLogsFormatJSON
+	config.ConsoleLogsBackupPath = filepath.Join(tempDir, "conman.old")
+	service, err := NewLogsService(config)
+	require.NoError(t, err)
+
+	service.conAggLogFile = filepath.Join(tempDir, "consoleAgg-test.log")
+	service.EnsureAggLog()
+	defer func() {
+		require.NoError(t, service.conAggFile.Close())
+	}()
+
+	readTime := time.Date(2026, 3, 4, 5, 6, 7, 123456789, time.UTC)
+	service.writeConsoleLine(NewConsoleLine("x0c0s1b0", "console.x0c0s1b0", "no timestamp", 0, readTime))
+	service.writeConsoleLine(NewConsoleLine("x0c0s1b0", "console.x0c0s1b0", "2026-03-04 05:06:08 UTC login:", 13, readTime))
+
+	data, err := os.ReadFile(service.conAggLogFile)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Equal(t, []string{
+		"Starting aggregation log",
+		`{"seq":1,"node":"x0c0s1b0","timestamp":"2026-03-04T05:06:07.123456789Z","file":"console.x0c0s1b0","offset":0,"text":"no timestamp"}`,
+		`{"seq":2,"node":"x0c0s1b0","timestamp":"2026-03-04T05:06:08Z","file":"console.x0c0s1b0","offset":13,"text":"2026-03-04 05:06:08 UTC login:"}`,
+	}, lines)
+
+	config.AggLogsFormat = "xml"
+	_, err = NewLogsService(config)
+	require.Error(t, err)
+}
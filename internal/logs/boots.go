@@ -0,0 +1,351 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// This file contains the detection of boot boundaries in console logs
+
+package logs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxBootsPerNode bounds the boots kept for a node stuck in a reboot loop
+const maxBootsPerNode = 1000
+
+// DefaultBootMarkers match the firmware banners, kernel banner and conman
+// reconnect lines that start a boot
+var DefaultBootMarkers = []string{
+	`American Megatrends|Phoenix SecureCore|UEFI firmware|BIOS Date:`,
+	`Linux version \d`,
+	`<ConMan> Console \[[^\]]+\] connected`,
+}
+
+// ErrBootNotFound is returned for a boot that isn't in a node's index
+var ErrBootNotFound = errors.New("boot not found")
+
+// ErrBootsDisabled is returned when no boot markers are configured
+var ErrBootsDisabled = errors.New("boot detection is disabled")
+
+// Boot describes one boot found in a node's console logs
+type Boot struct {
+	ID          int        `json:"id"`
+	Start       *time.Time `json:"start,omitempty"` // conman timestamp of the first line, when known
+	End         *time.Time `json:"end,omitempty"`   // conman timestamp of the last line, when known
+	Marker      string     `json:"marker,omitempty"`
+	StartFile   string     `json:"startFile"`
+	StartOffset int64      `json:"startOffset"`
+	Partial     bool       `json:"partial"` // the start of the boot has been rotated away or was never logged
+	Current     bool       `json:"current"` // the most recent boot, still being logged
+}
+
+// bootRecord locates a boot by the generation of the log file it starts in.
+// Generations count log files from the oldest one read, so the live log has
+// the highest.
+type bootRecord struct {
+	id         int
+	generation int
+	offset     int64
+	start, end int64 // unix seconds, zero when unknown
+	marker     string
+	partial    bool
+	seen       []bool // markers seen so far in the boot
+}
+
+type nodeBoots struct {
+	mutex      sync.Mutex
+	generation int // generation of the live log
+	nextID     int
+	boots      []*bootRecord
+	timestamp  int64 // last conman timestamp seen
+}
+
+// bootIndex finds boot boundaries in the console logs. A boot starts at a
+// line matching a marker that has already been seen in the current boot, so
+// the firmware banner, kernel banner and reconnect lines of one boot are
+// grouped together. Output before the first marker forms a partial boot. It
+// is a logIndexer.
+type bootIndex struct {
+	markers    []*regexp.Regexp
+	maxRotated int
+	mutex      sync.RWMutex
+	nodes      map[string]*nodeBoots
+}
+
+func newBootIndex(markers []string, maxRotated int) (*bootIndex, error) {
+	bi := &bootIndex{maxRotated: maxRotated, nodes: make(map[string]*nodeBoots)}
+	for _, marker := range markers {
+		re, err := regexp.Compile(marker)
+		if err != nil {
+			return nil, fmt.Errorf("invalid boot marker %q: %w", marker, err)
+		}
+		bi.markers = append(bi.markers, re)
+	}
+	return bi, nil
+}
+
+func (bi *bootIndex) beginNode(xname string) {
+	bi.mutex.Lock()
+	defer bi.mutex.Unlock()
+	bi.nodes[xname] = &nodeBoots{generation: -1, nextID: 1}
+}
+
+func (bi *bootIndex) removeNode(xname string) {
+	bi.mutex.Lock()
+	defer bi.mutex.Unlock()
+	delete(bi.nodes, xname)
+}
+
+func (bi *bootIndex) node(xname string) *nodeBoots {
+	bi.mutex.RLock()
+	defer bi.mutex.RUnlock()
+	return bi.nodes[xname]
+}
+
+// beginFile moves to the next log file and drops the boots that started in
+// logs rotation has deleted. A boot that continues into a retained log is
+// kept as a partial boot.
+func (bi *bootIndex) beginFile(xname string) {
+	nb := bi.node(xname)
+	if nb == nil {
+		return
+	}
+
+	nb.mutex.Lock()
+	defer nb.mutex.Unlock()
+
+	nb.generation++
+	oldest := nb.generation - bi.maxRotated
+	for len(nb.boots) > 0 && nb.boots[0].generation < oldest {
+		if len(nb.boots) > 1 && nb.boots[1].generation <= oldest {
+			nb.boots = nb.boots[1:]
+			continue
+		}
+		nb.boots[0].generation = oldest
+		nb.boots[0].offset = 0
+		nb.boots[0].partial = true
+		break
+	}
+}
+
+func (bi *bootIndex) addLine(xname, text string, offset int64) {
+	nb := bi.node(xname)
+	if nb == nil {
+		return
+	}
+
+	nb.mutex.Lock()
+	defer nb.mutex.Unlock()
+
+	if ts, ok := ParseLineTimestamp(text); ok {
+		nb.timestamp = ts.Unix()
+	}
+
+	marker := slices.IndexFunc(bi.markers, func(re *regexp.Regexp) bool { return re.MatchString(text) })
+
+	var current *bootRecord
+	if len(nb.boots) > 0 {
+		current = nb.boots[len(nb.boots)-1]
+	}
+
+	switch {
+	case marker >= 0 && (current == nil || current.marker == "" || current.seen[marker]):
+		current = nb.newBoot(len(bi.markers), offset, strings.TrimSpace(text))
+	case current == nil:
+		// Output from before the first marker belongs to a boot whose start was not logged
+		current = nb.newBoot(len(bi.markers), offset, "")
+		current.partial = true
+	}
+
+	if marker >= 0 {
+		current.seen[marker] = true
+	}
+	if current.start == 0 {
+		current.start = nb.timestamp
+	}
+	current.end = nb.timestamp
+}
+
+func (nb *nodeBoots) newBoot(markers int, offset int64, marker string) *bootRecord {
+	boot := &bootRecord{
+		id:         nb.nextID,
+		generation: nb.generation,
+		offset:     offset,
+		marker:     marker,
+		seen:       make([]bool, markers),
+	}
+	nb.nextID++
+	nb.boots = append(nb.boots, boot)
+	if len(nb.boots) > maxBootsPerNode {
+		nb.boots = nb.boots[1:]
+		nb.boots[0].partial = true
+	}
+	return boot
+}
+
+// bootSpan is where a boot's output lies in the log files
+type bootSpan struct {
+	startRotation int
+	endRotation   int
+	endOffset     int64 // offset of the next boot in the end file, -1 for the current boot
+}
+
+// boots returns a copy of a node's boots, oldest first
+func (bi *bootIndex) boots(xname string) ([]Boot, []bootSpan) {
+	nb := bi.node(xname)
+	if nb == nil {
+		return []Boot{}, nil
+	}
+
+	nb.mutex.Lock()
+	defer nb.mutex.Unlock()
+
+	boots := make([]Boot, 0, len(nb.boots))
+	spans := make([]bootSpan, 0, len(nb.boots))
+	for i, record := range nb.boots {
+		boot := Boot{
+			ID:          record.id,
+			Marker:      record.marker,
+			StartOffset: record.offset,
+			Partial:     record.partial,
+			Current:     i == len(nb.boots)-1,
+		}
+		if record.start != 0 {
+			start := time.Unix(record.start, 0).UTC()
+			boot.Start = &start
+		}
+		if record.end != 0 {
+			end := time.Unix(record.end, 0).UTC()
+			boot.End = &end
+		}
+
+		span := bootSpan{startRotation: nb.generation - record.generation, endOffset: -1}
+		if i+1 < len(nb.boots) {
+			next := nb.boots[i+1]
+			span.endRotation = nb.generation - next.generation
+			span.endOffset = next.offset
+		}
+
+		boots = append(boots, boot)
+		spans = append(spans, span)
+	}
+	return boots, spans
+}
+
+// Boots returns the boots found in a node's console logs, oldest first
+func (ls *LogsService) Boots(consoleLogsPath, xname string) ([]Boot, error) {
+	if ls.boots == nil {
+		return nil, ErrBootsDisabled
+	}
+
+	boots, spans := ls.boots.boots(xname)
+	files, err := ls.ConsoleLogFiles(consoleLogsPath, xname)
+	if err != nil {
+		return nil, err
+	}
+	for i := range boots {
+		if f, ok := fileForRotation(files, spans[i].startRotation); ok {
+			boots[i].StartFile = filepath.Base(f.Path)
+		}
+	}
+	return boots, nil
+}
+
+// OpenBoot returns a reader of the redacted console output of one boot.
+// Negative IDs count back from the most recent boot, so -1 is the current boot.
+func (ls *LogsService) OpenBoot(consoleLogsPath, xname string, id int) (Boot, io.ReadCloser, error) {
+	if ls.boots == nil {
+		return Boot{}, nil, ErrBootsDisabled
+	}
+
+	boots, spans := ls.boots.boots(xname)
+	idx := slices.IndexFunc(boots, func(b Boot) bool { return b.ID == id })
+	if id < 0 && -id <= len(boots) {
+		idx = len(boots) + id
+	}
+	if idx < 0 {
+		return Boot{}, nil, ErrBootNotFound
+	}
+	boot, span := boots[idx], spans[idx]
+
+	files, err := ls.ConsoleLogFiles(consoleLogsPath, xname)
+	if err != nil {
+		return Boot{}, nil, err
+	}
+	if f, ok := fileForRotation(files, span.startRotation); ok {
+		boot.StartFile = filepath.Base(f.Path)
+	}
+
+	var readers []io.Reader
+	var closers []io.Closer
+	closeAll := func() {
+		for _, c := range closers {
+			_ = c.Close()
+		}
+	}
+
+	endRotation := span.endRotation
+	for rotation := span.startRotation; rotation >= endRotation; rotation-- {
+		f, ok := fileForRotation(files, rotation)
+		if !ok {
+			closeAll()
+			return Boot{}, nil, fmt.Errorf("log file of rotation %d of %s: %w", rotation, xname, os.ErrNotExist)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			closeAll()
+			return Boot{}, nil, err
+		}
+		closers = append(closers, rc)
+
+		var r io.Reader = rc
+		if rotation == span.startRotation && boot.StartOffset > 0 {
+			if _, err := io.CopyN(io.Discard, rc, boot.StartOffset); err != nil {
+				closeAll()
+				return Boot{}, nil, fmt.Errorf("failed to seek to boot in %s: %w", f.Path, err)
+			}
+		}
+		if rotation == endRotation && span.endOffset >= 0 {
+			length := span.endOffset
+			if rotation == span.startRotation {
+				length -= boot.StartOffset
+			}
+			r = io.LimitReader(r, length)
+		}
+		readers = append(readers, r)
+	}
+
+	return boot, &multiReadCloser{Reader: ls.redactor.Reader(io.MultiReader(readers...)), closers: closers}, nil
+}
+
+// fileForRotation finds the log file of a rotation, 0 being the live log
+func fileForRotation(files []ConsoleLogFile, rotation int) (ConsoleLogFile, bool) {
+	idx := slices.IndexFunc(files, func(f ConsoleLogFile) bool { return f.Rotation == rotation })
+	if idx < 0 {
+		return ConsoleLogFile{}, false
+	}
+	return files[idx], true
+}
+
+// multiReadCloser reads a sequence of files and closes them all
+type multiReadCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (m *multiReadCloser) Close() error {
+	var errs []error
+	for _, c := range m.closers {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
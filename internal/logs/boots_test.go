@@ -0,0 +1,143 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package logs
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newBootTestService returns a service that detects boots in the logs under tempDir
+func newBootTestService(t *testing.T, tempDir string, maxRotated int) (*LogsService, string) {
+	logsPath := filepath.Join(tempDir, "conman")
+	backupPath := filepath.Join(tempDir, "conman.old")
+	require.NoError(t, os.MkdirAll(logsPath, 0755))
+	require.NoError(t, os.MkdirAll(backupPath, 0755))
+
+	boots, err := newBootIndex(DefaultBootMarkers, maxRotated)
+	require.NoError(t, err)
+	return &LogsService{
+		config:   LogConfig{ConsoleLogsBackupPath: backupPath},
+		boots:    boots,
+		indexers: []logIndexer{boots},
+	}, logsPath
+}
+
+func readBoot(t *testing.T, service *LogsService, logsPath, xname string, id int) (Boot, string) {
+	boot, r, err := service.OpenBoot(logsPath, xname, id)
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	return boot, string(data)
+}
+
+func TestBoots(t *testing.T) {
+	tempDir := t.TempDir()
+	service, logsPath := newBootTestService(t, tempDir, 2)
+	xname := "x1000c0s0b0n0"
+
+	rotated := strings.Join([]string{
+		"still running from before",
+		"2026-03-01 01:00:00 UTC UEFI firmware starting",
+		"2026-03-01 01:01:00 UTC Linux version 6.1.0",
+		"2026-03-01 01:02:00 UTC login:",
+		"",
+	}, "\n")
+	require.NoError(t, os.WriteFile(filepath.Join(service.config.ConsoleLogsBackupPath, "console."+xname+".1"), []byte(rotated), 0600))
+
+	live := []string{
+		"2026-03-02 02:00:00 UTC Kernel panic - not syncing",
+		"2026-03-02 02:05:00 UTC UEFI firmware starting",
+		"2026-03-02 02:06:00 UTC Linux version 6.1.0",
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(logsPath, "console."+xname), []byte(strings.Join(live, "\n")+"\n"), 0600))
+
+	service.indexRotatedLogs(logsPath, xname)
+	addLiveLines(service, &liveLogPosition{}, xname, live...)
+
+	boots, err := service.Boots(logsPath, xname)
+	require.NoError(t, err)
+	require.Len(t, boots, 3)
+
+	require.True(t, boots[0].Partial)
+	require.Nil(t, boots[0].Start)
+
+	require.Equal(t, 2, boots[1].ID)
+	require.Equal(t, "2026-03-01 01:00:00 UTC UEFI firmware starting", boots[1].Marker)
+	require.Equal(t, "console."+xname+".1", boots[1].StartFile)
+	require.Equal(t, int64(len("still running from before\n")), boots[1].StartOffset)
+	require.Equal(t, time.Date(2026, 3, 1, 1, 0, 0, 0, time.UTC), *boots[1].Start)
+	require.Equal(t, time.Date(2026, 3, 2, 2, 0, 0, 0, time.UTC), *boots[1].End)
+	require.False(t, boots[1].Current)
+
+	require.True(t, boots[2].Current)
+	require.Equal(t, "console."+xname, boots[2].StartFile)
+
+	// A boot spanning a rotation is read from both files
+	_, output := readBoot(t, service, logsPath, xname, -2)
+	require.Equal(t, strings.Join([]string{
+		"2026-03-01 01:00:00 UTC UEFI firmware starting",
+		"2026-03-01 01:01:00 UTC Linux version 6.1.0",
+		"2026-03-01 01:02:00 UTC login:",
+		"2026-03-02 02:00:00 UTC Kernel panic - not syncing",
+		"",
+	}, "\n"), output)
+
+	boot, output := readBoot(t, service, logsPath, xname, -1)
+	require.Equal(t, 3, boot.ID)
+	require.Equal(t, strings.Join(live[1:], "\n")+"\n", output)
+
+	_, output = readBoot(t, service, logsPath, xname, 1)
+	require.Equal(t, "still running from before\n", output)
+
+	_, _, err = service.OpenBoot(logsPath, xname, 4)
+	require.ErrorIs(t, err, ErrBootNotFound)
+	_, _, err = service.OpenBoot(logsPath, xname, -4)
+	require.ErrorIs(t, err, ErrBootNotFound)
+
+	_, err = (&LogsService{}).Boots(logsPath, xname)
+	require.ErrorIs(t, err, ErrBootsDisabled)
+}
+
+func TestOpenBootRedaction(t *testing.T) {
+	service, logsPath := newBootTestService(t, t.TempDir(), 1)
+	redactor, err := NewRedactor([]string{"password"})
+	require.NoError(t, err)
+	service.redactor = redactor
+	xname := "x1000c0s0b0n0"
+
+	live := []string{"UEFI firmware starting", "login password=hunter2", "no newline"}
+	require.NoError(t, os.WriteFile(filepath.Join(logsPath, "console."+xname), []byte(strings.Join(live, "\n")), 0600))
+	service.indexRotatedLogs(logsPath, xname)
+	addLiveLines(service, &liveLogPosition{}, xname, live...)
+
+	_, output := readBoot(t, service, logsPath, xname, -1)
+	require.Equal(t, "UEFI firmware starting\nlogin password=[REDACTED]\nno newline", output)
+}
+
+func TestBootsRotation(t *testing.T) {
+	service, _ := newBootTestService(t, t.TempDir(), 1)
+	xname := "x1000c0s0b0n0"
+
+	var pos liveLogPosition
+	service.indexRotatedLogs(t.TempDir(), xname)
+	addLiveLines(service, &pos, xname, "Linux version 6.1.0", "Linux version 6.1.0")
+	addLiveLines(service, &pos, xname, "running")
+	addLiveLines(service, &pos, xname, "still running")
+
+	// The first boot was in a deleted log, and the second continues from one
+	boots, _ := service.boots.boots(xname)
+	require.Len(t, boots, 1)
+	require.Equal(t, 2, boots[0].ID)
+	require.True(t, boots[0].Partial)
+	require.Equal(t, int64(0), boots[0].StartOffset)
+}
@@ -4,17 +4,74 @@
 
 package logs
 
+import (
+	"log/slog"
+	"slices"
+)
+
+// Aggregation log formats
+const (
+	AggLogsFormatText = "text"
+	AggLogsFormatJSON = "json"
+)
+
 type LogConfig struct {
-	ConsoleLogsFileSize     string `desc:"Maximum size of console log files before rotation."`
-	ConsoleLogsNumRotate    int    `desc:"Number of rotated console log files to keep."`
-	ConsoleLogsBackupPath   string `desc:"Path to rotated console log files."`
-	AggLogsFileSize         string `desc:"Maximum size of aggregation log file before rotation."`
-	AggLogsNumRotate        int    `desc:"Number of rotated aggregation log files to keep."`
-	AggLogsPath             string `desc:"Path to aggregation log files."`
-	LogRotateEnabled        bool   `desc:"Enable log rotation."`
-	LogRotateCheckFrequency int    `desc:"Frequency in seconds to check for log rotation."`
-	LogRotateFilePath       string `desc:"Path to logrotate configuration file."`
-	LogRotateStateFilePath  string `desc:"Path to logrotate state file."`
+	ConsoleLogsFileSize     string   `desc:"Maximum size of console log files before rotation."`
+	ConsoleLogsNumRotate    int      `desc:"Number of rotated console log files to keep."`
+	ConsoleLogsBackupPath   string   `desc:"Path to rotated console log files."`
+	ConsoleLogsMaxAgeHours  int      `desc:"Maximum age in hours of console log files before rotation (0 rotates by size only)."`
+	ConsoleLogsCompression  string   `desc:"Compression of rotated console log files: none, gzip or zstd."`
+	AggLogsFileSize         string   `desc:"Maximum size of aggregation log file before rotation."`
+	AggLogsNumRotate        int      `desc:"Number of rotated aggregation log files to keep."`
+	AggLogsPath             string   `desc:"Path to aggregation log files."`
+	AggLogsFormat           string   `desc:"Format of aggregation log lines: text or json."`
+	AggLogsSinks            []string `desc:"Destinations of aggregated console output: file, stdout, syslog and http."`
+	AggSinkQueueSize        int      `desc:"Number of lines buffered for each stdout, syslog or http sink before lines are dropped."`
+	AggSyslogAddress        string   `desc:"Syslog server for the syslog sink, as udp://, tcp:// or tls://host:port."`
+	AggSyslogCAFile         string   `desc:"CA certificates verifying a tls:// syslog server (system roots when empty)."`
+	AggPushURL              string   `desc:"URL the http sink pushes aggregated console output to."`
+	AggPushFormat           string   `desc:"Format of the http sink: jsonl or loki."`
+	AggPushHeaders          []string `desc:"Headers sent by the http sink, as Name=Value."`
+	LogsDiskBudget          string   `desc:"Maximum total size of console, backup and aggregation logs, such as 50G (empty for no limit)."`
+	LogsRetentionHours      int      `desc:"Maximum age in hours of rotated logs before they are deleted (0 keeps them)."`
+	LogsMinRotatedPerNode   int      `desc:"Number of each node's newest rotated console logs kept regardless of the disk budget and retention."`
+	LogsMinFreePercent      int      `desc:"Free space percentage of a log volume below which it is reported low on space."`
+	LogRotateEnabled        bool     `desc:"Enable log rotation."`
+	LogRotateCheckFrequency int      `desc:"Frequency in seconds to check for log rotation."`
+	WatchRulesFile          string   `desc:"Path to the console output watch rules file (optional)."`
+	EventLogPath            string   `desc:"Path to the console watch event log."`
+	EventLogFileSize        string   `desc:"Maximum size of the console watch event log before it is rotated. One rotated event log is kept."`
+	SearchIndexMaxLines     int      `desc:"Maximum number of console log lines indexed for search per node (0 disables search)."`
+	BootMarkers             []string `desc:"Regular expressions matching console lines that start a boot."`
+	IntegrityEnabled        bool     `desc:"Keep hash chains that make changes to console logs evident."`
+	IntegrityPath           string   `desc:"Path to the console log hash chains."`
+	IntegrityKeyFile        string   `desc:"Path to a key signing the hash chain records with HMAC-SHA256 (optional)."`
+	IntegrityCheckFrequency int      `desc:"Frequency in seconds to checkpoint the live console logs in their hash chains."`
+	RedactRules             []string `desc:"Secret redaction rules for console output served to clients and aggregated: built-in rule names or regular expressions, where a group named secret limits what is replaced."`
+	RedactStoredLogs        bool     `desc:"Redact secrets from console logs when they are rotated."`
+
+	// Options of the logrotate based rotation, kept so existing deployments
+	// still start
+	ConsoleLogsCompress    bool   `desc:"Deprecated: compress rotated console log files with gzip, as console-logs-compression gzip does."`
+	LogRotateFilePath      string `desc:"Deprecated and ignored: logs are rotated in process."`
+	LogRotateStateFilePath string `desc:"Deprecated and ignored: logs are rotated in process."`
+}
+
+// ApplyDeprecated warns about the deprecated options that are set and maps
+// them onto the options replacing them
+func (c *LogConfig) ApplyDeprecated() {
+	if c.LogRotateFilePath != "" {
+		slog.Warn("The logrotate configuration file is deprecated and ignored, logs are rotated in process", "path", c.LogRotateFilePath)
+	}
+	if c.LogRotateStateFilePath != "" {
+		slog.Warn("The logrotate state file is deprecated and ignored, logs are rotated in process", "path", c.LogRotateStateFilePath)
+	}
+	if c.ConsoleLogsCompress {
+		if c.ConsoleLogsCompression == "" || c.ConsoleLogsCompression == CompressionNone {
+			c.ConsoleLogsCompression = CompressionGzip
+		}
+		slog.Warn("Compressing console logs is deprecated, set the console log compression instead", "compression", c.ConsoleLogsCompression)
+	}
 }
 
 func DefaultLogConfig() LogConfig {
@@ -23,11 +80,37 @@ func DefaultLogConfig() LogConfig {
 		ConsoleLogsFileSize:     "5M",
 		ConsoleLogsNumRotate:    2,
 		ConsoleLogsBackupPath:   "/var/log/conman.old",
+		ConsoleLogsMaxAgeHours:  0,
+		ConsoleLogsCompression:  CompressionNone,
 		AggLogsFileSize:         "20M",
 		AggLogsNumRotate:        1,
 		AggLogsPath:             "/tmp/consoleAgg",
+		AggLogsFormat:           AggLogsFormatText,
+		AggLogsSinks:            []string{SinkFile},
+		AggSinkQueueSize:        10000,
+		AggSyslogAddress:        "",
+		AggSyslogCAFile:         "",
+		AggPushURL:              "",
+		AggPushFormat:           PushFormatJSONLines,
+		AggPushHeaders:          nil,
+		LogsDiskBudget:          "",
+		LogsRetentionHours:      0,
+		LogsMinRotatedPerNode:   0,
+		LogsMinFreePercent:      10,
 		LogRotateCheckFrequency: 600,
-		LogRotateFilePath:       "/tmp/logrotate.conman",
-		LogRotateStateFilePath:  "/tmp/rot_conman.state",
+		WatchRulesFile:          "",
+		EventLogPath:            "/tmp/consoleEvents/events.log",
+		EventLogFileSize:        "10M",
+		SearchIndexMaxLines:     200000,
+		BootMarkers:             slices.Clone(DefaultBootMarkers),
+		IntegrityEnabled:        false,
+		IntegrityPath:           "/var/log/conman.integrity",
+		IntegrityKeyFile:        "",
+		IntegrityCheckFrequency: 10,
+		RedactRules:             nil,
+		RedactStoredLogs:        false,
+		ConsoleLogsCompress:     false,
+		LogRotateFilePath:       "",
+		LogRotateStateFilePath:  "",
 	}
 }
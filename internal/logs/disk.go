@@ -0,0 +1,313 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// This file contains the disk budget enforced across the console, backup and
+// aggregation log directories
+
+package logs
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/OpenCHAMI/remote-console/internal/metrics"
+)
+
+// rotatedConsoleLogName matches the rotated console logs in the backup directory
+var rotatedConsoleLogName = regexp.MustCompile(`^console\.(.+)\.([1-9][0-9]*)(\.gz|\.zst)?$`)
+
+// DiskStatus reports the space used by the logs and left on their volumes
+type DiskStatus struct {
+	UsedBytes    int64          `json:"usedBytes"`
+	BudgetBytes  int64          `json:"budgetBytes,omitempty"`
+	Volumes      []VolumeStatus `json:"volumes"`
+	LowSpace     bool           `json:"lowSpace"`
+	EvictedFiles int64          `json:"evictedFiles"` // since the service started
+	EvictedBytes int64          `json:"evictedBytes"`
+	Checked      time.Time      `json:"checked"`
+}
+
+// VolumeStatus reports the space left on the volume holding a log directory
+type VolumeStatus struct {
+	Path       string `json:"path"`
+	FreeBytes  uint64 `json:"freeBytes"`
+	TotalBytes uint64 `json:"totalBytes"`
+	LowSpace   bool   `json:"lowSpace"`
+}
+
+type diskState struct {
+	mutex   sync.Mutex
+	budget  int64 // zero for no limit
+	status  DiskStatus
+	checked bool
+}
+
+// budgetFile is a log file counted against the disk budget
+type budgetFile struct {
+	path     string
+	size     int64
+	modTime  time.Time
+	node     string // xname of a console log, empty for the aggregation log
+	rotation int    // 0 for live logs, which are never evicted
+}
+
+// initDiskBudget validates the disk budget
+func (ls *LogsService) initDiskBudget() error {
+	if ls.config.LogsDiskBudget == "" {
+		return nil
+	}
+	budget, err := parseFileSize(ls.config.LogsDiskBudget)
+	if err != nil {
+		return fmt.Errorf("logs disk budget: %w", err)
+	}
+	ls.disk.budget = budget
+	return nil
+}
+
+// EnforceDiskBudget deletes the rotated logs older than the retention, then
+// the oldest rotated logs until the logs fit in the disk budget, and records
+// how much space is left on the log volumes. The newest rotated logs of each
+// node are kept up to the configured minimum.
+func (ls *LogsService) EnforceDiskBudget() {
+	ls.mutex.Lock()
+	defer ls.mutex.Unlock()
+
+	now := time.Now()
+	files, dirs := ls.budgetFiles()
+
+	var used int64
+	for _, f := range files {
+		used += f.size
+	}
+
+	var evictedFiles, evictedBytes int64
+	evict := func(f budgetFile, reason string) {
+		if err := os.Remove(f.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			slog.Error("Failed to evict rotated log", "file", f.path, "error", err)
+			return
+		}
+		forgetTimeRange(f.path)
+		slog.Info("Evicted rotated log", "xname", f.node, "file", f.path, "size", f.size, "reason", reason)
+		if ls.integrity != nil {
+			ls.integrity.deleted(f.node, f.rotation, f.path)
+		}
+		used -= f.size
+		evictedFiles++
+		evictedBytes += f.size
+	}
+
+	candidates := evictionCandidates(files, ls.config.LogsMinRotatedPerNode)
+	if ls.config.LogsRetentionHours > 0 {
+		retention := time.Duration(ls.config.LogsRetentionHours) * time.Hour
+		candidates = slices.DeleteFunc(candidates, func(f budgetFile) bool {
+			if now.Sub(f.modTime) <= retention {
+				return false
+			}
+			evict(f, "age")
+			return true
+		})
+	}
+	for _, f := range candidates {
+		if ls.disk.budget == 0 || used <= ls.disk.budget {
+			break
+		}
+		evict(f, "budget")
+	}
+
+	status := DiskStatus{UsedBytes: used, BudgetBytes: ls.disk.budget, Checked: now}
+	for _, dir := range dirs {
+		var st unix.Statfs_t
+		if err := unix.Statfs(dir, &st); err != nil {
+			slog.Warn("Failed to read free space of log volume", "path", dir, "error", err)
+			continue
+		}
+		volume := VolumeStatus{
+			Path:       dir,
+			FreeBytes:  st.Bavail * uint64(st.Bsize),
+			TotalBytes: st.Blocks * uint64(st.Bsize),
+		}
+		volume.LowSpace = volume.TotalBytes > 0 && volume.FreeBytes*100 < volume.TotalBytes*uint64(ls.config.LogsMinFreePercent)
+		status.LowSpace = status.LowSpace || volume.LowSpace
+		status.Volumes = append(status.Volumes, volume)
+	}
+	if ls.disk.budget > 0 && used > ls.disk.budget {
+		status.LowSpace = true
+	}
+
+	ls.disk.mutex.Lock()
+	defer ls.disk.mutex.Unlock()
+	status.EvictedFiles = ls.disk.status.EvictedFiles + evictedFiles
+	status.EvictedBytes = ls.disk.status.EvictedBytes + evictedBytes
+	switch {
+	case status.LowSpace && !ls.disk.status.LowSpace:
+		slog.Warn("Console logs are low on disk space", "usedBytes", used, "budgetBytes", ls.disk.budget, "volumes", status.Volumes)
+	case !status.LowSpace && ls.disk.status.LowSpace:
+		slog.Info("Console logs are no longer low on disk space", "usedBytes", used)
+	}
+	ls.disk.status = status
+	ls.disk.checked = true
+}
+
+// budgetFiles lists the live and rotated logs and the directories holding them
+func (ls *LogsService) budgetFiles() ([]budgetFile, []string) {
+	ls.conAggMutex.Lock()
+	aggLogFile := ls.conAggLogFile
+	ls.conAggMutex.Unlock()
+
+	var files []budgetFile
+	var dirs []string
+	add := func(dir string, match func(name string) (budgetFile, bool)) {
+		if dir == "" {
+			return
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				slog.Warn("Failed to read log directory", "path", dir, "error", err)
+			}
+			return
+		}
+		if !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			f, ok := match(entry.Name())
+			if !ok {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			f.path, f.size, f.modTime = filepath.Join(dir, entry.Name()), info.Size(), info.ModTime()
+			files = append(files, f)
+		}
+	}
+
+	add(ls.rotation.consoleLogsPath, func(name string) (budgetFile, bool) {
+		xname, ok := strings.CutPrefix(name, "console.")
+		return budgetFile{node: xname}, ok
+	})
+	add(ls.config.ConsoleLogsBackupPath, func(name string) (budgetFile, bool) {
+		m := rotatedConsoleLogName.FindStringSubmatch(name)
+		if m == nil {
+			return budgetFile{}, false
+		}
+		rotation, _ := strconv.Atoi(m[2])
+		return budgetFile{node: m[1], rotation: rotation}, true
+	})
+	if aggLogFile != "" {
+		base := filepath.Base(aggLogFile)
+		add(filepath.Dir(aggLogFile), func(name string) (budgetFile, bool) {
+			if name == base {
+				return budgetFile{}, true
+			}
+			rotation, _, ok := parseRotatedName(name, base)
+			return budgetFile{rotation: rotation}, ok
+		})
+	}
+	return files, dirs
+}
+
+// evictionCandidates returns the rotated logs that may be deleted, oldest
+// first, leaving out the newest minPerNode rotated logs of each node
+func evictionCandidates(files []budgetFile, minPerNode int) []budgetFile {
+	var candidates []budgetFile
+	for _, f := range files {
+		if f.rotation > 0 {
+			candidates = append(candidates, f)
+		}
+	}
+
+	// Rotation numbers may have gaps after earlier evictions, so rank them per node
+	slices.SortFunc(candidates, func(a, b budgetFile) int {
+		return cmp.Or(strings.Compare(a.node, b.node), cmp.Compare(a.rotation, b.rotation))
+	})
+	kept := make(map[string]int)
+	candidates = slices.DeleteFunc(candidates, func(f budgetFile) bool {
+		if f.node == "" || kept[f.node] >= minPerNode {
+			return false
+		}
+		kept[f.node]++
+		return true
+	})
+
+	slices.SortFunc(candidates, func(a, b budgetFile) int {
+		return cmp.Or(a.modTime.Compare(b.modTime), cmp.Compare(b.rotation, a.rotation))
+	})
+	return candidates
+}
+
+// DiskStatus returns the result of the last disk budget check, false before the first
+func (ls *LogsService) DiskStatus() (DiskStatus, bool) {
+	ls.disk.mutex.Lock()
+	defer ls.disk.mutex.Unlock()
+	return ls.disk.status, ls.disk.checked
+}
+
+// diskMetrics returns the disk usage metrics of the logs
+func (ls *LogsService) diskMetrics() []metrics.Metric {
+	status, ok := ls.DiskStatus()
+	if !ok {
+		return nil
+	}
+
+	volumes := metrics.Metric{
+		Name: "remote_console_log_volume_free_bytes",
+		Help: "Free space on the volume holding a log directory.",
+		Type: metrics.TypeGauge,
+	}
+	for _, v := range status.Volumes {
+		volumes.Samples = append(volumes.Samples, metrics.Sample{Labels: map[string]string{"path": v.Path}, Value: float64(v.FreeBytes)})
+	}
+
+	return []metrics.Metric{
+		{
+			Name:    "remote_console_log_disk_used_bytes",
+			Help:    "Space used by the console, backup and aggregation logs.",
+			Type:    metrics.TypeGauge,
+			Samples: []metrics.Sample{{Value: float64(status.UsedBytes)}},
+		},
+		{
+			Name:    "remote_console_log_disk_budget_bytes",
+			Help:    "Disk budget of the logs, 0 for no limit.",
+			Type:    metrics.TypeGauge,
+			Samples: []metrics.Sample{{Value: float64(status.BudgetBytes)}},
+		},
+		{
+			Name:    "remote_console_log_disk_low_space",
+			Help:    "Whether the logs are over budget or a log volume is low on free space.",
+			Type:    metrics.TypeGauge,
+			Samples: []metrics.Sample{{Value: metrics.Bool(status.LowSpace)}},
+		},
+		{
+			Name:    "remote_console_log_evicted_files_total",
+			Help:    "Rotated logs deleted to stay within the disk budget and retention.",
+			Type:    metrics.TypeCounter,
+			Samples: []metrics.Sample{{Value: float64(status.EvictedFiles)}},
+		},
+		{
+			Name:    "remote_console_log_evicted_bytes_total",
+			Help:    "Size of the rotated logs deleted to stay within the disk budget and retention.",
+			Type:    metrics.TypeCounter,
+			Samples: []metrics.Sample{{Value: float64(status.EvictedBytes)}},
+		},
+		volumes,
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package logs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeAgedFile writes a log of size bytes last modified age ago
+func writeAgedFile(t *testing.T, path string, size int, age time.Duration) {
+	require.NoError(t, os.WriteFile(path, []byte(strings.Repeat("x", size)), 0600))
+	modTime := time.Now().Add(-age)
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestEnforceDiskBudget(t *testing.T) {
+	config := DefaultLogConfig()
+	config.LogsDiskBudget = "5K"
+	config.LogsMinRotatedPerNode = 1
+	config.AggLogsPath = t.TempDir()
+	config.SearchIndexMaxLines = 0
+	service, logsPath := newRotationService(t, config, "x0c0s1b0", "x0c0s1b1")
+	service.conAggLogFile = filepath.Join(config.AggLogsPath, "consoleAgg-test.log")
+	backupPath := service.config.ConsoleLogsBackupPath
+
+	_, ok := service.DiskStatus()
+	require.False(t, ok)
+	require.Nil(t, service.diskMetrics())
+
+	// Live logs are never evicted
+	writeAgedFile(t, filepath.Join(logsPath, "console.x0c0s1b0"), 1024, 0)
+	writeAgedFile(t, service.conAggLogFile, 512, 0)
+
+	keptB0 := filepath.Join(backupPath, "console.x0c0s1b0.1")
+	oldestB0 := filepath.Join(backupPath, "console.x0c0s1b0.2.gz")
+	keptB1 := filepath.Join(backupPath, "console.x0c0s1b1.3")
+	oldAgg := filepath.Join(config.AggLogsPath, "consoleAgg-test.log.1")
+	writeAgedFile(t, keptB0, 1024, 3*time.Hour)
+	writeAgedFile(t, oldestB0, 1024, 5*time.Hour)
+	writeAgedFile(t, keptB1, 1024, 6*time.Hour) // the newest rotation of its node even though the oldest file
+	writeAgedFile(t, oldAgg, 1024, 4*time.Hour)
+
+	// The oldest evictable files go first until the logs fit in the budget
+	service.EnforceDiskBudget()
+	_, err := os.Stat(oldestB0)
+	require.ErrorIs(t, err, os.ErrNotExist)
+	for _, path := range []string{keptB0, keptB1, oldAgg} {
+		_, err := os.Stat(path)
+		require.NoError(t, err, path)
+	}
+
+	status, ok := service.DiskStatus()
+	require.True(t, ok)
+	require.Equal(t, int64(1024+512+3*1024), status.UsedBytes)
+	require.Equal(t, int64(5120), status.BudgetBytes)
+	require.False(t, status.LowSpace)
+	require.Equal(t, int64(1), status.EvictedFiles)
+	require.Equal(t, int64(1024), status.EvictedBytes)
+	require.Len(t, status.Volumes, 3)
+	require.Greater(t, status.Volumes[0].TotalBytes, uint64(0))
+
+	// Logs that can't be brought within the budget are reported as low on space
+	writeAgedFile(t, filepath.Join(logsPath, "console.x0c0s1b1"), 8192, 0)
+	service.EnforceDiskBudget()
+	_, err = os.Stat(oldAgg)
+	require.ErrorIs(t, err, os.ErrNotExist)
+	status, _ = service.DiskStatus()
+	require.True(t, status.LowSpace)
+	require.Equal(t, int64(2), status.EvictedFiles)
+
+	names := make(map[string]float64)
+	for _, m := range service.diskMetrics() {
+		if len(m.Samples) == 1 && m.Samples[0].Labels == nil {
+			names[m.Name] = m.Samples[0].Value
+		}
+	}
+	require.Equal(t, float64(1), names["remote_console_log_disk_low_space"])
+	require.Equal(t, float64(2), names["remote_console_log_evicted_files_total"])
+}
+
+func TestEnforceDiskRetention(t *testing.T) {
+	config := DefaultLogConfig()
+	config.LogsRetentionHours = 24
+	config.SearchIndexMaxLines = 0
+	service, _ := newRotationService(t, config)
+	backupPath := service.config.ConsoleLogsBackupPath
+
+	recent := filepath.Join(backupPath, "console.x0c0s1b0.1")
+	expired := filepath.Join(backupPath, "console.x0c0s1b0.2")
+	writeAgedFile(t, recent, 10, time.Hour)
+	writeAgedFile(t, expired, 10, 48*time.Hour)
+
+	service.EnforceDiskBudget()
+	_, err := os.Stat(recent)
+	require.NoError(t, err)
+	_, err = os.Stat(expired)
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	// Volumes below the free space threshold are low on space
+	service.config.LogsMinFreePercent = 101
+	service.EnforceDiskBudget()
+	status, _ := service.DiskStatus()
+	require.True(t, status.LowSpace)
+	require.True(t, status.Volumes[0].LowSpace)
+}
+
+func TestEvictionCandidates(t *testing.T) {
+	now := time.Now()
+	files := []budgetFile{
+		{path: "live", node: "x1", modTime: now},
+		{path: "x1.1", node: "x1", rotation: 1, modTime: now.Add(-time.Hour)},
+		{path: "x1.4", node: "x1", rotation: 4, modTime: now.Add(-2 * time.Hour)},
+		{path: "x1.7", node: "x1", rotation: 7, modTime: now.Add(-3 * time.Hour)},
+		{path: "agg.1", rotation: 1, modTime: now.Add(-time.Minute)},
+	}
+
+	var paths []string
+	for _, f := range evictionCandidates(files, 2) {
+		paths = append(paths, f.path)
+	}
+	require.Equal(t, []string{"x1.7", "agg.1"}, paths)
+}
+
+func TestInitDiskBudgetInvalid(t *testing.T) {
+	config := DefaultLogConfig()
+	config.ConsoleLogsBackupPath = filepath.Join(t.TempDir(), "conman.old")
+	config.LogsDiskBudget = "lots"
+	_, err := NewLogsService(config)
+	require.Error(t, err)
+}
@@ -0,0 +1,227 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// This file contains access to the retained history of a console log, the
+// live file along with the files moved to the backup directory by rotation
+
+package logs
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// ConsoleLogFile is one file of a console log's history
+type ConsoleLogFile struct {
+	Path       string
+	Rotation   int  // 0 for the live log, higher numbers are older
+	Compressed bool // gzip or zstd compressed by rotation, as given by the file suffix
+}
+
+// ConsoleLogFiles returns the retained log files for xname, oldest first and
+// ending with the live log. The live log is always included even when it does
+// not exist yet, since conman recreates it on the next output.
+func (ls *LogsService) ConsoleLogFiles(consoleLogsPath, xname string) ([]ConsoleLogFile, error) {
+	return consoleLogFiles(consoleLogsPath, ls.config.ConsoleLogsBackupPath, xname)
+}
+
+func consoleLogFiles(consoleLogsPath, backupPath, xname string) ([]ConsoleLogFile, error) {
+	// Rotation names the files console.<xname>.<N>, with a .gz or .zst suffix when compressed
+	live := filepath.Join(consoleLogsPath, fmt.Sprintf("console.%s", xname))
+	files, err := rotatedFiles(backupPath, filepath.Base(live))
+	if err != nil {
+		return nil, err
+	}
+	slices.Reverse(files)
+
+	return append(files, ConsoleLogFile{Path: live}), nil
+}
+
+// Open opens the log file for reading, decompressing it if needed
+func (f ConsoleLogFile) Open() (io.ReadCloser, error) {
+	file, err := os.Open(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	if !f.Compressed {
+		return file, nil
+	}
+
+	var zr io.ReadCloser
+	switch compressionOf(f.Path) {
+	case CompressionZstd:
+		var d *zstd.Decoder
+		if d, err = zstd.NewReader(file); err == nil {
+			zr = d.IOReadCloser()
+		}
+	default:
+		zr, err = gzip.NewReader(file)
+	}
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to decompress %q: %w", f.Path, err)
+	}
+	return &compressedLogReader{ReadCloser: zr, file: file}, nil
+}
+
+// compressedLogReader closes both the decompressor and the underlying file
+type compressedLogReader struct {
+	io.ReadCloser
+	file *os.File
+}
+
+func (r *compressedLogReader) Close() error {
+	zerr := r.ReadCloser.Close()
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	return zerr
+}
+
+// ConsoleLogFileInfo describes a console log file
+type ConsoleLogFileInfo struct {
+	Name        string     `json:"name"`
+	Rotation    int        `json:"rotation"`
+	Compressed  bool       `json:"compressed"`
+	Compression string     `json:"compression,omitempty"` // gzip or zstd when compressed
+	Size        int64      `json:"size"`
+	ModTime     time.Time  `json:"modTime"`
+	Start       *time.Time `json:"start,omitempty"` // first timestamp in the file
+	End         *time.Time `json:"end,omitempty"`   // last timestamp in the file
+}
+
+// fileTimeRange is the range of the timestamps found in a log file, up to
+// the end of its last complete line
+type fileTimeRange struct {
+	stat       os.FileInfo
+	start, end *time.Time
+	scannedTo  int64
+}
+
+// timeRanges caches the time range of each log file by path, so listing the
+// history only reads what was added to a file since it was last listed.
+// Entries follow the files rotation renames and are dropped with the files
+// rotation and the disk budget remove.
+var timeRanges = struct {
+	sync.Mutex
+	byPath map[string]fileTimeRange
+}{byPath: make(map[string]fileTimeRange)}
+
+// forgetTimeRange drops the cached time range of a removed log file
+func forgetTimeRange(path string) {
+	timeRanges.Lock()
+	defer timeRanges.Unlock()
+	delete(timeRanges.byPath, path)
+}
+
+// moveTimeRange keeps the cached time range of a renamed log file
+func moveTimeRange(from, to string) {
+	timeRanges.Lock()
+	defer timeRanges.Unlock()
+	tr, ok := timeRanges.byPath[from]
+	delete(timeRanges.byPath, from)
+	delete(timeRanges.byPath, to)
+	if ok {
+		timeRanges.byPath[to] = tr
+	}
+}
+
+// Info returns the size of the log file and the range of the timestamps conman wrote in it
+func (f ConsoleLogFile) Info() (ConsoleLogFileInfo, error) {
+	stat, err := os.Stat(f.Path)
+	if err != nil {
+		return ConsoleLogFileInfo{}, err
+	}
+
+	info := ConsoleLogFileInfo{
+		Name:       filepath.Base(f.Path),
+		Rotation:   f.Rotation,
+		Compressed: f.Compressed,
+		Size:       stat.Size(),
+		ModTime:    stat.ModTime().UTC(),
+	}
+	if f.Compressed {
+		info.Compression = compressionOf(f.Path)
+	}
+
+	timeRanges.Lock()
+	cached, ok := timeRanges.byPath[f.Path]
+	timeRanges.Unlock()
+
+	tr := fileTimeRange{stat: stat}
+	switch {
+	case ok && os.SameFile(cached.stat, stat) && cached.stat.Size() == stat.Size() && cached.stat.ModTime().Equal(stat.ModTime()):
+		tr = cached
+	case ok && !f.Compressed && os.SameFile(cached.stat, stat) && cached.stat.Size() <= stat.Size():
+		// The live log only grows until it is rotated, so read what was added
+		tr.start, tr.end, tr.scannedTo = cached.start, cached.end, cached.scannedTo
+		fallthrough
+	default:
+		if err := tr.scan(f); err != nil {
+			return ConsoleLogFileInfo{}, err
+		}
+		timeRanges.Lock()
+		timeRanges.byPath[f.Path] = tr
+		timeRanges.Unlock()
+	}
+
+	info.Start, info.End = tr.start, tr.end
+	return info, nil
+}
+
+// scan extends the time range with the lines of the file after scannedTo
+func (tr *fileTimeRange) scan(f ConsoleLogFile) error {
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+	if tr.scannedTo > 0 {
+		if _, err := r.(io.Seeker).Seek(tr.scannedTo, io.SeekStart); err != nil {
+			return fmt.Errorf("failed to read %q: %w", f.Path, err)
+		}
+	}
+
+	// A timestamp only needs the start of a line, so the rest of a line
+	// longer than the buffer is skipped rather than failing the scan
+	br := bufio.NewReaderSize(r, 64*1024)
+	for {
+		line, err := br.ReadSlice('\n')
+		length := int64(len(line))
+		ts, ok := ParseLineTimestamp(string(bytes.TrimRight(line, "\r\n")))
+		for errors.Is(err, bufio.ErrBufferFull) {
+			line, err = br.ReadSlice('\n')
+			length += int64(len(line))
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to read %q: %w", f.Path, err)
+		}
+
+		if ok {
+			ts = ts.UTC()
+			if tr.start == nil {
+				tr.start = &ts
+			}
+			tr.end = &ts
+		}
+		// Only count complete lines, so a line still being written is read again
+		if err != nil {
+			return nil
+		}
+		tr.scannedTo += length
+	}
+}
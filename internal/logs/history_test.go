@@ -0,0 +1,142 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package logs
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeGzipFile(t *testing.T, path, content string) {
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	zw := gzip.NewWriter(f)
+	_, err = zw.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	require.NoError(t, f.Close())
+}
+
+func TestConsoleLogFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	logsPath := filepath.Join(tempDir, "conman")
+	backupPath := filepath.Join(tempDir, "conman.old")
+	require.NoError(t, os.MkdirAll(backupPath, 0755))
+
+	require.NoError(t, os.WriteFile(filepath.Join(backupPath, "console.x1000c0s0b0n0.1"), []byte("newer\n"), 0600))
+	writeGzipFile(t, filepath.Join(backupPath, "console.x1000c0s0b0n0.2.gz"), "older\n")
+
+	// Files of other nodes and unrelated names are ignored
+	require.NoError(t, os.WriteFile(filepath.Join(backupPath, "console.x1000c0s0b0n01.1"), nil, 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(backupPath, "console.x1000c0s0b0n0.tmp"), nil, 0600))
+
+	files, err := consoleLogFiles(logsPath, backupPath, "x1000c0s0b0n0")
+	require.NoError(t, err)
+	require.Equal(t, []ConsoleLogFile{
+		{Path: filepath.Join(backupPath, "console.x1000c0s0b0n0.2.gz"), Rotation: 2, Compressed: true},
+		{Path: filepath.Join(backupPath, "console.x1000c0s0b0n0.1"), Rotation: 1},
+		{Path: filepath.Join(logsPath, "console.x1000c0s0b0n0")},
+	}, files)
+
+	for i, expected := range []string{"older\n", "newer\n"} {
+		r, err := files[i].Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+		require.Equal(t, expected, string(data))
+	}
+
+	_, err = files[2].Open()
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	// A missing backup directory just means there are no rotated files
+	files, err = consoleLogFiles(logsPath, filepath.Join(tempDir, "missing"), "x1000c0s0b0n0")
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+}
+
+func TestConsoleLogFileInfo(t *testing.T) {
+	tempDir := t.TempDir()
+	content := "booting\n2026-03-04 05:00:00 UTC Linux version 6.1.0\nno timestamp\n2026-03-04 07:00:00 UTC login:\n"
+
+	rotated := filepath.Join(tempDir, "console.x1000c0s0b0n0.1.gz")
+	writeGzipFile(t, rotated, content)
+
+	info, err := ConsoleLogFile{Path: rotated, Rotation: 1, Compressed: true}.Info()
+	require.NoError(t, err)
+	require.Equal(t, "console.x1000c0s0b0n0.1.gz", info.Name)
+	require.Equal(t, 1, info.Rotation)
+	require.True(t, info.Compressed)
+	require.Positive(t, info.Size)
+	require.Equal(t, time.Date(2026, 3, 4, 5, 0, 0, 0, time.UTC), *info.Start)
+	require.Equal(t, time.Date(2026, 3, 4, 7, 0, 0, 0, time.UTC), *info.End)
+
+	live := filepath.Join(tempDir, "console.x1000c0s0b0n0")
+	require.NoError(t, os.WriteFile(live, []byte("no timestamps\n"), 0600))
+	info, err = ConsoleLogFile{Path: live}.Info()
+	require.NoError(t, err)
+	require.Nil(t, info.Start)
+	require.Nil(t, info.End)
+}
+
+func TestConsoleLogFileInfoLongLines(t *testing.T) {
+	live := filepath.Join(t.TempDir(), "console.x1000c0s0b0n0")
+	content := "2026-03-04 05:00:00 UTC booting\n" +
+		"2026-03-04 06:00:00 UTC " + strings.Repeat("x", 2*1024*1024) + "\n" +
+		strings.Repeat("y", 2*1024*1024) + "\n" +
+		"2026-03-04 07:00:00 UTC login:\n"
+	require.NoError(t, os.WriteFile(live, []byte(content), 0600))
+
+	// Lines over the buffer are skipped past, keeping their timestamps
+	info, err := ConsoleLogFile{Path: live}.Info()
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 3, 4, 5, 0, 0, 0, time.UTC), *info.Start)
+	require.Equal(t, time.Date(2026, 3, 4, 7, 0, 0, 0, time.UTC), *info.End)
+
+	timeRanges.Lock()
+	scannedTo := timeRanges.byPath[live].scannedTo
+	timeRanges.Unlock()
+	require.Equal(t, int64(len(content)), scannedTo)
+}
+
+func TestConsoleLogFileInfoCache(t *testing.T) {
+	live := filepath.Join(t.TempDir(), "console.x1000c0s0b0n0")
+	require.NoError(t, os.WriteFile(live, []byte("2026-03-04 05:00:00 UTC booting\n2026-03-04 06:00:00 UTC partial"), 0600))
+
+	info, err := ConsoleLogFile{Path: live}.Info()
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 3, 4, 5, 0, 0, 0, time.UTC), *info.Start)
+	require.Equal(t, time.Date(2026, 3, 4, 6, 0, 0, 0, time.UTC), *info.End)
+
+	// Appending resumes from the last complete line
+	file, err := os.OpenFile(live, os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	_, err = file.WriteString(" line\n2026-03-04 07:00:00 UTC login:\n")
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	info, err = ConsoleLogFile{Path: live}.Info()
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 3, 4, 5, 0, 0, 0, time.UTC), *info.Start)
+	require.Equal(t, time.Date(2026, 3, 4, 7, 0, 0, 0, time.UTC), *info.End)
+
+	// A replaced file is scanned again
+	replacement := live + ".new"
+	require.NoError(t, os.WriteFile(replacement, []byte("2026-03-05 01:00:00 UTC booting\n"), 0600))
+	require.NoError(t, os.Rename(replacement, live))
+
+	info, err = ConsoleLogFile{Path: live}.Info()
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 3, 5, 1, 0, 0, 0, time.UTC), *info.Start)
+	require.Equal(t, time.Date(2026, 3, 5, 1, 0, 0, 0, time.UTC), *info.End)
+}
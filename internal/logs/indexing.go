@@ -0,0 +1,112 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// This file feeds the console logs read by aggregation to the log indexes
+
+package logs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// logIndexer is given each node's console log lines in order, starting with
+// the oldest rotated log when aggregation starts tailing the node
+type logIndexer interface {
+	beginNode(xname string)                   // discard any earlier state for the node
+	beginFile(xname string)                   // the following lines come from the next log file
+	addLine(xname, text string, offset int64) // offset is the start of the line in its file
+	removeNode(xname string)
+}
+
+// indexRotatedLogs feeds a node's rotated logs, redacted, to the indexers,
+// leaving them ready for lines from the live log
+func (ls *LogsService) indexRotatedLogs(consoleLogsPath, xname string) {
+	if len(ls.indexers) == 0 {
+		return
+	}
+
+	for _, ix := range ls.indexers {
+		ix.beginNode(xname)
+	}
+
+	files, err := ls.ConsoleLogFiles(consoleLogsPath, xname)
+	if err != nil {
+		slog.Warn("Failed to list rotated console logs for indexing", "xname", xname, "error", err)
+	}
+	redact := ls.redactor.Stream()
+	for _, f := range files {
+		if f.Rotation == 0 {
+			continue
+		}
+		for _, ix := range ls.indexers {
+			ix.beginFile(xname)
+		}
+		if err := ls.indexLogFile(xname, f, redact); err != nil {
+			slog.Warn("Failed to index rotated console log", "file", f.Path, "error", err)
+		}
+	}
+
+	for _, ix := range ls.indexers {
+		ix.beginFile(xname)
+	}
+}
+
+func (ls *LogsService) indexLogFile(xname string, f ConsoleLogFile, redact *RedactStream) error {
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := r.Close(); err != nil {
+			slog.Warn("Failed to close console log", "file", f.Path, "error", err)
+		}
+	}()
+
+	br := bufio.NewReader(r)
+	var offset int64
+	for {
+		line, err := br.ReadString('\n')
+		if len(line) > 0 {
+			for _, ix := range ls.indexers {
+				ix.addLine(xname, redact.Line(strings.TrimSuffix(line, "\n")), offset)
+			}
+			offset += int64(len(line))
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", f.Path, err)
+		}
+	}
+}
+
+// liveLogPosition follows the position of the lines tailed from a live log
+type liveLogPosition struct {
+	end int64 // offset after the last line
+}
+
+// indexLiveLine feeds a line tailed from the live log to the indexers. start
+// and end are the offsets of the line as read, before any redaction.
+func (ls *LogsService) indexLiveLine(pos *liveLogPosition, xname, text string, start, end int64) {
+	if len(ls.indexers) == 0 {
+		return
+	}
+
+	// Reading from the start again means the log was rotated
+	if start == 0 && pos.end > 0 {
+		for _, ix := range ls.indexers {
+			ix.beginFile(xname)
+		}
+	}
+	pos.end = end
+
+	for _, ix := range ls.indexers {
+		ix.addLine(xname, text, start)
+	}
+}
@@ -0,0 +1,739 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// This file keeps a hash chain for each console log so changes made to the
+// logs after they were written can be detected
+
+package logs
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Types of hash chain records
+const (
+	IntegrityStart      = "start"      // a new live log was first seen
+	IntegrityCheckpoint = "checkpoint" // the live log was hashed up to an offset
+	IntegritySeal       = "seal"       // the log was rotated and hashed in full
+	IntegrityDeleted    = "deleted"    // the log was deleted by rotation or the disk budget
+	IntegrityBreak      = "break"      // the live log no longer matched its checkpoints
+)
+
+// Kinds of problems found by verification
+const (
+	ProblemChain     = "chain"     // chain records were changed, removed or reordered
+	ProblemModified  = "modified"  // a log differs from what was hashed
+	ProblemTruncated = "truncated" // a log is shorter than what was hashed
+	ProblemMissing   = "missing"   // a log is gone without being deleted by the service
+	ProblemBreak     = "break"     // the service found a live log changed behind its back
+	ProblemUnsealed  = "unsealed"  // a rotated log could not be hashed when it was rotated
+)
+
+// ErrIntegrityDisabled is returned when integrity mode is not enabled
+var ErrIntegrityDisabled = errors.New("console log integrity is disabled")
+
+// ErrNoIntegrityChain is returned for a console log without a hash chain
+var ErrNoIntegrityChain = errors.New("console log has no hash chain")
+
+// IntegrityRecord is one line of a console log's hash chain
+type IntegrityRecord struct {
+	Seq      uint64    `json:"seq"` // from 1, without gaps
+	Type     string    `json:"type"`
+	Time     time.Time `json:"time"`
+	Segment  uint64    `json:"segment"`            // the log file the record is about
+	Offset   int64     `json:"offset,omitempty"`   // number of bytes hashed
+	SHA256   string    `json:"sha256,omitempty"`   // hash of the first Offset bytes
+	File     string    `json:"file,omitempty"`     // name of the rotated or deleted file
+	Redacted bool      `json:"redacted,omitempty"` // the sealed log was rewritten by redaction
+	Detail   string    `json:"detail,omitempty"`
+	Prev     string    `json:"prev"`          // SHA-256 of the previous record's line
+	MAC      string    `json:"mac,omitempty"` // HMAC-SHA256 of the record without its MAC
+}
+
+// IntegrityReport is the result of verifying a console log against its hash chain
+type IntegrityReport struct {
+	Node       string             `json:"node"`
+	Verified   bool               `json:"verified"` // no gap or modification was found
+	Records    uint64             `json:"records"`
+	Head       string             `json:"head,omitempty"` // SHA-256 of the last chain record
+	Segments   []SegmentStatus    `json:"segments"`
+	Problems   []IntegrityProblem `json:"problems,omitempty"`
+	Unverified []string           `json:"unverified,omitempty"` // retained logs older than the chain
+}
+
+// Segment statuses
+const (
+	SegmentOK       = "ok"
+	SegmentLive     = "live"
+	SegmentDeleted  = "deleted"
+	SegmentModified = "modified"
+	SegmentMissing  = "missing"
+	SegmentBroken   = "broken"
+)
+
+// SegmentStatus is the state of one log file covered by the hash chain
+type SegmentStatus struct {
+	Segment uint64 `json:"segment"`
+	File    string `json:"file,omitempty"`
+	Status  string `json:"status"`
+	Bytes   int64  `json:"bytes"`             // covered by the chain
+	Pending int64  `json:"pending,omitempty"` // live bytes written since the last checkpoint
+}
+
+// IntegrityProblem is a gap or modification found by verification
+type IntegrityProblem struct {
+	Kind    string `json:"kind"`
+	Segment uint64 `json:"segment,omitempty"`
+	File    string `json:"file,omitempty"`
+	Detail  string `json:"detail"`
+}
+
+// integrityTracker maintains the hash chains of the console logs
+type integrityTracker struct {
+	dir     string
+	key     []byte // signs the records when set
+	mutex   sync.Mutex
+	chains  map[string]*integrityChain
+	touched map[string]bool // nodes whose live log has new lines
+}
+
+// integrityChain is the state of a node's hash chain
+type integrityChain struct {
+	path   string
+	seq    uint64
+	last   string // hash of the last record
+	next   uint64 // next segment number
+	open   *liveSegment
+	sealed []uint64 // in the order they were rotated, the last one is rotation 1
+}
+
+// liveSegment follows the hashing of the live log
+type liveSegment struct {
+	id           uint64
+	offset       int64
+	checkpointed int64
+	hash         hash.Hash   // nil until the live log is hashed again after a restart
+	file         os.FileInfo // identifies the live log that was hashed
+	checkpoints  []IntegrityRecord
+}
+
+// segmentRecords gathers the chain records about one log file
+type segmentRecords struct {
+	id          uint64
+	checkpoints []IntegrityRecord
+	seal        *IntegrityRecord
+	deleted     *IntegrityRecord
+	broken      *IntegrityRecord
+}
+
+// LoadIntegrityKey reads the key signing the hash chain records
+func LoadIntegrityKey(path string) ([]byte, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read integrity key: %w", err)
+	}
+	key := bytes.TrimSpace(data)
+	if len(key) == 0 {
+		return nil, fmt.Errorf("integrity key file %q is empty", path)
+	}
+	return key, nil
+}
+
+// initIntegrity prepares the hash chains when integrity mode is enabled
+func (ls *LogsService) initIntegrity() error {
+	if !ls.config.IntegrityEnabled {
+		return nil
+	}
+	key, err := LoadIntegrityKey(ls.config.IntegrityKeyFile)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(ls.config.IntegrityPath, 0700); err != nil {
+		return fmt.Errorf("error ensuring integrity directory: %w", err)
+	}
+
+	ls.integrity = &integrityTracker{
+		dir:     ls.config.IntegrityPath,
+		key:     key,
+		chains:  make(map[string]*integrityChain),
+		touched: make(map[string]bool),
+	}
+	slog.Info("Console log integrity enabled", "path", ls.config.IntegrityPath, "signed", key != nil)
+	return nil
+}
+
+func chainPath(dir, xname string) string {
+	return filepath.Join(dir, fmt.Sprintf("console.%s.chain", xname))
+}
+
+// IntegrityNodes lists the nodes with a hash chain in dir
+func IntegrityNodes(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read integrity directory: %w", err)
+	}
+	var xnames []string
+	for _, entry := range entries {
+		name, ok := strings.CutPrefix(entry.Name(), "console.")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		if xname, ok := strings.CutSuffix(name, ".chain"); ok {
+			xnames = append(xnames, xname)
+		}
+	}
+	return xnames, nil
+}
+
+// touch notes that the live log of xname has new lines
+func (it *integrityTracker) touch(xname string) {
+	it.mutex.Lock()
+	defer it.mutex.Unlock()
+	it.touched[xname] = true
+}
+
+// chain returns the chain of xname, loading it on first use
+func (it *integrityTracker) chain(xname string) *integrityChain {
+	if c, ok := it.chains[xname]; ok {
+		return c
+	}
+
+	c := &integrityChain{path: chainPath(it.dir, xname), next: 1}
+	records, problems, head, err := readChain(c.path, it.key)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Error("Failed to read console log hash chain", "xname", xname, "error", err)
+	}
+	for _, p := range problems {
+		slog.Error("Console log hash chain is damaged", "xname", xname, "detail", p.Detail)
+	}
+	if n := len(records); n > 0 {
+		c.seq, c.last = records[n-1].Seq, head
+	}
+
+	segments, sealed := chainSegments(records)
+	c.sealed = sealed
+	for _, s := range segments {
+		c.next = max(c.next, s.id+1)
+	}
+	if n := len(segments); n > 0 {
+		if s := segments[n-1]; s.seal == nil && s.deleted == nil && s.broken == nil {
+			// The live log is hashed again and checked against these on the next checkpoint
+			c.open = &liveSegment{id: s.id, checkpoints: s.checkpoints}
+			if n := len(s.checkpoints); n > 0 {
+				c.open.checkpointed = s.checkpoints[n-1].Offset
+			}
+		}
+	}
+
+	it.chains[xname] = c
+	return c
+}
+
+// append signs and writes a record at the end of the chain
+func (it *integrityTracker) append(c *integrityChain, rec IntegrityRecord) error {
+	rec.Seq = c.seq + 1
+	rec.Time = time.Now().UTC()
+	rec.Prev = c.last
+	rec.MAC = ""
+	if it.key != nil {
+		data, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		rec.MAC = recordMAC(it.key, data)
+	}
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(c.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open hash chain: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write hash chain: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write hash chain: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	c.seq, c.last = rec.Seq, hex.EncodeToString(sum[:])
+	return nil
+}
+
+func recordMAC(key, data []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// CheckpointIntegrity hashes the new output of the live logs that had lines
+// since the last checkpoint and adds a checkpoint to their chains
+func (ls *LogsService) CheckpointIntegrity() {
+	if ls.integrity == nil {
+		return
+	}
+	ls.mutex.Lock()
+	defer ls.mutex.Unlock()
+
+	it := ls.integrity
+	it.mutex.Lock()
+	defer it.mutex.Unlock()
+
+	for xname := range it.touched {
+		live := filepath.Join(ls.rotation.consoleLogsPath, fmt.Sprintf("console.%s", xname))
+		if err := it.checkpoint(it.chain(xname), live); err != nil {
+			slog.Error("Failed to checkpoint console log hash chain", "xname", xname, "error", err)
+		}
+	}
+	clear(it.touched)
+}
+
+// checkpoint hashes what was written to the live log since the last checkpoint
+func (it *integrityTracker) checkpoint(c *integrityChain, live string) error {
+	f, err := os.Open(live)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	seg := c.open
+	if seg != nil && seg.hash != nil && (!os.SameFile(seg.file, info) || info.Size() < seg.offset) {
+		if err := it.breakSegment(c, "the live log was replaced or truncated outside rotation"); err != nil {
+			return err
+		}
+		seg = nil
+	}
+	if seg != nil && seg.hash == nil {
+		// The service restarted, so the live log is checked against the earlier checkpoints
+		h, n, problem := hashCheckpoints(f, seg.checkpoints, info.Size())
+		if problem != nil {
+			slog.Error("Console log changed while it was not being followed", "file", live, "detail", problem.Detail)
+			if err := it.breakSegment(c, problem.Detail); err != nil {
+				return err
+			}
+			seg = nil
+		} else {
+			seg.hash, seg.offset, seg.checkpoints = h, n, nil
+		}
+	}
+	if seg == nil {
+		if info.Size() == 0 {
+			return nil
+		}
+		seg = &liveSegment{id: c.next, hash: sha256.New()}
+		if err := it.append(c, IntegrityRecord{Type: IntegrityStart, Segment: seg.id, File: filepath.Base(live)}); err != nil {
+			return err
+		}
+		c.next++
+		c.open = seg
+	}
+	seg.file = info
+
+	if _, err := f.Seek(seg.offset, io.SeekStart); err != nil {
+		return err
+	}
+	n, err := io.CopyN(seg.hash, f, info.Size()-seg.offset)
+	seg.offset += n
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("failed to hash %q: %w", live, err)
+	}
+	if seg.offset == seg.checkpointed {
+		return nil
+	}
+	seg.checkpointed = seg.offset
+	return it.append(c, IntegrityRecord{
+		Type:    IntegrityCheckpoint,
+		Segment: seg.id,
+		Offset:  seg.offset,
+		SHA256:  hex.EncodeToString(seg.hash.Sum(nil)),
+	})
+}
+
+// breakSegment records that the live log no longer matches its chain
+func (it *integrityTracker) breakSegment(c *integrityChain, detail string) error {
+	err := it.append(c, IntegrityRecord{Type: IntegrityBreak, Segment: c.open.id, Offset: c.open.offset, Detail: detail})
+	c.open = nil
+	return err
+}
+
+// seal hashes a rotated console log in full and closes its segment
+func (it *integrityTracker) seal(event RotationEvent) {
+	if event.Node == "" {
+		return
+	}
+	it.mutex.Lock()
+	defer it.mutex.Unlock()
+
+	c := it.chain(event.Node)
+	seg := c.open
+	c.open = nil
+	delete(it.touched, event.Node)
+
+	if seg == nil {
+		// The log was rotated before it was first checkpointed
+		seg = &liveSegment{id: c.next}
+		if err := it.append(c, IntegrityRecord{Type: IntegrityStart, Segment: seg.id, File: filepath.Base(event.File)}); err != nil {
+			slog.Error("Failed to start console log hash chain segment", "xname", event.Node, "error", err)
+			return
+		}
+		c.next++
+	}
+
+	// The log was removed rather than kept by rotation
+	if event.RotatedTo == "" {
+		if err := it.append(c, IntegrityRecord{Type: IntegrityDeleted, Segment: seg.id, File: filepath.Base(event.File)}); err != nil {
+			slog.Error("Failed to record deleted console log", "xname", event.Node, "error", err)
+		}
+		return
+	}
+
+	rec := IntegrityRecord{Type: IntegritySeal, Segment: seg.id, File: filepath.Base(event.RotatedTo), Redacted: event.Redacted}
+	h, n, err := hashLogFile(rotatedLogFile(event.RotatedTo))
+	if err != nil {
+		slog.Error("Failed to hash rotated console log", "file", event.RotatedTo, "error", err)
+		rec.Detail = err.Error()
+	} else {
+		rec.Offset, rec.SHA256 = n, hex.EncodeToString(h.Sum(nil))
+	}
+
+	// The chain numbers the rotated logs even when one could not be hashed
+	c.sealed = append(c.sealed, seg.id)
+	if err := it.append(c, rec); err != nil {
+		slog.Error("Failed to seal console log hash chain segment", "xname", event.Node, "error", err)
+	}
+}
+
+// deleted records that a rotated console log was deleted by the service
+func (it *integrityTracker) deleted(xname string, rotation int, path string) {
+	if xname == "" || rotation < 1 {
+		return
+	}
+	it.mutex.Lock()
+	defer it.mutex.Unlock()
+
+	c := it.chain(xname)
+	i := len(c.sealed) - rotation
+	if i < 0 {
+		// Older than the chain
+		return
+	}
+	if err := it.append(c, IntegrityRecord{Type: IntegrityDeleted, Segment: c.sealed[i], File: filepath.Base(path)}); err != nil {
+		slog.Error("Failed to record deleted console log", "xname", xname, "error", err)
+	}
+}
+
+func rotatedLogFile(path string) ConsoleLogFile {
+	return ConsoleLogFile{Path: path, Compressed: compressionOf(path) != CompressionNone}
+}
+
+func hashLogFile(f ConsoleLogFile) (hash.Hash, int64, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+	h := sha256.New()
+	n, err := io.Copy(h, r)
+	return h, n, err
+}
+
+// hashCheckpoints hashes up to limit bytes of r, or all of it when limit is
+// negative, checking the hash at each checkpoint on the way
+func hashCheckpoints(r io.Reader, checkpoints []IntegrityRecord, limit int64) (hash.Hash, int64, *IntegrityProblem) {
+	h := sha256.New()
+	var n int64
+	for _, cp := range checkpoints {
+		k, _ := io.CopyN(h, r, cp.Offset-n)
+		n += k
+		if n < cp.Offset {
+			return h, n, &IntegrityProblem{Kind: ProblemTruncated, Segment: cp.Segment,
+				Detail: fmt.Sprintf("log ends at byte %d before the checkpoint at byte %d", n, cp.Offset)}
+		}
+		if hex.EncodeToString(h.Sum(nil)) != cp.SHA256 {
+			return h, n, &IntegrityProblem{Kind: ProblemModified, Segment: cp.Segment,
+				Detail: fmt.Sprintf("the first %d bytes do not match checkpoint %d", cp.Offset, cp.Seq)}
+		}
+	}
+
+	var k int64
+	var err error
+	if limit < 0 {
+		k, err = io.Copy(h, r)
+	} else {
+		k, err = io.CopyN(h, r, limit-n)
+	}
+	n += k
+	if err != nil && err != io.EOF {
+		return h, n, &IntegrityProblem{Kind: ProblemTruncated, Detail: fmt.Sprintf("failed to read the log: %v", err)}
+	}
+	return h, n, nil
+}
+
+// readChain reads and checks the links and signatures of a hash chain,
+// returning its records, the problems found and the hash of its last record
+func readChain(path string, key []byte) ([]IntegrityRecord, []IntegrityProblem, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	var records []IntegrityRecord
+	var problems []IntegrityProblem
+	var last string
+	var seq uint64
+	chainProblem := func(detail string, args ...any) {
+		problems = append(problems, IntegrityProblem{Kind: ProblemChain, Detail: fmt.Sprintf(detail, args...)})
+	}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		var rec IntegrityRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			chainProblem("record after %d is not valid: %v", seq, err)
+			continue
+		}
+		if rec.Seq != seq+1 {
+			chainProblem("record %d follows record %d", rec.Seq, seq)
+		}
+		if rec.Prev != last {
+			chainProblem("record %d does not link to the record before it", rec.Seq)
+		}
+		if key != nil {
+			mac := rec.MAC
+			rec.MAC = ""
+			data, err := json.Marshal(rec)
+			if err != nil || !hmac.Equal([]byte(mac), []byte(recordMAC(key, data))) {
+				chainProblem("record %d has an invalid signature", rec.Seq)
+			}
+			rec.MAC = mac
+		}
+
+		sum := sha256.Sum256(line)
+		seq, last = rec.Seq, hex.EncodeToString(sum[:])
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, "", fmt.Errorf("failed to read hash chain %q: %w", path, err)
+	}
+	return records, problems, last, nil
+}
+
+// chainSegments gathers the records by segment in the order the segments
+// started, along with the segments in the order they were sealed
+func chainSegments(records []IntegrityRecord) ([]*segmentRecords, []uint64) {
+	var segments []*segmentRecords
+	byID := make(map[uint64]*segmentRecords)
+	var sealed []uint64
+	for i := range records {
+		rec := &records[i]
+		s, ok := byID[rec.Segment]
+		if !ok {
+			s = &segmentRecords{id: rec.Segment}
+			byID[rec.Segment] = s
+			segments = append(segments, s)
+		}
+		switch rec.Type {
+		case IntegrityCheckpoint:
+			s.checkpoints = append(s.checkpoints, *rec)
+		case IntegritySeal:
+			s.seal = rec
+			sealed = append(sealed, rec.Segment)
+		case IntegrityDeleted:
+			s.deleted = rec
+		case IntegrityBreak:
+			s.broken = rec
+		}
+	}
+	return segments, sealed
+}
+
+// VerifyIntegrity checks the console logs of xname against their hash chain
+func (ls *LogsService) VerifyIntegrity(consoleLogsPath, xname string) (IntegrityReport, error) {
+	if ls.integrity == nil {
+		return IntegrityReport{}, ErrIntegrityDisabled
+	}
+	// Rotation moves the files the report is about
+	ls.mutex.Lock()
+	defer ls.mutex.Unlock()
+	return VerifyConsoleLog(consoleLogsPath, ls.config.ConsoleLogsBackupPath, ls.config.IntegrityPath, ls.integrity.key, xname)
+}
+
+// VerifyConsoleLog checks the live and rotated console logs of xname against
+// the hash chain kept in integrityPath, reporting any gap or modification
+func VerifyConsoleLog(consoleLogsPath, backupPath, integrityPath string, key []byte, xname string) (IntegrityReport, error) {
+	records, problems, head, err := readChain(chainPath(integrityPath, xname), key)
+	if errors.Is(err, os.ErrNotExist) {
+		return IntegrityReport{}, ErrNoIntegrityChain
+	}
+	if err != nil {
+		return IntegrityReport{}, err
+	}
+	report := IntegrityReport{Node: xname, Head: head, Problems: problems, Segments: []SegmentStatus{}}
+	if n := len(records); n > 0 {
+		report.Records = records[n-1].Seq
+	}
+
+	files, err := consoleLogFiles(consoleLogsPath, backupPath, xname)
+	if err != nil {
+		return IntegrityReport{}, err
+	}
+	live := files[len(files)-1]
+	rotated := make(map[int]ConsoleLogFile)
+	for _, f := range files[:len(files)-1] {
+		rotated[f.Rotation] = f
+	}
+
+	segments, sealed := chainSegments(records)
+	byID := make(map[uint64]*segmentRecords)
+	for _, s := range segments {
+		byID[s.id] = s
+	}
+	problem := func(s *segmentRecords, kind, file, detail string) {
+		report.Problems = append(report.Problems, IntegrityProblem{Kind: kind, Segment: s.id, File: file, Detail: detail})
+	}
+
+	// The rotated logs are numbered by how many segments were sealed after theirs
+	for i, id := range sealed {
+		s := byID[id]
+		status := SegmentStatus{Segment: id, File: s.seal.File, Bytes: s.seal.Offset}
+		f, exists := rotated[len(sealed)-i]
+		switch {
+		case s.deleted != nil:
+			status.Status = SegmentDeleted
+		case !exists:
+			status.Status = SegmentMissing
+			problem(s, ProblemMissing, s.seal.File, "the rotated log was removed without being deleted by the service")
+		default:
+			status.File = filepath.Base(f.Path)
+			status.Status = SegmentOK
+			if p := verifySealed(f, s); p != nil {
+				status.Status = SegmentModified
+				problem(s, p.Kind, status.File, p.Detail)
+			}
+		}
+		report.Segments = append(report.Segments, status)
+	}
+	for _, f := range files[:len(files)-1] {
+		if f.Rotation > len(sealed) {
+			report.Unverified = append(report.Unverified, filepath.Base(f.Path))
+		}
+	}
+
+	for i, s := range segments {
+		switch {
+		case s.seal != nil:
+			continue
+		case s.broken != nil:
+			report.Segments = append(report.Segments, SegmentStatus{Segment: s.id, Status: SegmentBroken, Bytes: s.broken.Offset})
+			problem(s, ProblemBreak, "", s.broken.Detail)
+		case s.deleted != nil:
+			report.Segments = append(report.Segments, SegmentStatus{Segment: s.id, File: s.deleted.File, Status: SegmentDeleted})
+		case i < len(segments)-1:
+			// Only the newest segment can still be live
+			report.Segments = append(report.Segments, SegmentStatus{Segment: s.id, Status: SegmentMissing})
+			problem(s, ProblemMissing, "", "the log ended without being rotated")
+		default:
+			report.Segments = append(report.Segments, verifyLive(live, s, problem))
+		}
+	}
+
+	report.Verified = len(report.Problems) == 0
+	return report, nil
+}
+
+// verifySealed checks a rotated log against its checkpoints and seal
+func verifySealed(f ConsoleLogFile, s *segmentRecords) *IntegrityProblem {
+	if s.seal.SHA256 == "" {
+		return &IntegrityProblem{Kind: ProblemUnsealed, Detail: fmt.Sprintf("the log was not hashed when it was rotated: %s", s.seal.Detail)}
+	}
+	r, err := f.Open()
+	if err != nil {
+		return &IntegrityProblem{Kind: ProblemMissing, Detail: err.Error()}
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+
+	// Redaction rewrote the log after its checkpoints, so only the seal applies
+	checkpoints := s.checkpoints
+	if s.seal.Redacted {
+		checkpoints = nil
+	}
+	h, n, p := hashCheckpoints(r, checkpoints, -1)
+	if p != nil {
+		return p
+	}
+	if n != s.seal.Offset || hex.EncodeToString(h.Sum(nil)) != s.seal.SHA256 {
+		return &IntegrityProblem{Kind: ProblemModified, Detail: fmt.Sprintf("the log of %d bytes does not match the %d bytes sealed at rotation", n, s.seal.Offset)}
+	}
+	return nil
+}
+
+// verifyLive checks the live log against the checkpoints of the open segment
+func verifyLive(live ConsoleLogFile, s *segmentRecords, problem func(*segmentRecords, string, string, string)) SegmentStatus {
+	name := filepath.Base(live.Path)
+	status := SegmentStatus{Segment: s.id, File: name, Status: SegmentLive}
+	if n := len(s.checkpoints); n > 0 {
+		status.Bytes = s.checkpoints[n-1].Offset
+	}
+
+	f, err := os.Open(live.Path)
+	if err != nil {
+		if status.Bytes > 0 {
+			status.Status = SegmentMissing
+			problem(s, ProblemMissing, name, "the live log was removed")
+		}
+		return status
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	_, n, p := hashCheckpoints(f, s.checkpoints, -1)
+	if p != nil {
+		status.Status = SegmentModified
+		problem(s, p.Kind, name, p.Detail)
+		return status
+	}
+	status.Pending = n - status.Bytes
+	return status
+}
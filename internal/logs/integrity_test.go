@@ -0,0 +1,194 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package logs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/OpenCHAMI/remote-console/internal/nodes"
+)
+
+func newIntegrityConfig(t *testing.T) LogConfig {
+	config := DefaultLogConfig()
+	config.ConsoleLogsFileSize = "1K"
+	config.ConsoleLogsNumRotate = 2
+	config.SearchIndexMaxLines = 0
+	config.IntegrityEnabled = true
+	config.IntegrityPath = filepath.Join(t.TempDir(), "integrity")
+	config.IntegrityKeyFile = filepath.Join(t.TempDir(), "integrity.key")
+	require.NoError(t, os.WriteFile(config.IntegrityKeyFile, []byte("secret\n"), 0600))
+	return config
+}
+
+// appendLog appends to a console log as conmand does
+func appendLog(t *testing.T, path, data string) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	_, err = f.WriteString(data)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+}
+
+func checkpoint(service *LogsService, xname string) {
+	service.integrity.touch(xname)
+	service.CheckpointIntegrity()
+}
+
+func segmentStatuses(report IntegrityReport) []string {
+	var statuses []string
+	for _, s := range report.Segments {
+		statuses = append(statuses, s.Status)
+	}
+	return statuses
+}
+
+func TestIntegrityChain(t *testing.T) {
+	xname := "x0c0s1b0"
+	config := newIntegrityConfig(t)
+	config.LogsRetentionHours = 1
+	service, logsPath := newRotationService(t, config, xname)
+	live := filepath.Join(logsPath, "console."+xname)
+	backupPath := service.config.ConsoleLogsBackupPath
+
+	// Lines are checkpointed as they arrive and the log is sealed when rotated
+	appendLog(t, live, strings.Repeat("a", 600))
+	checkpoint(service, xname)
+	appendLog(t, live, strings.Repeat("b", 600))
+	checkpoint(service, xname)
+	service.LogRotate(nil)
+	appendLog(t, live, strings.Repeat("c", 100))
+	checkpoint(service, xname)
+	appendLog(t, live, strings.Repeat("c", 50))
+
+	report, err := service.VerifyIntegrity(logsPath, xname)
+	require.NoError(t, err)
+	require.True(t, report.Verified, report.Problems)
+	require.Equal(t, uint64(6), report.Records)
+	require.Equal(t, []SegmentStatus{
+		{Segment: 1, File: "console." + xname + ".1", Status: SegmentOK, Bytes: 1200},
+		{Segment: 2, File: "console." + xname, Status: SegmentLive, Bytes: 100, Pending: 50},
+	}, report.Segments)
+
+	// Logs deleted by rotation and the disk budget are not gaps
+	appendLog(t, live, strings.Repeat("d", 2048))
+	service.LogRotate(nil)
+	appendLog(t, live, strings.Repeat("e", 2048))
+	service.LogRotate(nil)
+	appendLog(t, live, strings.Repeat("f", 2048))
+	service.LogRotate(nil)
+	writeAgedFile(t, filepath.Join(backupPath, "console."+xname+".2"), 2048, 2*time.Hour)
+
+	report, err = service.VerifyIntegrity(logsPath, xname)
+	require.NoError(t, err)
+	require.False(t, report.Verified)
+	require.Equal(t, []string{SegmentDeleted, SegmentDeleted, SegmentModified, SegmentOK}, segmentStatuses(report))
+	require.Len(t, report.Problems, 1)
+	require.Equal(t, ProblemModified, report.Problems[0].Kind)
+	require.Equal(t, uint64(3), report.Problems[0].Segment)
+
+	service.EnforceDiskBudget()
+	report, err = service.VerifyIntegrity(logsPath, xname)
+	require.NoError(t, err)
+	require.True(t, report.Verified, report.Problems)
+	require.Equal(t, []string{SegmentDeleted, SegmentDeleted, SegmentDeleted, SegmentOK}, segmentStatuses(report))
+
+	// Removing a rotated log leaves a gap
+	require.NoError(t, os.Remove(filepath.Join(backupPath, "console."+xname+".1")))
+	report, err = service.VerifyIntegrity(logsPath, xname)
+	require.NoError(t, err)
+	require.False(t, report.Verified)
+	require.Equal(t, ProblemMissing, report.Problems[0].Kind)
+	require.Equal(t, uint64(4), report.Problems[0].Segment)
+
+	// Changed chain records fail their link and signature checks
+	chain := chainPath(config.IntegrityPath, xname)
+	data, err := os.ReadFile(chain)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(chain, []byte(strings.Replace(string(data), `"offset":600`, `"offset":601`, 1)), 0600))
+	report, err = VerifyConsoleLog(logsPath, backupPath, config.IntegrityPath, []byte("secret"), xname)
+	require.NoError(t, err)
+	var kinds []string
+	for _, p := range report.Problems {
+		kinds = append(kinds, p.Kind)
+	}
+	require.Equal(t, []string{ProblemChain, ProblemChain, ProblemMissing}, kinds)
+}
+
+func TestIntegrityRestart(t *testing.T) {
+	xname := "x0c0s1b0"
+	service, logsPath := newRotationService(t, newIntegrityConfig(t), xname)
+	live := filepath.Join(logsPath, "console."+xname)
+	targets := map[string]*nodes.NodeConsoleInfo{xname: {ID: xname}}
+	restart := func() *LogsService {
+		restarted, err := NewLogsService(service.config)
+		require.NoError(t, err)
+		restarted.UpdateRotationTargets(logsPath, targets)
+		return restarted
+	}
+
+	appendLog(t, live, strings.Repeat("a", 100))
+	checkpoint(service, xname)
+
+	// The live log is followed again from the earlier checkpoints
+	service = restart()
+	appendLog(t, live, strings.Repeat("b", 100))
+	checkpoint(service, xname)
+	report, err := service.VerifyIntegrity(logsPath, xname)
+	require.NoError(t, err)
+	require.True(t, report.Verified, report.Problems)
+	require.Equal(t, []SegmentStatus{{Segment: 1, File: "console." + xname, Status: SegmentLive, Bytes: 200}}, report.Segments)
+
+	// Changes made while the service was down break the chain
+	f, err := os.OpenFile(live, os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	_, err = f.WriteAt([]byte("x"), 10)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	service = restart()
+	checkpoint(service, xname)
+	report, err = service.VerifyIntegrity(logsPath, xname)
+	require.NoError(t, err)
+	require.False(t, report.Verified)
+	require.Equal(t, []string{SegmentBroken, SegmentLive}, segmentStatuses(report))
+	require.Equal(t, ProblemBreak, report.Problems[0].Kind)
+	require.Equal(t, int64(200), report.Segments[1].Bytes)
+
+	// Truncating the live log outside rotation breaks the chain too
+	require.NoError(t, os.Truncate(live, 50))
+	checkpoint(service, xname)
+	report, err = service.VerifyIntegrity(logsPath, xname)
+	require.NoError(t, err)
+	require.Equal(t, []string{SegmentBroken, SegmentBroken, SegmentLive}, segmentStatuses(report))
+}
+
+func TestIntegrityDisabled(t *testing.T) {
+	config := DefaultLogConfig()
+	config.SearchIndexMaxLines = 0
+	service, logsPath := newRotationService(t, config)
+	_, err := service.VerifyIntegrity(logsPath, "x0c0s1b0")
+	require.ErrorIs(t, err, ErrIntegrityDisabled)
+
+	config = newIntegrityConfig(t)
+	service, logsPath = newRotationService(t, config)
+	_, err = service.VerifyIntegrity(logsPath, "x0c0s1b0")
+	require.ErrorIs(t, err, ErrNoIntegrityChain)
+
+	appendLog(t, filepath.Join(logsPath, "console.x0c0s1b0"), "line\n")
+	checkpoint(service, "x0c0s1b0")
+	xnames, err := IntegrityNodes(config.IntegrityPath)
+	require.NoError(t, err)
+	require.Equal(t, []string{"x0c0s1b0"}, xnames)
+
+	config.IntegrityKeyFile = filepath.Join(t.TempDir(), "missing.key")
+	_, err = NewLogsService(config)
+	require.Error(t, err)
+}
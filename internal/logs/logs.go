@@ -7,35 +7,133 @@ package logs
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"log/slog"
 	"os"
+	"slices"
 	"sync"
-	"time"
+
+	"github.com/OpenCHAMI/remote-console/internal/metrics"
 )
 
 type LogsService struct {
 	config LogConfig
 	mutex  sync.Mutex
 
+	// Serializes rotations, which only hold mutex while moving files
+	rotateMutex sync.Mutex
+
 	// Aggregation log fields
-	conAggMutex        sync.Mutex
-	conAggLogger       *log.Logger
-	conAggLogFile      string
-	conAggFile         *os.File
-	tailCancelByNode   map[string]*context.CancelFunc // nodeID -> cancel func
-	logRotateFileStamp map[string]time.Time           // filename -> last mod time
+	conAggMutex      sync.Mutex
+	conAggLogger     *log.Logger
+	conAggLogFile    string
+	conAggFile       *os.File
+	conAggSeq        uint64                         // sequence number of the last aggregated line
+	sinks            []logSink                      // destinations of the aggregated lines
+	tailCancelByNode map[string]*context.CancelFunc // nodeID -> cancel func
+
+	// Native log rotation targets and handlers
+	rotation rotationState
+
+	// Disk budget and the space left for the logs
+	disk diskState
+
+	// Console output watches, nil when no rules are configured
+	watches *watchEngine
+
+	// Live feeds of aggregated console output
+	subscribers subscribers
+
+	// Full-text index of the console logs, nil when search is disabled
+	search *searchIndex
+
+	// Boots found in the console logs, nil when no boot markers are configured
+	boots *bootIndex
+
+	// Indexes fed with the console log lines read by aggregation
+	indexers []logIndexer
+
+	// Hash chains of the console logs, nil unless integrity mode is enabled
+	integrity *integrityTracker
+
+	// Secret redaction of console output, nil when no rules are configured
+	redactor *Redactor
 }
 
 func NewLogsService(config LogConfig) (*LogsService, error) {
 	service := &LogsService{
-		config:             config,
-		tailCancelByNode:   make(map[string]*context.CancelFunc),
-		logRotateFileStamp: make(map[string]time.Time),
+		config:           config,
+		tailCancelByNode: make(map[string]*context.CancelFunc),
+	}
+
+	switch config.AggLogsFormat {
+	case "", AggLogsFormatText, AggLogsFormatJSON:
+	default:
+		return nil, fmt.Errorf("invalid aggregation log format %q: must be %q or %q", config.AggLogsFormat, AggLogsFormatText, AggLogsFormatJSON)
 	}
 
 	if err := service.initLogRotate(); err != nil {
 		return nil, err
 	}
 
+	if err := service.initDiskBudget(); err != nil {
+		return nil, err
+	}
+
+	if err := service.initIntegrity(); err != nil {
+		return nil, err
+	}
+
+	redactor, err := NewRedactor(config.RedactRules)
+	if err != nil {
+		return nil, err
+	}
+	service.redactor = redactor
+
+	if config.SearchIndexMaxLines > 0 {
+		service.search = newSearchIndex(config.SearchIndexMaxLines, config.ConsoleLogsNumRotate)
+		service.indexers = append(service.indexers, service.search)
+	}
+
+	// Empty markers are ignored, so setting an empty value turns boot detection off
+	bootMarkers := slices.DeleteFunc(slices.Clone(config.BootMarkers), func(m string) bool { return m == "" })
+	if len(bootMarkers) > 0 {
+		boots, err := newBootIndex(bootMarkers, config.ConsoleLogsNumRotate)
+		if err != nil {
+			return nil, err
+		}
+		service.boots = boots
+		service.indexers = append(service.indexers, service.boots)
+	}
+
+	sinks, err := newLogSinks(service, config)
+	if err != nil {
+		return nil, err
+	}
+	service.sinks = sinks
+
+	if config.WatchRulesFile != "" {
+		watchConfig, err := LoadWatchConfig(config.WatchRulesFile)
+		if err != nil {
+			return nil, err
+		}
+
+		eventLogMaxSize, err := parseFileSize(config.EventLogFileSize)
+		if err != nil {
+			return nil, fmt.Errorf("event log: %w", err)
+		}
+		service.watches, err = newWatchEngine(watchConfig, config.EventLogPath, eventLogMaxSize)
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("Console output watches enabled", "rules", len(watchConfig.Rules), "webhooks", len(watchConfig.Webhooks), "eventLog", config.EventLogPath)
+	}
+
 	return service, nil
 }
+
+// Metrics returns the metrics of the logs service
+func (ls *LogsService) Metrics() []metrics.Metric {
+	return slices.Concat(ls.diskMetrics(), ls.sinkMetrics(), ls.redactor.metrics())
+}
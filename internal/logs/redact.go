@@ -0,0 +1,345 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// This file redacts secrets from console output before it is sent to
+// clients, aggregated or kept in rotated logs
+
+package logs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"github.com/OpenCHAMI/remote-console/internal/metrics"
+)
+
+// RedactedText replaces each redacted secret
+const RedactedText = "[REDACTED]"
+
+// RedactPrivateKey is the built-in rule redacting PEM private key blocks,
+// which span lines
+const RedactPrivateKey = "private-key"
+
+// redactBuiltins are the named rules that can be given instead of a regular
+// expression. A group named secret limits the replaced text to that group.
+var redactBuiltins = map[string]string{
+	"password":          `(?i)\b(?:password|passwd|passphrase|pwd)\s*[:=]\s*(?P<secret>\S+)`,
+	"bearer-token":      `(?i)\bbearer\s+(?P<secret>[A-Za-z0-9._~+/-]+=*)`,
+	"jwt":               `\beyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]*`,
+	"url-credentials":   `\b[a-zA-Z][a-zA-Z0-9+.-]*://[^/\s:@]+:(?P<secret>[^/\s@]+)@`,
+	"ipmitool-password": `\bipmitool\b.*\s-P\s*(?P<secret>\S+)`,
+	"aws-access-key":    `\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`,
+}
+
+var (
+	privateKeyBegin = regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY-----`)
+	privateKeyEnd   = regexp.MustCompile(`-----END [A-Z0-9 ]*PRIVATE KEY-----`)
+)
+
+// redactRule is one single-line redaction rule
+type redactRule struct {
+	name   string // built-in name, or pattern-N for the Nth configured rule
+	re     *regexp.Regexp
+	secret int // index of the secret group, 0 to replace the whole match
+	count  atomic.Int64
+}
+
+// Redactor applies the configured redaction rules. A nil Redactor leaves
+// lines unchanged.
+type Redactor struct {
+	rules       []*redactRule
+	privateKeys bool
+	keyCount    atomic.Int64
+}
+
+// RedactStream redacts the lines of one log in order, following private key
+// blocks across lines. A nil RedactStream leaves lines unchanged.
+type RedactStream struct {
+	redactor     *Redactor
+	inKey        bool
+	keyBegun     bool // a block has begun in this log
+	unmatchedEnd bool // a block ended before any began
+}
+
+// NewRedactor builds a redactor from built-in rule names and regular
+// expressions. It returns nil when no rules are given.
+func NewRedactor(rules []string) (*Redactor, error) {
+	r := &Redactor{}
+	for i, rule := range rules {
+		if rule == "" {
+			continue
+		}
+		if rule == RedactPrivateKey {
+			r.privateKeys = true
+			continue
+		}
+
+		name, pattern := rule, redactBuiltins[rule]
+		if pattern == "" {
+			name, pattern = fmt.Sprintf("pattern-%d", i+1), rule
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction rule %d: %w", i+1, err)
+		}
+		r.rules = append(r.rules, &redactRule{name: name, re: re, secret: max(re.SubexpIndex("secret"), 0)})
+	}
+
+	if len(r.rules) == 0 && !r.privateKeys {
+		return nil, nil
+	}
+	return r, nil
+}
+
+// Stream returns a stream redacting the lines of one log
+func (r *Redactor) Stream() *RedactStream {
+	if r == nil {
+		return nil
+	}
+	return &RedactStream{redactor: r}
+}
+
+// Line redacts the secrets in the next line of the log
+func (s *RedactStream) Line(text string) string {
+	if s == nil {
+		return text
+	}
+	if s.redactor.privateKeys {
+		text = s.redactKeys(text)
+	}
+	for _, rule := range s.redactor.rules {
+		text = rule.apply(text)
+	}
+	return text
+}
+
+// Reset starts the stream over for a log read on its own, inside a private
+// key block when inKey is set
+func (s *RedactStream) Reset(inKey bool) {
+	if s == nil {
+		return
+	}
+	*s = RedactStream{redactor: s.redactor, inKey: inKey, keyBegun: inKey}
+}
+
+// Clone returns a stream with the same rules and state
+func (s *RedactStream) Clone() *RedactStream {
+	if s == nil {
+		return nil
+	}
+	clone := *s
+	return &clone
+}
+
+// UnmatchedKeyEnd reports whether the log ended a private key block before
+// any began in it, as when it continues a block from a log read separately.
+// The lines before the end were then not redacted.
+func (s *RedactStream) UnmatchedKeyEnd() bool {
+	return s != nil && s.unmatchedEnd
+}
+
+// Reader returns a reader of src with each line redacted. A nil Redactor
+// returns src.
+func (r *Redactor) Reader(src io.Reader) io.Reader {
+	if r == nil {
+		return src
+	}
+	return &redactReader{stream: r.Stream(), src: bufio.NewReader(src)}
+}
+
+// redactReader redacts a log a line at a time as it is read
+type redactReader struct {
+	stream  *RedactStream
+	src     *bufio.Reader
+	pending []byte // redacted text not yet read
+	err     error
+}
+
+func (rr *redactReader) Read(p []byte) (int, error) {
+	for len(rr.pending) == 0 {
+		if rr.err != nil {
+			return 0, rr.err
+		}
+		line, err := rr.src.ReadString('\n')
+		rr.err = err
+		if len(line) > 0 {
+			text, newline := strings.CutSuffix(line, "\n")
+			rr.pending = append(rr.pending[:0], rr.stream.Line(text)...)
+			if newline {
+				rr.pending = append(rr.pending, '\n')
+			}
+		}
+	}
+	n := copy(p, rr.pending)
+	rr.pending = rr.pending[n:]
+	return n, nil
+}
+
+// redactKeys replaces the lines between the markers of private key blocks
+func (s *RedactStream) redactKeys(text string) string {
+	var b strings.Builder
+	for {
+		if s.inKey {
+			loc := privateKeyEnd.FindStringIndex(text)
+			if loc == nil {
+				if strings.TrimSpace(text) != "" {
+					b.WriteString(RedactedText)
+				}
+				return b.String()
+			}
+			if loc[0] > 0 {
+				b.WriteString(RedactedText)
+			}
+			b.WriteString(text[loc[0]:loc[1]])
+			text = text[loc[1]:]
+			s.inKey = false
+			continue
+		}
+
+		loc := privateKeyBegin.FindStringIndex(text)
+		if !s.keyBegun {
+			if end := privateKeyEnd.FindStringIndex(text); end != nil && (loc == nil || end[0] < loc[0]) {
+				s.unmatchedEnd = true
+			}
+		}
+		if loc == nil {
+			b.WriteString(text)
+			return b.String()
+		}
+		b.WriteString(text[:loc[1]])
+		text = text[loc[1]:]
+		s.inKey = true
+		s.keyBegun = true
+		s.redactor.keyCount.Add(1)
+	}
+}
+
+func (rule *redactRule) apply(text string) string {
+	matches := rule.re.FindAllStringSubmatchIndex(text, -1)
+	if matches == nil {
+		return text
+	}
+
+	var b strings.Builder
+	last := 0
+	var count int64
+	for _, m := range matches {
+		start, end := m[2*rule.secret], m[2*rule.secret+1]
+		// Text an earlier rule already redacted is left alone
+		if start < 0 || text[start:end] == RedactedText {
+			continue
+		}
+		b.WriteString(text[last:start])
+		b.WriteString(RedactedText)
+		last = end
+		count++
+	}
+	if count == 0 {
+		return text
+	}
+	b.WriteString(text[last:])
+	rule.count.Add(count)
+	return b.String()
+}
+
+// redactFile rewrites a log with its secrets redacted, keeping its mode and
+// modification time. It reports whether anything was redacted.
+func (r *Redactor) redactFile(path string) (bool, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = in.Close()
+	}()
+	info, err := in.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	out, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".redact-*")
+	if err != nil {
+		return false, fmt.Errorf("failed to create redacted log: %w", err)
+	}
+	defer func() {
+		_ = out.Close()
+		_ = os.Remove(out.Name())
+	}()
+
+	stream := r.Stream()
+	br := bufio.NewReader(in)
+	bw := bufio.NewWriter(out)
+	changed := false
+	for {
+		line, readErr := br.ReadString('\n')
+		if len(line) > 0 {
+			text, newline := strings.CutSuffix(line, "\n")
+			redacted := stream.Line(text)
+			changed = changed || redacted != text
+			if _, err := bw.WriteString(redacted); err != nil {
+				return false, err
+			}
+			if newline {
+				if err := bw.WriteByte('\n'); err != nil {
+					return false, err
+				}
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return false, fmt.Errorf("failed to read %q: %w", path, readErr)
+		}
+	}
+	if !changed {
+		return false, nil
+	}
+
+	if err := bw.Flush(); err != nil {
+		return false, err
+	}
+	if err := out.Chmod(info.Mode().Perm()); err != nil {
+		return false, err
+	}
+	if err := out.Close(); err != nil {
+		return false, err
+	}
+	if err := os.Chtimes(out.Name(), info.ModTime(), info.ModTime()); err != nil {
+		return false, err
+	}
+	if err := os.Rename(out.Name(), path); err != nil {
+		return false, fmt.Errorf("failed to replace %q with its redacted copy: %w", path, err)
+	}
+	return true, nil
+}
+
+// Redactor returns the redactor for console output, nil when no rules are configured
+func (ls *LogsService) Redactor() *Redactor {
+	return ls.redactor
+}
+
+func (r *Redactor) metrics() []metrics.Metric {
+	if r == nil {
+		return nil
+	}
+	m := metrics.Metric{
+		Name: "remote_console_redactions_total",
+		Help: "Secrets redacted from console output, by rule.",
+		Type: metrics.TypeCounter,
+	}
+	if r.privateKeys {
+		m.Samples = append(m.Samples, metrics.Sample{Labels: map[string]string{"rule": RedactPrivateKey}, Value: float64(r.keyCount.Load())})
+	}
+	for _, rule := range r.rules {
+		m.Samples = append(m.Samples, metrics.Sample{Labels: map[string]string{"rule": rule.name}, Value: float64(rule.count.Load())})
+	}
+	return []metrics.Metric{m}
+}
@@ -8,26 +8,126 @@
 package logs
 
 import (
-	"bufio"
+	"compress/gzip"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"maps"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
+	"github.com/klauspost/compress/zstd"
+
 	"github.com/OpenCHAMI/remote-console/internal/nodes"
 )
 
-// LogRotate initializes and starts log rotation
+// Compression of rotated console logs
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+// compressionSuffixes maps compression to the suffix of compressed files
+var compressionSuffixes = map[string]string{
+	CompressionGzip: ".gz",
+	CompressionZstd: ".zst",
+}
+
+// Rotation reasons
+const (
+	RotationReasonSize = "size"
+	RotationReasonAge  = "age"
+)
+
+// rotationSettleDelay gives conmand time to reopen its logs after SIGHUP
+// before the rotated files are compressed
+const rotationSettleDelay = 5 * time.Second
+
+// RotationEvent reports a log file moved aside by rotation
+type RotationEvent struct {
+	Node      string    // empty for the aggregation log
+	File      string    // path of the log that was rotated
+	RotatedTo string    // path the log was moved to, after any compression
+	Size      int64     // size of the log when it was rotated
+	Reason    string    // RotationReasonSize or RotationReasonAge
+	Time      time.Time // when the log was rotated
+	Redacted  bool      // secrets were redacted from the rotated log
+}
+
+// rotationTarget is a log file and its rotation policy
+type rotationTarget struct {
+	node        string
+	path        string
+	backupDir   string
+	maxSize     int64
+	maxAge      time.Duration // zero to rotate by size only
+	keep        int
+	compression string
+}
+
+// rotationState tracks what the rotator manages
+type rotationState struct {
+	consoleLogsPath string
+	nodes           []string
+	lastRotation    map[string]time.Time // log path -> when it was last rotated
+	handlers        []func(RotationEvent)
+	settleDelay     time.Duration
+}
+
+// parseFileSize parses a size such as 512, 100k, 5M or 1G
+func parseFileSize(size string) (int64, error) {
+	size = strings.TrimSpace(size)
+	multiplier := int64(1)
+	if size != "" {
+		switch strings.ToUpper(size[len(size)-1:]) {
+		case "K":
+			multiplier = 1 << 10
+		case "M":
+			multiplier = 1 << 20
+		case "G":
+			multiplier = 1 << 30
+		}
+		if multiplier > 1 {
+			size = size[:len(size)-1]
+		}
+	}
+
+	n, err := strconv.ParseInt(size, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid file size %q: must be a positive number with an optional k, M or G suffix", size)
+	}
+	return n * multiplier, nil
+}
+
+// initLogRotate validates the rotation policy and prepares the backup directory
 func (ls *LogsService) initLogRotate() error {
 	slog.Debug("Setting up log rotation")
 	ls.mutex.Lock()
 	defer ls.mutex.Unlock()
 
-	// Set up the 'backups' directory for logrotation to use
+	if _, err := parseFileSize(ls.config.ConsoleLogsFileSize); err != nil {
+		return fmt.Errorf("console logs: %w", err)
+	}
+	if _, err := parseFileSize(ls.config.AggLogsFileSize); err != nil {
+		return fmt.Errorf("aggregation logs: %w", err)
+	}
+	switch ls.config.ConsoleLogsCompression {
+	case "", CompressionNone, CompressionGzip, CompressionZstd:
+	default:
+		return fmt.Errorf("invalid console log compression %q: must be %q, %q or %q", ls.config.ConsoleLogsCompression, CompressionNone, CompressionGzip, CompressionZstd)
+	}
+
+	ls.rotation.lastRotation = make(map[string]time.Time)
+	ls.rotation.settleDelay = rotationSettleDelay
+
+	// Set up the 'backups' directory for rotated logs
 	slog.Info("Ensuring console log backup directory present", "path", ls.config.ConsoleLogsBackupPath)
 	err := os.MkdirAll(ls.config.ConsoleLogsBackupPath, 0755)
 	if err != nil {
@@ -37,226 +137,384 @@ func (ls *LogsService) initLogRotate() error {
 	return nil
 }
 
-func writeConfigEntry(lrf *os.File, fileName string, oldDir string, numRotate int, fileSize string) error {
-	var b strings.Builder
-	fmt.Fprintf(&b, "%s { \n", fileName)
-	fmt.Fprintln(&b, "  nocompress")
-	fmt.Fprintln(&b, "  missingok")
-	fmt.Fprintln(&b, "  nocopytruncate")
-	fmt.Fprintln(&b, "  nocreate")
-	fmt.Fprintln(&b, "  nodelaycompress")
-	fmt.Fprintln(&b, "  nomail")
-	fmt.Fprintln(&b, "  notifempty")
-	fmt.Fprintf(&b, "  olddir %s\n", oldDir)
-	fmt.Fprintf(&b, "  rotate %d\n", numRotate)
-	fmt.Fprintf(&b, "  size=%s\n", fileSize)
-	fmt.Fprintln(&b, "}")
-
-	_, err := lrf.WriteString(b.String())
-
-	return err
+// UpdateRotationTargets sets the consoles whose logs are rotated
+func (ls *LogsService) UpdateRotationTargets(consoleLogsPath string, nodes map[string]*nodes.NodeConsoleInfo) {
+	ls.mutex.Lock()
+	defer ls.mutex.Unlock()
+
+	ls.rotation.consoleLogsPath = consoleLogsPath
+	ls.rotation.nodes = slices.Sorted(maps.Keys(nodes))
+	slog.Info("Updated log rotation targets", "nodeCount", len(nodes))
 }
 
-// UpdateLogRotateConf updates the log rotation configuration file
-func (ls *LogsService) UpdateLogRotateConf(consoleLogsPath string, nodes map[string]*nodes.NodeConsoleInfo) (err error) {
+// OnRotation registers a handler called after each log file is rotated
+func (ls *LogsService) OnRotation(handler func(RotationEvent)) {
 	ls.mutex.Lock()
 	defer ls.mutex.Unlock()
+	ls.rotation.handlers = append(ls.rotation.handlers, handler)
+}
 
-	// Open the file for writing
-	slog.Debug("Opening log rotation configuration file", "path", ls.config.LogRotateFilePath)
-	lrf, err := os.Create(ls.config.LogRotateFilePath)
-	if err != nil {
-		return fmt.Errorf("unable to create log rotation config file %q: %w", ls.config.LogRotateFilePath, err)
+// LogRotate rotates the console logs and the aggregation log that are due.
+// reopenConsoles is called once after console logs are moved aside so conmand
+// reopens them, and the rotated console logs are compressed after that. The
+// service is only locked while files are moved, so integrity checks and the
+// disk budget aren't held up by the settle delay and compression.
+func (ls *LogsService) LogRotate(reopenConsoles func() error) {
+	if !ls.config.LogRotateEnabled {
+		return
 	}
-	defer func() {
-		if cerr := lrf.Close(); cerr != nil && err == nil {
-			err = fmt.Errorf("failed to close log rotation config file %q: %w", ls.config.LogRotateFilePath, cerr)
+	ls.rotateMutex.Lock()
+	defer ls.rotateMutex.Unlock()
+
+	now := time.Now()
+	consoleSize, _ := parseFileSize(ls.config.ConsoleLogsFileSize)
+	aggSize, _ := parseFileSize(ls.config.AggLogsFileSize)
+
+	ls.mutex.Lock()
+	var events []RotationEvent
+	for _, xname := range ls.rotation.nodes {
+		target := rotationTarget{
+			node:        xname,
+			path:        filepath.Join(ls.rotation.consoleLogsPath, fmt.Sprintf("console.%s", xname)),
+			backupDir:   ls.config.ConsoleLogsBackupPath,
+			maxSize:     consoleSize,
+			maxAge:      time.Duration(ls.config.ConsoleLogsMaxAgeHours) * time.Hour,
+			keep:        ls.config.ConsoleLogsNumRotate,
+			compression: ls.config.ConsoleLogsCompression,
 		}
-	}()
+		event, rotated, err := ls.rotateFile(target, now)
+		if err != nil {
+			slog.Error("Failed to rotate console log", "xname", xname, "error", err)
+		}
+		if rotated {
+			events = append(events, event)
+		}
+	}
+	settleDelay := ls.rotation.settleDelay
+	handlers := slices.Clone(ls.rotation.handlers)
+	ls.mutex.Unlock()
+
+	if len(events) > 0 {
+		slog.Info("Console logs rotated, signaling conmand", "count", len(events))
+		if reopenConsoles != nil {
+			if err := reopenConsoles(); err != nil {
+				slog.Error("Failed to have conmand reopen console logs", "error", err)
+			}
+		}
+
+		// conmand keeps writing to the moved files until it has reopened them
+		compression := ls.config.ConsoleLogsCompression
+		compressed := compression != "" && compression != CompressionNone
+		redact := ls.config.RedactStoredLogs && ls.redactor != nil
+		if compressed || redact || ls.integrity != nil {
+			time.Sleep(settleDelay)
+		}
+		if redact {
+			for i := range events {
+				if events[i].RotatedTo == "" {
+					continue
+				}
+				redacted, err := ls.redactor.redactFile(events[i].RotatedTo)
+				if err != nil {
+					slog.Error("Failed to redact rotated console log", "file", events[i].RotatedTo, "error", err)
+					continue
+				}
+				events[i].Redacted = redacted
+			}
+		}
+		if compressed {
+			for i := range events {
+				path, err := compressLogFile(events[i].RotatedTo, compression)
+				if err != nil {
+					slog.Error("Failed to compress rotated console log", "file", events[i].RotatedTo, "error", err)
+					continue
+				}
+				events[i].RotatedTo = path
+			}
+		}
+
+		if ls.integrity != nil {
+			for _, event := range events {
+				ls.integrity.seal(event)
+			}
+		}
+	}
+
+	ls.conAggMutex.Lock()
+	aggLogFile := ls.conAggLogFile
+	ls.conAggMutex.Unlock()
 
-	slog.Info("Writing log rotation configuration file", "nodeCount", len(nodes))
+	if aggLogFile != "" {
+		target := rotationTarget{
+			path:      aggLogFile,
+			backupDir: filepath.Dir(aggLogFile),
+			maxSize:   aggSize,
+			keep:      ls.config.AggLogsNumRotate,
+		}
+		ls.mutex.Lock()
+		event, rotated, err := ls.rotateFile(target, now)
+		ls.mutex.Unlock()
+		if err != nil {
+			slog.Error("Failed to rotate aggregation log", "file", aggLogFile, "error", err)
+		}
+		if rotated {
+			// Reopen the aggregation log file after rotation
+			ls.reopenAggLog()
+			events = append(events, event)
+		}
+	}
 
-	// Write out the contents of the file
-	if _, err := fmt.Fprintln(lrf, "# Auto-generated conman log rotation configuration file."); err != nil {
-		return fmt.Errorf("failed to write log rotation header: %w", err)
+	if len(events) == 0 {
+		slog.Debug("No log files needed rotation")
+	}
+	for _, event := range events {
+		slog.Info("Log file rotated", "xname", event.Node, "file", event.File, "rotatedTo", event.RotatedTo, "size", event.Size, "reason", event.Reason)
+		for _, handler := range handlers {
+			handler(event)
+		}
 	}
+}
 
-	// Add the aggregation file
-	if ls.conAggLogFile != "" {
-		conAggLogDir := filepath.Dir(ls.conAggLogFile)
-		if len(conAggLogDir) > 0 {
-			if err := writeConfigEntry(lrf, ls.conAggLogFile, conAggLogDir, ls.config.AggLogsNumRotate, ls.config.AggLogsFileSize); err != nil {
-				return fmt.Errorf("failed to write aggregation log rotate entry for %q: %w", ls.conAggLogFile, err)
+// rotateFile moves a log aside when it is due, shifting its older rotations
+// up by one and dropping those beyond the number kept
+func (ls *LogsService) rotateFile(t rotationTarget, now time.Time) (RotationEvent, bool, error) {
+	stat, err := os.Stat(t.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return RotationEvent{}, false, nil
+	}
+	if err != nil {
+		return RotationEvent{}, false, err
+	}
+
+	backups, err := rotatedFiles(t.backupDir, filepath.Base(t.path))
+	if err != nil {
+		return RotationEvent{}, false, err
+	}
+
+	last, ok := ls.rotation.lastRotation[t.path]
+	if !ok {
+		// The newest rotated file was last written when it was rotated
+		last = now
+		if len(backups) > 0 {
+			if info, err := os.Stat(backups[0].Path); err == nil {
+				last = info.ModTime()
 			}
-		} else {
-			return fmt.Errorf("invalid aggregation file name/dir for log rotation (file=%q dir=%q)", ls.conAggLogFile, conAggLogDir)
 		}
+		ls.rotation.lastRotation[t.path] = last
+	}
+
+	// Empty logs are never rotated
+	var reason string
+	switch {
+	case stat.Size() == 0:
+		return RotationEvent{}, false, nil
+	case stat.Size() >= t.maxSize:
+		reason = RotationReasonSize
+	case t.maxAge > 0 && now.Sub(last) >= t.maxAge:
+		reason = RotationReasonAge
+	default:
+		return RotationEvent{}, false, nil
 	}
 
-	// Add all nodes
-	for _, cni := range nodes {
-		id := cni.ID
-		fn := filepath.Join(consoleLogsPath, fmt.Sprintf("console.%s", id))
-		if err := writeConfigEntry(lrf, fn, ls.config.ConsoleLogsBackupPath, ls.config.ConsoleLogsNumRotate, ls.config.ConsoleLogsFileSize); err != nil {
-			return fmt.Errorf("failed to write console log rotate entry for %q: %w", fn, err)
+	// Shift the older rotations up, newest last so nothing is overwritten
+	for i := len(backups) - 1; i >= 0; i-- {
+		b := backups[i]
+		if b.Rotation >= t.keep {
+			if err := os.Remove(b.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return RotationEvent{}, false, fmt.Errorf("failed to remove old rotation %q: %w", b.Path, err)
+			}
+			forgetTimeRange(b.Path)
+			if ls.integrity != nil {
+				ls.integrity.deleted(t.node, b.Rotation, b.Path)
+			}
+			continue
+		}
+		name := filepath.Join(t.backupDir, fmt.Sprintf("%s.%d%s", filepath.Base(t.path), b.Rotation+1, compressionSuffixes[compressionOf(b.Path)]))
+		if err := os.Rename(b.Path, name); err != nil {
+			return RotationEvent{}, false, fmt.Errorf("failed to shift rotation %q: %w", b.Path, err)
 		}
+		moveTimeRange(b.Path, name)
 	}
 
-	if _, err := fmt.Fprintln(lrf, ""); err != nil {
-		return fmt.Errorf("failed to write log rotation footer: %w", err)
+	event := RotationEvent{Node: t.node, File: t.path, Size: stat.Size(), Reason: reason, Time: now}
+	ls.rotation.lastRotation[t.path] = now
+
+	if t.keep < 1 {
+		if err := os.Remove(t.path); err != nil {
+			return RotationEvent{}, false, fmt.Errorf("failed to remove log %q: %w", t.path, err)
+		}
+		forgetTimeRange(t.path)
+		return event, true, nil
 	}
 
-	slog.Debug("Completed writing log rotation configuration file")
-	return nil
+	event.RotatedTo = filepath.Join(t.backupDir, fmt.Sprintf("%s.1", filepath.Base(t.path)))
+	if err := moveFile(t.path, event.RotatedTo); err != nil {
+		return RotationEvent{}, false, err
+	}
+	return event, true, nil
 }
 
-func parseTimestamp(consoleLogsPath string, conAggLogFile string, line string) (string, time.Time, bool, bool) {
-	var nodeName string
-	var fd time.Time
-	isCon := false
-	isAgg := false
-
-	filePrefix := filepath.Join(consoleLogsPath, "console.")
-	timeStampStr := ""
-	pos := strings.Index(line, filePrefix)
-	nodeStPos := 0
-	if pos != -1 {
-		nodeStPos = pos + len(filePrefix)
-		posQ2 := strings.Index(line[nodeStPos:], "\"")
-		if posQ2 == -1 {
-			slog.Error("Unexpected file format - expected quote to close filename")
-			return nodeName, fd, isCon, isAgg
+// rotatedFiles lists the rotations of a log in dir, newest first
+func rotatedFiles(dir, base string) ([]ConsoleLogFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read log backup directory: %w", err)
+	}
+
+	var files []ConsoleLogFile
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
 		}
-		posQ2 += nodeStPos
-		nodeName = line[nodeStPos:posQ2]
-		timeStampStr = line[posQ2+2:]
-		isCon = true
-	} else {
-		if conAggLogFile == "" {
-			return nodeName, fd, isCon, isAgg
+		if rotation, compressed, ok := parseRotatedName(entry.Name(), base); ok {
+			files = append(files, ConsoleLogFile{
+				Path:       filepath.Join(dir, entry.Name()),
+				Rotation:   rotation,
+				Compressed: compressed,
+			})
 		}
+	}
+	slices.SortFunc(files, func(a, b ConsoleLogFile) int { return a.Rotation - b.Rotation })
+	return files, nil
+}
 
-		pos = strings.Index(line, conAggLogFile)
-		if pos == -1 {
-			return nodeName, fd, isCon, isAgg
+// parseRotatedName recognizes <base>.<N> with an optional compression suffix
+func parseRotatedName(name, base string) (int, bool, bool) {
+	suffix, ok := strings.CutPrefix(name, base+".")
+	if !ok {
+		return 0, false, false
+	}
+
+	compressed := false
+	for _, ext := range compressionSuffixes {
+		if trimmed, found := strings.CutSuffix(suffix, ext); found {
+			suffix, compressed = trimmed, true
+			break
 		}
-		nodeName = "consoleAgg.log"
-		isAgg = true
-		timeStampStr = line[len(conAggLogFile)+pos+2:]
 	}
 
-	var year, month, day, hour, min, sec int
-	_, err := fmt.Sscanf(timeStampStr, "%d-%d-%d-%d:%d:%d", &year, &month, &day, &hour, &min, &sec)
-	if err != nil {
-		slog.Error("Error parsing timestamp", "timestamp", timeStampStr, "error", err)
-		return nodeName, fd, false, false
+	rotation, err := strconv.Atoi(suffix)
+	if err != nil || rotation < 1 {
+		return 0, false, false
 	}
-	fd = time.Date(year, time.Month(month), day, hour, min, sec, 0, time.Local)
+	return rotation, compressed, true
+}
 
-	return nodeName, fd, isCon, isAgg
+// compressionOf returns the compression of a log file from its name
+func compressionOf(path string) string {
+	for compression, ext := range compressionSuffixes {
+		if strings.HasSuffix(path, ext) {
+			return compression
+		}
+	}
+	return CompressionNone
 }
 
-func readLogRotTimestamps(config LogConfig, consoleLogsPath string, conAggLogFile string, fileStamp map[string]time.Time) (conChanged, aggChanged bool) {
-	conChanged = false
-	aggChanged = false
+// moveFile renames a live log into the backup directory. When that is on
+// another filesystem the log is copied and then truncated in place, as the
+// writer keeps appending to the file it has open. Lines written in the
+// moment between the copy and the truncation are lost, so the backup
+// directory is best kept on the same filesystem as the logs.
+func moveFile(from, to string) error {
+	err := os.Rename(from, to)
+	if !errors.Is(err, syscall.EXDEV) {
+		if err != nil {
+			return fmt.Errorf("failed to move %q to %q: %w", from, to, err)
+		}
+		moveTimeRange(from, to)
+		return nil
+	}
+	forgetTimeRange(from)
+	forgetTimeRange(to)
+	return copyTruncateFile(from, to)
+}
 
-	sf, err := os.Open(config.LogRotateStateFilePath)
+// copyTruncateFile copies a log up to its end and then empties it
+func copyTruncateFile(from, to string) (err error) {
+	src, err := os.OpenFile(from, os.O_RDWR, 0)
 	if err != nil {
-		slog.Error("Unable to open log rotation state file", "path", config.LogRotateStateFilePath, "error", err)
-		return false, false
+		return fmt.Errorf("failed to open %q: %w", from, err)
 	}
 	defer func() {
-		if err := sf.Close(); err != nil {
-			slog.Warn("Failed to close log rotation state file", "path", config.LogRotateStateFilePath, "error", err)
-		}
+		_ = src.Close()
 	}()
 
-	er := bufio.NewReader(sf)
-
-	// Read the logrotate state -- version 2 line
-	_, err = er.ReadString('\n')
+	dst, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
 	if err != nil {
-		slog.Error("Unable to read log rotation state file", "path", config.LogRotateStateFilePath, "error", err)
-		return false, false
+		return fmt.Errorf("failed to create %q: %w", to, err)
 	}
-
-	for {
-		line, err := er.ReadString('\n')
-		if err != nil {
-			break
+	defer func() {
+		if cerr := dst.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("failed to close %q: %w", to, cerr)
 		}
+	}()
 
-		if fileName, fd, isCon, isAgg := parseTimestamp(consoleLogsPath, conAggLogFile, line); isCon || isAgg {
-			if _, ok := fileStamp[fileName]; ok {
-				if fileStamp[fileName] != fd {
-					slog.Debug("Log file rotated", "file", fileName)
-					fileStamp[fileName] = fd
-					if isCon {
-						conChanged = true
-					} else {
-						aggChanged = true
-					}
-				}
-			} else {
-				slog.Debug("New log file detected", "file", fileName)
-				fileStamp[fileName] = fd
-				if isCon {
-					conChanged = true
-				} else {
-					aggChanged = true
-				}
-			}
-		}
+	if _, err := io.Copy(dst, src); err != nil {
+		return fmt.Errorf("failed to copy %q to %q: %w", from, to, err)
 	}
-
-	return conChanged, aggChanged
+	if err := src.Truncate(0); err != nil {
+		return fmt.Errorf("failed to truncate %q after copying: %w", from, err)
+	}
+	return nil
 }
 
-// LogRotate performs a log rotation check and rotation if needed
-func (ls *LogsService) LogRotate(consoleLogsPath string) bool {
-	ls.mutex.Lock()
-	defer ls.mutex.Unlock()
+// compressLogFile replaces a rotated log with a compressed copy and returns its path
+func compressLogFile(path, compression string) (string, error) {
+	compressedPath := path + compressionSuffixes[compression]
+	tmpPath := compressedPath + ".tmp"
 
-	consoleLogChanged := false
-	if ls.logRotateFileStamp == nil {
-		ls.logRotateFileStamp = make(map[string]time.Time)
+	if err := writeCompressed(path, tmpPath, compression); err != nil {
+		_ = os.Remove(tmpPath)
+		return path, err
 	}
-	readLogRotTimestamps(ls.config, consoleLogsPath, ls.conAggLogFile, ls.logRotateFileStamp)
-
-	if ls.config.LogRotateEnabled {
-		consoleLogChanged = ls.rotateLogsOnce(ls.config, consoleLogsPath)
+	if err := os.Rename(tmpPath, compressedPath); err != nil {
+		_ = os.Remove(tmpPath)
+		return path, fmt.Errorf("failed to rename %q: %w", tmpPath, err)
 	}
-
-	return consoleLogChanged
+	if err := os.Remove(path); err != nil {
+		return compressedPath, fmt.Errorf("failed to remove %q after compressing: %w", path, err)
+	}
+	forgetTimeRange(path)
+	return compressedPath, nil
 }
 
-func (ls *LogsService) rotateLogsOnce(config LogConfig, consoleLogsPath string) bool {
-	conChanged := false
-	aggChanged := false
-	slog.Info("Starting logrotate")
-	cmd := exec.Command("logrotate", "-s", config.LogRotateStateFilePath, config.LogRotateFilePath)
-	exitCode := -1
-	if err := cmd.Run(); err != nil {
-		var ee *exec.ExitError
-		if errors.As(err, &ee) {
-			exitCode = ee.ExitCode()
-			slog.Warn("Logrotate exited with error", "exitCode", exitCode, "error", ee)
-		}
-	} else {
-		exitCode = 0
+func writeCompressed(from, to, compression string) (err error) {
+	src, err := os.Open(from)
+	if err != nil {
+		return fmt.Errorf("failed to open %q: %w", from, err)
 	}
-	slog.Info("Log rotation completed", "exitCode", exitCode)
+	defer func() {
+		_ = src.Close()
+	}()
 
-	if conChanged, aggChanged = readLogRotTimestamps(config, consoleLogsPath, ls.conAggLogFile, ls.logRotateFileStamp); aggChanged {
-		time.Sleep(5 * time.Second)
+	dst, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create %q: %w", to, err)
+	}
+	defer func() {
+		if cerr := dst.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("failed to close %q: %w", to, cerr)
+		}
+	}()
 
-		if aggChanged {
-			// Reopen the aggregation log file after rotation
-			ls.reopenAggLog()
+	var zw io.WriteCloser
+	switch compression {
+	case CompressionGzip:
+		zw = gzip.NewWriter(dst)
+	case CompressionZstd:
+		if zw, err = zstd.NewWriter(dst); err != nil {
+			return fmt.Errorf("failed to start zstd compression: %w", err)
 		}
-	} else {
-		slog.Debug("No log files changed with logrotate")
+	default:
+		return fmt.Errorf("unknown compression %q", compression)
 	}
 
-	return conChanged
+	if _, err := io.Copy(zw, src); err != nil {
+		_ = zw.Close()
+		return fmt.Errorf("failed to compress %q: %w", from, err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to finish compressing %q: %w", from, err)
+	}
+	return nil
 }
@@ -5,9 +5,10 @@
 package logs
 
 import (
-	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -29,245 +30,325 @@ func TestInitNewLogsService(t *testing.T) {
 	require.NoError(t, err, "Backup directory should exist")
 }
 
-func TestUpdateLogRotateConf(t *testing.T) {
-	tempDir := t.TempDir()
-
+func TestInitNewLogsServiceInvalidRotation(t *testing.T) {
 	config := DefaultLogConfig()
-	config.LogRotateFilePath = filepath.Join(tempDir, "logrotate.test")
-	config.AggLogsPath = tempDir
-	config.ConsoleLogsBackupPath = filepath.Join(tempDir, "conman.old")
+	config.ConsoleLogsBackupPath = filepath.Join(t.TempDir(), "conman.old")
+	config.ConsoleLogsFileSize = "5X"
+	_, err := NewLogsService(config)
+	require.Error(t, err)
 
-	service, err := NewLogsService(config)
-	require.NoError(t, err)
-	service.conAggLogFile = "/tmp/consoleAgg-test.log"
+	config = DefaultLogConfig()
+	config.ConsoleLogsBackupPath = filepath.Join(t.TempDir(), "conman.old")
+	config.ConsoleLogsCompression = "bzip2"
+	_, err = NewLogsService(config)
+	require.Error(t, err)
+}
+
+func TestApplyDeprecated(t *testing.T) {
+	config := DefaultLogConfig()
+	config.LogRotateFilePath = "/tmp/logrotate.conman"
+	config.LogRotateStateFilePath = "/tmp/rot_conman.state"
+	config.ApplyDeprecated()
+	require.Equal(t, CompressionNone, config.ConsoleLogsCompression)
+
+	// The old compression option means gzip unless another is set
+	config.ConsoleLogsCompress = true
+	config.ApplyDeprecated()
+	require.Equal(t, CompressionGzip, config.ConsoleLogsCompression)
+	config.ConsoleLogsCompression = CompressionZstd
+	config.ApplyDeprecated()
+	require.Equal(t, CompressionZstd, config.ConsoleLogsCompression)
+}
 
-	nodes := map[string]*nodes.NodeConsoleInfo{
-		"x0c0s1b0": {ID: "x0c0s1b0"},
-		"x0c0s1b1": {ID: "x0c0s1b1"},
+func TestParseFileSize(t *testing.T) {
+	tests := []struct {
+		size     string
+		expected int64
+	}{
+		{"512", 512},
+		{"2K", 2 << 10},
+		{"100k", 100 << 10},
+		{"5M", 5 << 20},
+		{"1G", 1 << 30},
+	}
+	for _, tt := range tests {
+		size, err := parseFileSize(tt.size)
+		require.NoError(t, err, tt.size)
+		require.Equal(t, tt.expected, size, tt.size)
 	}
 
-	require.NoError(t, service.UpdateLogRotateConf("/var/log/conman", nodes))
-
-	// Verify that the log rotation configuration file was created
-	data, err := os.ReadFile(config.LogRotateFilePath)
-	require.NoError(t, err, "Log rotation config file should exist")
-
-	logConfigContents := string(data)
-
-	// Verify header
-	require.Contains(t, logConfigContents, "# Auto-generated conman log rotation configuration file.")
-
-	// Verify aggregation log entry
-	aggLogEntry := `/tmp/consoleAgg-test.log { 
-  nocompress
-  missingok
-  nocopytruncate
-  nocreate
-  nodelaycompress
-  nomail
-  notifempty
-  olddir /tmp
-  rotate 1
-  size=20M
-}`
-	require.Contains(t, logConfigContents, aggLogEntry, "Aggregation log entry should be present")
-
-	// Verify console log entry for x0c0s1b0
-	consoleLogEntry0 := fmt.Sprintf(`/var/log/conman/console.x0c0s1b0 { 
-  nocompress
-  missingok
-  nocopytruncate
-  nocreate
-  nodelaycompress
-  nomail
-  notifempty
-  olddir %s
-  rotate 2
-  size=5M
-}`, config.ConsoleLogsBackupPath)
-	require.Contains(t, logConfigContents, consoleLogEntry0, "Console log entry for x0c0s1b0 should be present")
-
-	// Verify console log entry for x0c0s1b1
-	consoleLogEntry1 := fmt.Sprintf(`/var/log/conman/console.x0c0s1b1 { 
-  nocompress
-  missingok
-  nocopytruncate
-  nocreate
-  nodelaycompress
-  nomail
-  notifempty
-  olddir %s
-  rotate 2
-  size=5M
-}`, config.ConsoleLogsBackupPath)
-	require.Contains(t, logConfigContents, consoleLogEntry1, "Console log entry for x0c0s1b1 should be present")
+	for _, size := range []string{"", "M", "0", "-1K", "5X"} {
+		_, err := parseFileSize(size)
+		require.Error(t, err, size)
+	}
 }
 
-func TestReadLogRotTimestamps(t *testing.T) {
-	// Prepare a temporary log rotation timestamp file
+// newRotationService returns a logs service rotating the console logs of
+// nodes in the returned logs directory
+func newRotationService(t *testing.T, config LogConfig, xnames ...string) (*LogsService, string) {
 	tempDir := t.TempDir()
-	logRotTimestampsFile := filepath.Join(tempDir, "logrot.timestamps")
-
-	console1Timestamp := time.Date(2023, 11, 14, 12, 26, 40, 0, time.Local)
-	console2Timestamp := time.Date(2023, 11, 14, 12, 34, 60, 0, time.Local)
-
-	content := fmt.Sprintf(`# Log rotation timestamps
-"/var/log/conman/console.x0c0s1b0" %s
-"/var/log/conman/console.x0c0s1b1" %s
-`, console1Timestamp.Format("2006-01-02-15:04:05"), console2Timestamp.Format("2006-01-02-15:04:05"))
-
-	config := DefaultLogConfig()
-	config.LogRotateStateFilePath = logRotTimestampsFile
-	config.LogRotateFilePath = filepath.Join(tempDir, "logrotate.test")
+	logsPath := filepath.Join(tempDir, "conman")
+	require.NoError(t, os.MkdirAll(logsPath, 0755))
 
-	err := os.WriteFile(logRotTimestampsFile, []byte(content), 0600)
+	config.ConsoleLogsBackupPath = filepath.Join(tempDir, "conman.old")
+	service, err := NewLogsService(config)
 	require.NoError(t, err)
+	service.rotation.settleDelay = 0
 
-	fileStamp := make(map[string]time.Time)
-	// Read the timestamps
-	conChanged, aggChanged := readLogRotTimestamps(config, "/var/log/conman", "", fileStamp)
-	require.True(t, conChanged, "Console logs should show changes")
-	require.False(t, aggChanged, "Aggregation log should not show changes")
-
-	// Verify the contents
-	expected := map[string]time.Time{
-		"x0c0s1b0": console1Timestamp,
-		"x0c0s1b1": console2Timestamp,
+	targets := make(map[string]*nodes.NodeConsoleInfo)
+	for _, xname := range xnames {
+		targets[xname] = &nodes.NodeConsoleInfo{ID: xname}
 	}
-
-	require.Equal(t, expected, fileStamp, "Timestamps should match expected values")
+	service.UpdateRotationTargets(logsPath, targets)
+	return service, logsPath
 }
 
-func TestRotateLogsOnce(t *testing.T) {
-	tempDir := t.TempDir()
-	logRotateStateFilePath := filepath.Join(tempDir, "logrot.state")
-	logBackupDir := filepath.Join(tempDir, "logbackups")
-	err := os.MkdirAll(logBackupDir, 0755)
+func readLogFile(t *testing.T, f ConsoleLogFile) string {
+	r, err := f.Open()
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, r.Close())
+	}()
+	data, err := io.ReadAll(r)
 	require.NoError(t, err)
+	return string(data)
+}
 
+func TestLogRotate(t *testing.T) {
 	config := DefaultLogConfig()
-	config.LogRotateStateFilePath = logRotateStateFilePath
-	config.LogRotateFilePath = filepath.Join(tempDir, "logrotate.test")
-	config.ConsoleLogsBackupPath = logBackupDir
-	config.LogRotateEnabled = true
 	config.ConsoleLogsFileSize = "1K"
-
-	nodes := map[string]*nodes.NodeConsoleInfo{
-		"x0c0s1b0": {ID: "x0c0s1b0"},
-		"x0c0s1b1": {ID: "x0c0s1b1"},
+	config.ConsoleLogsNumRotate = 2
+	config.SearchIndexMaxLines = 0
+	service, logsPath := newRotationService(t, config, "x0c0s1b0", "x0c0s1b1", "x0c0s1b2")
+
+	var events []RotationEvent
+	service.OnRotation(func(event RotationEvent) { events = append(events, event) })
+	reopened := 0
+	reopen := func() error {
+		reopened++
+		return nil
 	}
-	service, err := NewLogsService(config)
-	require.NoError(t, err)
 
-	require.NoError(t, service.UpdateLogRotateConf(tempDir, nodes))
+	// Logs under the size limit, empty or missing are left alone
+	small := filepath.Join(logsPath, "console.x0c0s1b0")
+	require.NoError(t, os.WriteFile(small, []byte("small log\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(logsPath, "console.x0c0s1b1"), nil, 0600))
+	service.LogRotate(reopen)
+	require.Empty(t, events)
+	require.Zero(t, reopened)
+
+	// Each rotation shifts the older logs and drops those beyond the number kept
+	large := filepath.Join(logsPath, "console.x0c0s1b1")
+	for i, fill := range []string{"a", "b", "c"} {
+		require.NoError(t, os.WriteFile(large, []byte(strings.Repeat(fill, 2048)), 0600))
+		service.LogRotate(reopen)
+		require.Equal(t, i+1, reopened)
+	}
 
-	// Perform log rotation check
-	service.logRotateFileStamp = make(map[string]time.Time)
-	changed := service.rotateLogsOnce(config, tempDir)
-	// TODO This is the current behavior, but seems wrong - should be false if no files exist?
-	require.True(t, changed, "Change should be detected")
+	require.Len(t, events, 3)
+	require.Equal(t, "x0c0s1b1", events[2].Node)
+	require.Equal(t, large, events[2].File)
+	require.Equal(t, filepath.Join(service.config.ConsoleLogsBackupPath, "console.x0c0s1b1.1"), events[2].RotatedTo)
+	require.Equal(t, int64(2048), events[2].Size)
+	require.Equal(t, RotationReasonSize, events[2].Reason)
 
-	changed = service.rotateLogsOnce(config, tempDir)
-	require.False(t, changed, "Nothing should have changed")
+	_, err := os.Stat(large)
+	require.ErrorIs(t, err, os.ErrNotExist)
+	_, err = os.Stat(small)
+	require.NoError(t, err)
 
-	// Now create some log files to trigger rotation
-	consoleLog1 := filepath.Join(tempDir, "console.x0c0s1b0")
-	consoleLog2 := filepath.Join(tempDir, "console.x0c0s1b1")
+	files, err := service.ConsoleLogFiles(logsPath, "x0c0s1b1")
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+	require.Equal(t, 2, files[0].Rotation)
+	require.Equal(t, strings.Repeat("b", 2048), readLogFile(t, files[0]))
+	require.Equal(t, 1, files[1].Rotation)
+	require.Equal(t, strings.Repeat("c", 2048), readLogFile(t, files[1]))
+
+	// Rotation does nothing when disabled
+	service.config.LogRotateEnabled = false
+	require.NoError(t, os.WriteFile(large, []byte(strings.Repeat("d", 2048)), 0600))
+	service.LogRotate(reopen)
+	require.Len(t, events, 3)
+}
 
-	// Writ over 1KB of data to each log file
-	largeData := make([]byte, 2048)
-	for i := range largeData {
-		largeData[i] = 'A'
+func TestLogRotateMovesTimeRanges(t *testing.T) {
+	config := DefaultLogConfig()
+	config.ConsoleLogsFileSize = "1K"
+	config.ConsoleLogsNumRotate = 1
+	config.LogsRetentionHours = 24
+	config.SearchIndexMaxLines = 0
+	service, logsPath := newRotationService(t, config, "x0c0s1b0")
+	live := filepath.Join(logsPath, "console.x0c0s1b0")
+	rotated := filepath.Join(service.config.ConsoleLogsBackupPath, "console.x0c0s1b0.1")
+	cached := func(path string) bool {
+		timeRanges.Lock()
+		defer timeRanges.Unlock()
+		_, ok := timeRanges.byPath[path]
+		return ok
 	}
 
-	err = os.WriteFile(consoleLog1, largeData, 0600)
-	require.NoError(t, err)
-	err = os.WriteFile(consoleLog2, largeData, 0600)
-	require.NoError(t, err)
-
-	// Update timestamps to force rotation
-	service.logRotateFileStamp["x0c0s1b0"] = time.Now().Add(-2 * time.Hour)
-	service.logRotateFileStamp["x0c0s1b1"] = time.Now().Add(-2 * time.Hour)
+	// The time range of the live log follows it into the backup directory,
+	// replacing that of the rotation it pushes out
+	for _, fill := range []string{"a", "b"} {
+		require.NoError(t, os.WriteFile(live, []byte("2026-03-04 05:00:00 UTC "+strings.Repeat(fill, 2048)+"\n"), 0600))
+		_, err := ConsoleLogFile{Path: live}.Info()
+		require.NoError(t, err)
+		service.LogRotate(nil)
+		require.False(t, cached(live))
+		require.True(t, cached(rotated))
+	}
 
-	// Perform log rotation check again
-	changed = service.rotateLogsOnce(config, tempDir)
-	require.True(t, changed, "Log rotations should be detected")
+	// It is dropped with the file when the file expires
+	expired := time.Now().Add(-48 * time.Hour)
+	require.NoError(t, os.Chtimes(rotated, expired, expired))
+	service.EnforceDiskBudget()
+	require.False(t, cached(rotated))
+}
 
-	// Verify that the log files have been rotated (moved to backup directory)
-	backupFiles, err := os.ReadDir(logBackupDir)
-	fmt.Println(backupFiles)
-	require.NoError(t, err)
+func TestLogRotateAge(t *testing.T) {
+	config := DefaultLogConfig()
+	config.ConsoleLogsMaxAgeHours = 1
+	config.SearchIndexMaxLines = 0
+	service, logsPath := newRotationService(t, config, "x0c0s1b0")
+
+	var events []RotationEvent
+	service.OnRotation(func(event RotationEvent) { events = append(events, event) })
+
+	log := filepath.Join(logsPath, "console.x0c0s1b0")
+	require.NoError(t, os.WriteFile(log, []byte("boot\n"), 0600))
+	service.LogRotate(nil)
+	require.Empty(t, events)
+
+	// A log that has not been rotated for longer than the maximum age is rotated
+	service.rotation.lastRotation[log] = time.Now().Add(-2 * time.Hour)
+	service.LogRotate(nil)
+	require.Len(t, events, 1)
+	require.Equal(t, RotationReasonAge, events[0].Reason)
+}
 
-	var found1, found2 bool
-	for _, f := range backupFiles {
-		if f.Name() == "console.x0c0s1b0.1" {
-			found1 = true
-		}
-		if f.Name() == "console.x0c0s1b1.1" {
-			found2 = true
-		}
+func TestLogRotateCompression(t *testing.T) {
+	for _, compression := range []string{CompressionGzip, CompressionZstd} {
+		t.Run(compression, func(t *testing.T) {
+			config := DefaultLogConfig()
+			config.ConsoleLogsFileSize = "1K"
+			config.ConsoleLogsCompression = compression
+			config.SearchIndexMaxLines = 0
+			service, logsPath := newRotationService(t, config, "x0c0s1b0")
+
+			var events []RotationEvent
+			service.OnRotation(func(event RotationEvent) { events = append(events, event) })
+
+			log := filepath.Join(logsPath, "console.x0c0s1b0")
+			for _, fill := range []string{"a", "b"} {
+				require.NoError(t, os.WriteFile(log, []byte(strings.Repeat(fill, 2048)), 0600))
+				service.LogRotate(nil)
+			}
+
+			suffix := compressionSuffixes[compression]
+			require.Len(t, events, 2)
+			require.Equal(t, filepath.Join(service.config.ConsoleLogsBackupPath, "console.x0c0s1b0.1"+suffix), events[1].RotatedTo)
+
+			files, err := service.ConsoleLogFiles(logsPath, "x0c0s1b0")
+			require.NoError(t, err)
+			require.Len(t, files, 3)
+			require.Equal(t, filepath.Join(service.config.ConsoleLogsBackupPath, "console.x0c0s1b0.2"+suffix), files[0].Path)
+			require.True(t, files[0].Compressed)
+			require.Equal(t, strings.Repeat("a", 2048), readLogFile(t, files[0]))
+			require.Equal(t, strings.Repeat("b", 2048), readLogFile(t, files[1]))
+
+			info, err := files[0].Info()
+			require.NoError(t, err)
+			require.Equal(t, compression, info.Compression)
+		})
 	}
-	require.True(t, found1, "console.x0c0s1b0.1 should be in backup directory")
-	require.True(t, found2, "console.x0c0s1b1.1 should be in backup directory")
 }
 
-func TestReadLogRotTimestampsWithAggregationEntry(t *testing.T) {
-	// Prepare a temporary log rotation timestamp file containing an aggregation entry
-	tempDir := t.TempDir()
-	logRotTimestampsFile := filepath.Join(tempDir, "logrot.timestamps")
-
-	consoleTimestamp := time.Date(2023, 11, 14, 12, 26, 40, 0, time.Local)
-	aggTimestamp := time.Date(2023, 11, 15, 8, 30, 0, 0, time.Local)
-
-	content := fmt.Sprintf(`# Log rotation timestamps
-"/var/log/conman/console.x0c0s1b0" %s
-"/tmp/consoleAgg-test.log" %s
-`, consoleTimestamp.Format("2006-01-02-15:04:05"), aggTimestamp.Format("2006-01-02-15:04:05"))
-
+func TestLogRotateAggregationLog(t *testing.T) {
 	config := DefaultLogConfig()
-	config.LogRotateStateFilePath = logRotTimestampsFile
-	config.LogRotateFilePath = filepath.Join(tempDir, "logrotate.test")
-
-	err := os.WriteFile(logRotTimestampsFile, []byte(content), 0600)
+	config.AggLogsFileSize = "1K"
+	config.AggLogsNumRotate = 1
+	config.AggLogsPath = t.TempDir()
+	config.SearchIndexMaxLines = 0
+	service, _ := newRotationService(t, config)
+	service.EnsureAggLog()
+
+	var events []RotationEvent
+	service.OnRotation(func(event RotationEvent) { events = append(events, event) })
+
+	service.writeToAggLog("x0c0s1b0", strings.Repeat("a", 2048))
+	service.LogRotate(func() error {
+		t.Fatal("conmand should not be signaled for the aggregation log")
+		return nil
+	})
+
+	require.Len(t, events, 1)
+	require.Empty(t, events[0].Node)
+	require.Equal(t, service.conAggLogFile+".1", events[0].RotatedTo)
+
+	// Lines written after rotation go to the reopened log
+	service.writeToAggLog("x0c0s1b0", "after rotation")
+	data, err := os.ReadFile(service.conAggLogFile)
 	require.NoError(t, err)
+	require.Contains(t, string(data), "after rotation")
+	require.NotContains(t, string(data), strings.Repeat("a", 2048))
 
-	fileStamp := make(map[string]time.Time)
-	aggPath := "/tmp/consoleAgg-test.log"
-
-	conChanged, aggChanged := readLogRotTimestamps(config, "/var/log/conman", aggPath, fileStamp)
-
-	require.True(t, conChanged, "Console logs should show changes")
-	require.True(t, aggChanged, "Aggregation log should show changes")
-
-	expected := map[string]time.Time{
-		"x0c0s1b0":       consoleTimestamp,
-		"consoleAgg.log": aggTimestamp,
-	}
-	require.Equal(t, expected, fileStamp, "Timestamps should match expected values including aggregation log")
+	rotated, err := os.ReadFile(events[0].RotatedTo)
+	require.NoError(t, err)
+	require.Contains(t, string(rotated), strings.Repeat("a", 2048))
 }
 
-func TestReadLogRotTimestampsNoEntries(t *testing.T) {
-	// Prepare a temporary log rotation timestamp file with no entries
+func TestCopyTruncateFileKeepsWriter(t *testing.T) {
 	tempDir := t.TempDir()
-	logRotTimestampsFile := filepath.Join(tempDir, "logrot.timestamps")
+	live := filepath.Join(tempDir, "console.x1000c0s1b0")
+	rotated := filepath.Join(tempDir, "console.x1000c0s1b0.1")
 
-	content := "logrotate state -- version 2\n"
-
-	config := DefaultLogConfig()
-	config.LogRotateStateFilePath = logRotTimestampsFile
-	config.LogRotateFilePath = filepath.Join(tempDir, "logrotate.test")
-
-	err := os.WriteFile(logRotTimestampsFile, []byte(content), 0600)
+	// The writer has the log open for appending, as conmand does
+	writer, err := os.OpenFile(live, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	defer writer.Close()
+	_, err = writer.WriteString("before rotation\n")
 	require.NoError(t, err)
 
-	fileStamp := make(map[string]time.Time)
-	// Read the timestamps
-	conChanged, aggChanged := readLogRotTimestamps(config, "/var/log/conman", "", fileStamp)
-	require.False(t, conChanged, "Console logs should not show changes")
-	require.False(t, aggChanged, "Aggregation log should not show changes")
+	require.NoError(t, copyTruncateFile(live, rotated))
+	_, err = writer.WriteString("after rotation\n")
+	require.NoError(t, err)
 
-	// Verify the contents
-	expected := map[string]time.Time{}
+	data, err := os.ReadFile(rotated)
+	require.NoError(t, err)
+	require.Equal(t, "before rotation\n", string(data))
+	data, err = os.ReadFile(live)
+	require.NoError(t, err)
+	require.Equal(t, "after rotation\n", string(data))
+}
 
-	require.Equal(t, expected, fileStamp, "Timestamps should be empty")
+func TestLogRotateDoesNotHoldService(t *testing.T) {
+	config := DefaultLogConfig()
+	config.ConsoleLogsFileSize = "1K"
+	config.ConsoleLogsCompression = CompressionGzip
+	config.SearchIndexMaxLines = 0
+	service, logsPath := newRotationService(t, config, "x0c0s1b0")
+	service.rotation.settleDelay = 2 * time.Second
+	require.NoError(t, os.WriteFile(filepath.Join(logsPath, "console.x0c0s1b0"), []byte(strings.Repeat("line of output\n", 100)), 0600))
+
+	reopened := make(chan struct{})
+	rotated := make(chan struct{})
+	go func() {
+		defer close(rotated)
+		service.LogRotate(func() error {
+			close(reopened)
+			return nil
+		})
+	}()
+	<-reopened
+
+	// The disk budget is enforced while the rotated log waits to be compressed
+	start := time.Now()
+	service.EnforceDiskBudget()
+	require.Less(t, time.Since(start), time.Second)
+
+	<-rotated
+	files, err := consoleLogFiles(logsPath, service.config.ConsoleLogsBackupPath, "x0c0s1b0")
+	require.NoError(t, err)
+	require.Equal(t, CompressionGzip, compressionOf(files[0].Path))
 }
@@ -0,0 +1,411 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// This file contains the full-text search index over console logs
+
+package logs
+
+import (
+	"bufio"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Words shorter than this are not indexed
+const minSearchWordLen = 2
+
+// SearchQuery selects the console log lines to return from a search
+type SearchQuery struct {
+	Text   string                  // every word must appear in a matching line
+	Accept func(xname string) bool // selects the nodes to search, nil for all
+	Since  time.Time               // zero for no lower bound
+	Until  time.Time               // zero for no upper bound
+	Limit  int
+}
+
+// SearchResult is a console log line matching a search
+type SearchResult struct {
+	Node      string     `json:"node"`
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+	File      string     `json:"file"`
+	Offset    int64      `json:"offset"`
+	Text      string     `json:"text"`
+}
+
+// ErrNoSearchWords is returned for a query with no word long enough to search for
+var ErrNoSearchWords = fmt.Errorf("query must contain a word of at least %d letters or digits", minSearchWordLen)
+
+// ErrSearchDisabled is returned when the search index is turned off
+var ErrSearchDisabled = errors.New("console log search is disabled")
+
+// searchWords splits text into lowercase words of letters and digits
+func searchWords(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	words = slices.DeleteFunc(words, func(w string) bool { return len(w) < minSearchWordLen })
+	slices.Sort(words)
+	return slices.Compact(words)
+}
+
+// indexedLine locates a line within its log file
+type indexedLine struct {
+	offset    int64 // start of the line
+	timestamp int64 // unix seconds of the last conman timestamp, zero when unknown
+}
+
+// indexSegment indexes the lines of one console log file
+type indexSegment struct {
+	lines    []indexedLine
+	postings map[string][]uint32 // word -> indexes into lines
+}
+
+func newIndexSegment() *indexSegment {
+	return &indexSegment{postings: make(map[string][]uint32)}
+}
+
+func (seg *indexSegment) add(text string, offset, timestamp int64) {
+	id := uint32(len(seg.lines))
+	seg.lines = append(seg.lines, indexedLine{offset: offset, timestamp: timestamp})
+	for _, word := range searchWords(text) {
+		seg.postings[word] = append(seg.postings[word], id)
+	}
+}
+
+// match returns the lines containing every word
+func (seg *indexSegment) match(words []string) []uint32 {
+	var ids []uint32
+	for i, word := range words {
+		postings := seg.postings[word]
+		if i == 0 {
+			ids = slices.Clone(postings)
+			continue
+		}
+		ids = slices.DeleteFunc(ids, func(id uint32) bool {
+			_, found := slices.BinarySearch(postings, id)
+			return !found
+		})
+	}
+	return ids
+}
+
+// nodeIndex holds a node's segments, oldest first, with the live log last
+type nodeIndex struct {
+	mutex     sync.Mutex
+	segments  []*indexSegment
+	lines     int
+	timestamp int64 // last conman timestamp seen
+	full      bool  // set once the live log alone exceeds the line limit
+}
+
+// searchIndex is an in-memory inverted index of the console logs. Each
+// segment corresponds to one log file and segments are dropped as their files
+// are rotated away. It is a logIndexer.
+type searchIndex struct {
+	maxLines   int // per node
+	maxRotated int
+	mutex      sync.RWMutex
+	nodes      map[string]*nodeIndex
+}
+
+func newSearchIndex(maxLines, maxRotated int) *searchIndex {
+	return &searchIndex{
+		maxLines:   maxLines,
+		maxRotated: maxRotated,
+		nodes:      make(map[string]*nodeIndex),
+	}
+}
+
+// beginNode discards a node's index before its logs are read again
+func (si *searchIndex) beginNode(xname string) {
+	si.mutex.Lock()
+	defer si.mutex.Unlock()
+	si.nodes[xname] = &nodeIndex{}
+}
+
+// removeNode discards the index of a node that is no longer tracked
+func (si *searchIndex) removeNode(xname string) {
+	si.mutex.Lock()
+	defer si.mutex.Unlock()
+	delete(si.nodes, xname)
+}
+
+func (si *searchIndex) node(xname string) *nodeIndex {
+	si.mutex.RLock()
+	defer si.mutex.RUnlock()
+	return si.nodes[xname]
+}
+
+// beginFile starts a segment for the next log file, dropping the segments of
+// logs that rotation has deleted
+func (si *searchIndex) beginFile(xname string) {
+	ni := si.node(xname)
+	if ni == nil {
+		return
+	}
+
+	ni.mutex.Lock()
+	defer ni.mutex.Unlock()
+
+	ni.segments = append(ni.segments, newIndexSegment())
+	ni.full = false
+	for len(ni.segments) > si.maxRotated+1 {
+		ni.lines -= len(ni.segments[0].lines)
+		ni.segments = ni.segments[1:]
+	}
+}
+
+// addLine indexes a line in the newest segment, dropping the oldest segments
+// to stay within the line limit
+func (si *searchIndex) addLine(xname, text string, offset int64) {
+	ni := si.node(xname)
+	if ni == nil {
+		return
+	}
+
+	ni.mutex.Lock()
+	defer ni.mutex.Unlock()
+
+	if ts, ok := ParseLineTimestamp(text); ok {
+		ni.timestamp = ts.Unix()
+	}
+
+	if ni.full || len(ni.segments) == 0 {
+		return
+	}
+	for ni.lines >= si.maxLines && len(ni.segments) > 1 {
+		ni.lines -= len(ni.segments[0].lines)
+		ni.segments = ni.segments[1:]
+	}
+	if ni.lines >= si.maxLines {
+		ni.full = true
+		slog.Warn("Console log search index is full, later lines are not searchable", "xname", xname, "lines", ni.lines)
+		return
+	}
+
+	ni.segments[len(ni.segments)-1].add(text, offset, ni.timestamp)
+	ni.lines++
+}
+
+// searchCandidate is an indexed line that may match a search
+type searchCandidate struct {
+	node      string
+	rotation  int
+	offset    int64
+	timestamp int64
+}
+
+// candidates returns the indexed lines containing every word, newest first
+func (si *searchIndex) candidates(words []string, query SearchQuery) []searchCandidate {
+	si.mutex.RLock()
+	selected := make(map[string]*nodeIndex, len(si.nodes))
+	for xname, ni := range si.nodes {
+		if query.Accept == nil || query.Accept(xname) {
+			selected[xname] = ni
+		}
+	}
+	si.mutex.RUnlock()
+
+	var since, until int64
+	if !query.Since.IsZero() {
+		since = query.Since.Unix()
+	}
+	if !query.Until.IsZero() {
+		until = query.Until.Unix()
+	}
+
+	var found []searchCandidate
+	for xname, ni := range selected {
+		ni.mutex.Lock()
+		for i, seg := range ni.segments {
+			for _, id := range seg.match(words) {
+				line := seg.lines[id]
+				if (since != 0 || until != 0) && line.timestamp == 0 {
+					continue
+				}
+				if (since != 0 && line.timestamp < since) || (until != 0 && line.timestamp > until) {
+					continue
+				}
+				found = append(found, searchCandidate{
+					node:      xname,
+					rotation:  len(ni.segments) - 1 - i,
+					offset:    line.offset,
+					timestamp: line.timestamp,
+				})
+			}
+		}
+		ni.mutex.Unlock()
+	}
+
+	slices.SortFunc(found, func(a, b searchCandidate) int {
+		return cmp.Or(
+			cmp.Compare(b.timestamp, a.timestamp),
+			strings.Compare(a.node, b.node),
+			cmp.Compare(a.rotation, b.rotation),
+			cmp.Compare(b.offset, a.offset),
+		)
+	})
+	return found
+}
+
+// Search returns the most recent indexed console log lines matching the
+// query. Lines are read back from the log files and redacted; lines that
+// have since been rotated away are skipped.
+func (ls *LogsService) Search(consoleLogsPath string, query SearchQuery) ([]SearchResult, bool, error) {
+	if ls.search == nil {
+		return nil, false, ErrSearchDisabled
+	}
+
+	words := searchWords(query.Text)
+	if len(words) == 0 {
+		return nil, false, ErrNoSearchWords
+	}
+
+	results := []SearchResult{}
+	files := make(map[string][]ConsoleLogFile)
+	candidates := ls.search.candidates(words, query)
+	for len(candidates) > 0 {
+		if len(results) >= query.Limit {
+			return results, true, nil
+		}
+
+		// Read back as many lines as are still needed, reading each file once
+		batch := candidates[:min(query.Limit-len(results), len(candidates))]
+		candidates = candidates[len(batch):]
+
+		type fileKey struct {
+			node     string
+			rotation int
+		}
+		batchFiles := make(map[fileKey]ConsoleLogFile)
+		offsets := make(map[fileKey][]int64)
+		for _, c := range batch {
+			nodeFiles, ok := files[c.node]
+			if !ok {
+				var err error
+				nodeFiles, err = ls.ConsoleLogFiles(consoleLogsPath, c.node)
+				if err != nil {
+					return nil, false, err
+				}
+				files[c.node] = nodeFiles
+			}
+			idx := slices.IndexFunc(nodeFiles, func(f ConsoleLogFile) bool { return f.Rotation == c.rotation })
+			if idx < 0 {
+				continue
+			}
+			key := fileKey{c.node, c.rotation}
+			batchFiles[key] = nodeFiles[idx]
+			offsets[key] = append(offsets[key], c.offset)
+		}
+
+		lines := make(map[fileKey]map[int64]string, len(batchFiles))
+		for key, f := range batchFiles {
+			fileLines, err := readLinesAt(f, offsets[key])
+			if err != nil {
+				slog.Debug("Failed to read search results", "file", f.Path, "error", err)
+			}
+			lines[key] = fileLines
+		}
+
+		for _, c := range batch {
+			key := fileKey{c.node, c.rotation}
+			text, ok := lines[key][c.offset]
+			if !ok {
+				continue
+			}
+			// Lines are indexed redacted, and the file may have rotated since
+			text = ls.redactor.Stream().Line(text)
+			if !containsWords(text, words) {
+				continue
+			}
+
+			result := SearchResult{
+				Node:   c.node,
+				File:   filepath.Base(batchFiles[key].Path),
+				Offset: c.offset,
+				Text:   text,
+			}
+			if c.timestamp != 0 {
+				ts := time.Unix(c.timestamp, 0).UTC()
+				result.Timestamp = &ts
+			}
+			results = append(results, result)
+		}
+	}
+	return results, false, nil
+}
+
+// containsWords reports whether text contains every word
+func containsWords(text string, words []string) bool {
+	lineWords := searchWords(text)
+	for _, word := range words {
+		if _, found := slices.BinarySearch(lineWords, word); !found {
+			return false
+		}
+	}
+	return true
+}
+
+// readLinesAt reads the lines starting at each offset, which are offsets
+// into the decompressed content for compressed files. The file is read once,
+// in offset order. The lines read before an error are returned with it.
+func readLinesAt(f ConsoleLogFile, offsets []int64) (map[int64]string, error) {
+	offsets = slices.Clone(offsets)
+	slices.Sort(offsets)
+	offsets = slices.Compact(offsets)
+	lines := make(map[int64]string, len(offsets))
+
+	if !f.Compressed {
+		file, err := os.Open(f.Path)
+		if err != nil {
+			return lines, err
+		}
+		defer func() { _ = file.Close() }()
+		for _, offset := range offsets {
+			line, err := bufio.NewReader(io.NewSectionReader(file, offset, 1<<62)).ReadString('\n')
+			if err != nil && err != io.EOF {
+				return lines, err
+			}
+			lines[offset] = strings.TrimRight(line, "\r\n")
+		}
+		return lines, nil
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return lines, err
+	}
+	defer func() { _ = rc.Close() }()
+	r := bufio.NewReader(rc)
+	var pos int64
+	for _, offset := range offsets {
+		if offset < pos {
+			// Not the start of a line, the file changed since it was indexed
+			continue
+		}
+		if _, err := r.Discard(int(offset - pos)); err != nil {
+			return lines, err
+		}
+		line, err := r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return lines, err
+		}
+		lines[offset] = strings.TrimRight(line, "\r\n")
+		pos = offset + int64(len(line))
+		if err == io.EOF {
+			break
+		}
+	}
+	return lines, nil
+}
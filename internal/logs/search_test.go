@@ -0,0 +1,155 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package logs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchWords(t *testing.T) {
+	require.Equal(t, []string{"2026", "ce", "edac", "errors", "mc0"}, searchWords("EDAC MC0: 2 CE errors, 2026 edac"))
+}
+
+// addLiveLines indexes lines as the log aggregation tail would read them from
+// a newly created live log
+func addLiveLines(service *LogsService, pos *liveLogPosition, xname string, lines ...string) {
+	var end int64
+	for _, line := range lines {
+		start := end
+		end += int64(len(line)) + 1
+		service.indexLiveLine(pos, xname, line, start, end)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	tempDir := t.TempDir()
+	logsPath := filepath.Join(tempDir, "conman")
+	backupPath := filepath.Join(tempDir, "conman.old")
+	require.NoError(t, os.MkdirAll(logsPath, 0755))
+	require.NoError(t, os.MkdirAll(backupPath, 0755))
+
+	xname := "x1000c0s0b0n0"
+	rotated := "2026-03-01 01:00:00 UTC EDAC MC0: 1 CE error\n2026-03-01 02:00:00 UTC login:\n"
+	writeGzipFile(t, filepath.Join(backupPath, "console."+xname+".1.gz"), rotated)
+
+	live := []string{"booting", "2026-03-04 05:00:00 UTC EDAC MC1: 3 CE errors", "no timestamp edac"}
+	content := ""
+	for _, line := range live {
+		content += line + "\n"
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(logsPath, "console."+xname), []byte(content), 0600))
+
+	service := &LogsService{
+		config: LogConfig{ConsoleLogsBackupPath: backupPath},
+		search: newSearchIndex(1000, 2),
+	}
+	service.indexers = []logIndexer{service.search}
+	service.indexRotatedLogs(logsPath, xname)
+	addLiveLines(service, &liveLogPosition{}, xname, live...)
+
+	results, truncated, err := service.Search(logsPath, SearchQuery{Text: "edac ce", Limit: 10})
+	require.NoError(t, err)
+	require.False(t, truncated)
+	require.Len(t, results, 2)
+	require.Equal(t, "2026-03-04 05:00:00 UTC EDAC MC1: 3 CE errors", results[0].Text)
+	require.Equal(t, "console."+xname, results[0].File)
+	require.Equal(t, int64(len("booting\n")), results[0].Offset)
+	require.Equal(t, time.Date(2026, 3, 4, 5, 0, 0, 0, time.UTC), *results[0].Timestamp)
+	require.Equal(t, "console."+xname+".1.gz", results[1].File)
+	require.Equal(t, int64(0), results[1].Offset)
+
+	// Lines without a timestamp take the last one seen
+	results, _, err = service.Search(logsPath, SearchQuery{Text: "timestamp", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, time.Date(2026, 3, 4, 5, 0, 0, 0, time.UTC), *results[0].Timestamp)
+
+	results, truncated, err = service.Search(logsPath, SearchQuery{Text: "EDAC", Since: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), Limit: 1})
+	require.NoError(t, err)
+	require.True(t, truncated)
+	require.Len(t, results, 1)
+
+	results, _, err = service.Search(logsPath, SearchQuery{Text: "edac", Accept: func(string) bool { return false }, Limit: 10})
+	require.NoError(t, err)
+	require.Empty(t, results)
+
+	_, _, err = service.Search(logsPath, SearchQuery{Text: "a :", Limit: 10})
+	require.ErrorIs(t, err, ErrNoSearchWords)
+
+	_, _, err = (&LogsService{}).Search(logsPath, SearchQuery{Text: "edac", Limit: 10})
+	require.ErrorIs(t, err, ErrSearchDisabled)
+}
+
+func TestSearchRedaction(t *testing.T) {
+	logsPath := t.TempDir()
+	xname := "x1000c0s0b0n0"
+	require.NoError(t, os.WriteFile(filepath.Join(logsPath, "console."+xname), []byte("login password=hunter2 ok\n"), 0600))
+
+	redactor, err := NewRedactor([]string{"password"})
+	require.NoError(t, err)
+	service := &LogsService{search: newSearchIndex(1000, 1), redactor: redactor}
+	service.indexers = []logIndexer{service.search}
+	service.indexRotatedLogs(logsPath, xname)
+	addLiveLines(service, &liveLogPosition{}, xname, "login password=[REDACTED] ok")
+
+	// The line is read back as stored, and redacted again before it is returned
+	results, _, err := service.Search(logsPath, SearchQuery{Text: "login", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, "login password=[REDACTED] ok", results[0].Text)
+}
+
+func TestSearchIndexRotation(t *testing.T) {
+	index := newSearchIndex(1000, 1)
+	service := &LogsService{search: index, indexers: []logIndexer{index}}
+	xname := "x1000c0s0b0n0"
+
+	var pos liveLogPosition
+	service.indexRotatedLogs(t.TempDir(), xname)
+	addLiveLines(service, &pos, xname, "first log")
+	addLiveLines(service, &pos, xname, "second log")
+	addLiveLines(service, &pos, xname, "third log")
+
+	// Only the live log and one rotated log are kept
+	found := index.candidates([]string{"log"}, SearchQuery{})
+	require.Len(t, found, 2)
+	require.Equal(t, 0, found[0].rotation)
+	require.Equal(t, 1, found[1].rotation)
+	require.Empty(t, index.candidates([]string{"first"}, SearchQuery{}))
+
+	// The oldest logs are dropped to stay within the line limit
+	index = newSearchIndex(2, 5)
+	service = &LogsService{search: index, indexers: []logIndexer{index}}
+	pos = liveLogPosition{}
+	service.indexRotatedLogs(t.TempDir(), xname)
+	addLiveLines(service, &pos, xname, "first log")
+	addLiveLines(service, &pos, xname, "second log", "third log")
+	require.Len(t, index.candidates([]string{"log"}, SearchQuery{}), 2)
+	require.Empty(t, index.candidates([]string{"first"}, SearchQuery{}))
+}
+
+func TestReadLinesAt(t *testing.T) {
+	tempDir := t.TempDir()
+	content := "first\r\nsecond\nthird"
+	offsets := []int64{14, 0, 7, 14}
+	expected := map[int64]string{0: "first", 7: "second", 14: "third"}
+
+	live := filepath.Join(tempDir, "console.x1000c0s0b0n0")
+	require.NoError(t, os.WriteFile(live, []byte(content), 0600))
+	lines, err := readLinesAt(ConsoleLogFile{Path: live}, offsets)
+	require.NoError(t, err)
+	require.Equal(t, expected, lines)
+
+	rotated := filepath.Join(tempDir, "console.x1000c0s0b0n0.1.gz")
+	writeGzipFile(t, rotated, content)
+	lines, err = readLinesAt(ConsoleLogFile{Path: rotated, Rotation: 1, Compressed: true}, offsets)
+	require.NoError(t, err)
+	require.Equal(t, expected, lines)
+}
@@ -0,0 +1,579 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// This file contains the destinations aggregated console output is written to
+
+package logs
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/OpenCHAMI/remote-console/internal/metrics"
+)
+
+// Aggregated output sinks
+const (
+	SinkFile   = "file"
+	SinkStdout = "stdout"
+	SinkSyslog = "syslog"
+	SinkHTTP   = "http"
+)
+
+// HTTP push formats
+const (
+	PushFormatJSONLines = "jsonl"
+	PushFormatLoki      = "loki"
+)
+
+const (
+	// lines sent to a sink in one write or request
+	maxSinkBatch = 500
+
+	sinkInitialBackoff = time.Second
+	sinkMaxBackoff     = time.Minute
+	sinkTimeout        = 10 * time.Second
+	// time given to the sinks on shutdown to send the lines they queued
+	sinkDrainTimeout = 10 * time.Second
+	// a batch is dropped after this many failed sends, about 15 minutes of retries
+	sinkMaxAttempts = 20
+
+	// RFC 5424 priority of console output: facility local0, severity info
+	syslogPriority = 16*8 + 6
+	syslogAppName  = "remote-console"
+)
+
+// logSink receives the aggregated console output. write is called for every
+// line in sequence order and must not block aggregation.
+type logSink interface {
+	name() string
+	write(line ConsoleLine, seq uint64)
+	stats() (sent, dropped uint64)
+	close(ctx context.Context) error
+}
+
+// sinkLine is a console line with its aggregation sequence number
+type sinkLine struct {
+	line ConsoleLine
+	seq  uint64
+}
+
+// errSinkRejected marks a batch the destination refused, which is dropped
+// rather than sent again
+var errSinkRejected = errors.New("rejected by the sink")
+
+// partialSendError is a send that failed after the first lines were delivered,
+// so that only the rest are sent again
+type partialSendError struct {
+	sent int
+	err  error
+}
+
+func (e *partialSendError) Error() string { return e.err.Error() }
+
+func (e *partialSendError) Unwrap() error { return e.err }
+
+// sinkSender delivers batches of lines to a buffered sink's destination
+type sinkSender interface {
+	send(ctx context.Context, lines []sinkLine) error
+	close() error
+}
+
+// newLogSinks creates the sinks selected by the configuration
+func newLogSinks(ls *LogsService, config LogConfig) ([]logSink, error) {
+	var sinks []logSink
+	seen := make(map[string]bool)
+	for _, name := range config.AggLogsSinks {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		switch name {
+		case SinkFile:
+			sinks = append(sinks, &fileSink{ls: ls})
+		case SinkStdout:
+			sinks = append(sinks, newBufferedSink(name, &writerSender{w: os.Stdout}, config.AggSinkQueueSize))
+		case SinkSyslog:
+			sender, err := newSyslogSender(config.AggSyslogAddress, config.AggSyslogCAFile)
+			if err != nil {
+				return nil, err
+			}
+			sinks = append(sinks, newBufferedSink(name, sender, config.AggSinkQueueSize))
+		case SinkHTTP:
+			sender, err := newHTTPSender(config.AggPushURL, config.AggPushFormat, config.AggPushHeaders)
+			if err != nil {
+				return nil, err
+			}
+			sinks = append(sinks, newBufferedSink(name, sender, config.AggSinkQueueSize))
+		default:
+			return nil, fmt.Errorf("unknown aggregation log sink %q: must be %q, %q, %q or %q", name, SinkFile, SinkStdout, SinkSyslog, SinkHTTP)
+		}
+	}
+	return sinks, nil
+}
+
+// aggLogFileEnabled reports whether the aggregation log file is one of the sinks
+func (ls *LogsService) aggLogFileEnabled() bool {
+	return slices.ContainsFunc(ls.sinks, func(s logSink) bool { return s.name() == SinkFile })
+}
+
+// sinkMetrics returns the lines sent and dropped by each sink
+func (ls *LogsService) sinkMetrics() []metrics.Metric {
+	if len(ls.sinks) == 0 {
+		return nil
+	}
+
+	sent := metrics.Metric{
+		Name: "remote_console_log_sink_sent_lines_total",
+		Help: "Aggregated console lines written to a sink.",
+		Type: metrics.TypeCounter,
+	}
+	dropped := metrics.Metric{
+		Name: "remote_console_log_sink_dropped_lines_total",
+		Help: "Aggregated console lines dropped because a sink's queue was full or their batch was dropped.",
+		Type: metrics.TypeCounter,
+	}
+	droppedBatches := metrics.Metric{
+		Name: "remote_console_log_sink_dropped_batches_total",
+		Help: "Batches of aggregated console lines dropped because a sink rejected them or kept failing.",
+		Type: metrics.TypeCounter,
+	}
+	for _, sink := range ls.sinks {
+		s, d := sink.stats()
+		labels := map[string]string{"sink": sink.name()}
+		sent.Samples = append(sent.Samples, metrics.Sample{Labels: labels, Value: float64(s)})
+		dropped.Samples = append(dropped.Samples, metrics.Sample{Labels: labels, Value: float64(d)})
+		if bs, ok := sink.(*bufferedSink); ok {
+			droppedBatches.Samples = append(droppedBatches.Samples, metrics.Sample{Labels: labels, Value: float64(bs.droppedBatches.Load())})
+		}
+	}
+	return []metrics.Metric{sent, dropped, droppedBatches}
+}
+
+// fileSink writes to the aggregation log file, which is rotated with the console logs
+type fileSink struct {
+	ls   *LogsService
+	sent atomic.Uint64
+}
+
+func (fs *fileSink) name() string { return SinkFile }
+
+// write is called with the aggregation log mutex held
+func (fs *fileSink) write(line ConsoleLine, seq uint64) {
+	if fs.ls.writeAggLogLocked(line, seq) {
+		fs.sent.Add(1)
+	}
+}
+
+func (fs *fileSink) stats() (uint64, uint64) { return fs.sent.Load(), 0 }
+
+func (fs *fileSink) close(context.Context) error { return nil }
+
+// bufferedSink queues lines for a sender, retrying failed sends with
+// exponential backoff. Lines are dropped when the queue is full, and batches
+// when the destination rejects them or every attempt fails.
+type bufferedSink struct {
+	sinkName       string
+	sender         sinkSender
+	queue          chan sinkLine
+	cancel         context.CancelFunc
+	stop           chan struct{}
+	stopOnce       sync.Once
+	done           chan struct{}
+	sent           atomic.Uint64
+	dropped        atomic.Uint64
+	droppedBatches atomic.Uint64
+}
+
+func newBufferedSink(name string, sender sinkSender, queueSize int) *bufferedSink {
+	ctx, cancel := context.WithCancel(context.Background())
+	bs := &bufferedSink{
+		sinkName: name,
+		sender:   sender,
+		queue:    make(chan sinkLine, max(queueSize, 1)),
+		cancel:   cancel,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go bs.run(ctx)
+	return bs
+}
+
+func (bs *bufferedSink) name() string { return bs.sinkName }
+
+func (bs *bufferedSink) write(line ConsoleLine, seq uint64) {
+	select {
+	case bs.queue <- sinkLine{line: line, seq: seq}:
+	default:
+		if bs.dropped.Add(1)%1000 == 1 {
+			slog.Warn("Aggregation log sink queue full, dropping lines", "sink", bs.sinkName, "dropped", bs.dropped.Load())
+		}
+	}
+}
+
+func (bs *bufferedSink) stats() (uint64, uint64) { return bs.sent.Load(), bs.dropped.Load() }
+
+func (bs *bufferedSink) run(ctx context.Context) {
+	defer close(bs.done)
+	for {
+		var batch []sinkLine
+		select {
+		case <-ctx.Done():
+			return
+		case <-bs.stop:
+			// Send what was queued before the sink was closed
+			for batch = bs.fill(nil); len(batch) > 0; batch = bs.fill(nil) {
+				if !bs.deliver(ctx, batch) {
+					return
+				}
+			}
+			return
+		case line := <-bs.queue:
+			batch = append(batch, line)
+		}
+
+		if !bs.deliver(ctx, bs.fill(batch)) {
+			return
+		}
+	}
+}
+
+// fill adds the queued lines to a batch, up to the batch size
+func (bs *bufferedSink) fill(batch []sinkLine) []sinkLine {
+	for len(batch) < maxSinkBatch {
+		select {
+		case line := <-bs.queue:
+			batch = append(batch, line)
+		default:
+			return batch
+		}
+	}
+	return batch
+}
+
+// deliver sends a batch until it succeeds, is rejected or runs out of
+// attempts, returning false when the sink is closed first
+func (bs *bufferedSink) deliver(ctx context.Context, batch []sinkLine) bool {
+	backoff := sinkInitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := bs.sender.send(ctx, batch)
+		if err == nil {
+			bs.sent.Add(uint64(len(batch)))
+			return true
+		}
+		var partial *partialSendError
+		if errors.As(err, &partial) {
+			bs.sent.Add(uint64(partial.sent))
+			batch = batch[partial.sent:]
+		}
+
+		if errors.Is(err, errSinkRejected) || attempt == sinkMaxAttempts {
+			// Sending it again would block every later line behind it
+			bs.dropped.Add(uint64(len(batch)))
+			bs.droppedBatches.Add(1)
+			slog.Error("Dropping aggregated output the sink failed to accept", "sink", bs.sinkName, "lines", len(batch), "attempts", attempt, "error", err)
+			return true
+		}
+
+		slog.Warn("Failed to send aggregated output to sink", "sink", bs.sinkName, "lines", len(batch), "attempt", attempt, "error", err)
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, sinkMaxBackoff)
+	}
+}
+
+// close sends the queued lines until ctx is done, then closes the sender.
+// Lines written after close are left unsent.
+func (bs *bufferedSink) close(ctx context.Context) error {
+	bs.stopOnce.Do(func() { close(bs.stop) })
+	select {
+	case <-bs.done:
+	case <-ctx.Done():
+		slog.Warn("Timed out sending queued aggregated output on shutdown", "sink", bs.sinkName, "queued", len(bs.queue))
+	}
+	bs.cancel()
+	<-bs.done
+	return bs.sender.close()
+}
+
+// CloseSinks sends the aggregated output the sinks have queued, giving up
+// after a short while, and closes their connections
+func (ls *LogsService) CloseSinks(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, sinkDrainTimeout)
+	defer cancel()
+
+	var errs []error
+	for _, sink := range ls.sinks {
+		if err := sink.close(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close %s sink: %w", sink.name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// writerSender writes JSON framed lines, used for stdout
+type writerSender struct {
+	w io.Writer
+}
+
+func (ws *writerSender) send(_ context.Context, lines []sinkLine) error {
+	var buf bytes.Buffer
+	if err := encodeJSONLines(&buf, lines); err != nil {
+		return fmt.Errorf("%w: %w", errSinkRejected, err)
+	}
+	_, err := ws.w.Write(buf.Bytes())
+	return err
+}
+
+func (ws *writerSender) close() error { return nil }
+
+func encodeJSONLines(buf *bytes.Buffer, lines []sinkLine) error {
+	enc := json.NewEncoder(buf)
+	for _, l := range lines {
+		if err := enc.Encode(l.line.Frame(l.seq)); err != nil {
+			return fmt.Errorf("failed to encode line: %w", err)
+		}
+	}
+	return nil
+}
+
+// syslogSender sends RFC 5424 messages over UDP, or over TCP or TLS with
+// octet-counting framing (RFC 6587)
+type syslogSender struct {
+	network   string
+	address   string
+	tlsConfig *tls.Config
+	hostname  string
+	conn      net.Conn
+}
+
+func newSyslogSender(address, caFile string) (*syslogSender, error) {
+	u, err := url.Parse(address)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid syslog address %q: must be udp://, tcp:// or tls:// host:port", address)
+	}
+
+	ss := &syslogSender{network: u.Scheme, address: u.Host, hostname: "-"}
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		ss.hostname = hostname
+	}
+
+	switch u.Scheme {
+	case "udp", "tcp":
+	case "tls":
+		ss.network = "tcp"
+		ss.tlsConfig = &tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12}
+		if caFile != "" {
+			pem, err := os.ReadFile(caFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read syslog CA file: %w", err)
+			}
+			ss.tlsConfig.RootCAs = x509.NewCertPool()
+			if !ss.tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in syslog CA file %q", caFile)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("invalid syslog address %q: must be udp://, tcp:// or tls:// host:port", address)
+	}
+	return ss, nil
+}
+
+func (ss *syslogSender) dial(ctx context.Context) error {
+	if ss.conn != nil {
+		return nil
+	}
+
+	dialer := &net.Dialer{Timeout: sinkTimeout}
+	var err error
+	if ss.tlsConfig != nil {
+		ss.conn, err = (&tls.Dialer{NetDialer: dialer, Config: ss.tlsConfig}).DialContext(ctx, ss.network, ss.address)
+	} else {
+		ss.conn, err = dialer.DialContext(ctx, ss.network, ss.address)
+	}
+	return err
+}
+
+// message formats a line as an RFC 5424 message, with the node as the MSGID
+func (ss *syslogSender) message(l sinkLine) string {
+	return fmt.Sprintf("<%d>1 %s %s %s - %s - %s", syslogPriority,
+		l.line.Timestamp.UTC().Format(time.RFC3339Nano), ss.hostname, syslogAppName, l.line.Node, l.line.Text)
+}
+
+func (ss *syslogSender) send(ctx context.Context, lines []sinkLine) error {
+	if err := ss.dial(ctx); err != nil {
+		return err
+	}
+
+	var err error
+	if ss.network == "udp" {
+		// Each message is its own datagram
+		for i, l := range lines {
+			if _, err = ss.conn.Write([]byte(ss.message(l))); err != nil {
+				err = &partialSendError{sent: i, err: err}
+				break
+			}
+		}
+	} else {
+		var buf bytes.Buffer
+		for _, l := range lines {
+			msg := ss.message(l)
+			buf.WriteString(strconv.Itoa(len(msg)))
+			buf.WriteByte(' ')
+			buf.WriteString(msg)
+		}
+		_ = ss.conn.SetWriteDeadline(time.Now().Add(sinkTimeout))
+		_, err = ss.conn.Write(buf.Bytes())
+	}
+
+	// Reconnect on the next attempt
+	if err != nil {
+		_ = ss.close()
+	}
+	return err
+}
+
+func (ss *syslogSender) close() error {
+	if ss.conn == nil {
+		return nil
+	}
+	err := ss.conn.Close()
+	ss.conn = nil
+	return err
+}
+
+// httpSender POSTs batches of lines as JSON lines or to the Loki push API
+type httpSender struct {
+	url     string
+	format  string
+	headers map[string]string
+	client  *http.Client
+}
+
+func newHTTPSender(pushURL, format string, headers []string) (*httpSender, error) {
+	if u, err := url.Parse(pushURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid aggregation push URL %q", pushURL)
+	}
+
+	hs := &httpSender{url: pushURL, format: format, headers: make(map[string]string), client: &http.Client{Timeout: sinkTimeout}}
+	switch format {
+	case "":
+		hs.format = PushFormatJSONLines
+	case PushFormatJSONLines, PushFormatLoki:
+	default:
+		return nil, fmt.Errorf("invalid aggregation push format %q: must be %q or %q", format, PushFormatJSONLines, PushFormatLoki)
+	}
+
+	for _, header := range headers {
+		if header == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(header, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid aggregation push header %q: must be Name=Value", header)
+		}
+		hs.headers[strings.TrimSpace(name)] = value
+	}
+	return hs, nil
+}
+
+// lokiPush is the body of a Loki push API request
+type lokiPush struct {
+	Streams []lokiStream `json:"streams"`
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"` // unix nanoseconds and line
+}
+
+func (hs *httpSender) body(lines []sinkLine) ([]byte, string, error) {
+	if hs.format != PushFormatLoki {
+		var buf bytes.Buffer
+		if err := encodeJSONLines(&buf, lines); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "application/x-ndjson", nil
+	}
+
+	// One stream per node, in the order the nodes first appear
+	var push lokiPush
+	streams := make(map[string]int)
+	for _, l := range lines {
+		idx, ok := streams[l.line.Node]
+		if !ok {
+			idx = len(push.Streams)
+			streams[l.line.Node] = idx
+			push.Streams = append(push.Streams, lokiStream{Stream: map[string]string{"job": syslogAppName, "node": l.line.Node}})
+		}
+		push.Streams[idx].Values = append(push.Streams[idx].Values, [2]string{strconv.FormatInt(l.line.Timestamp.UnixNano(), 10), l.line.Text})
+	}
+	data, err := json.Marshal(push)
+	return data, "application/json", err
+}
+
+func (hs *httpSender) send(ctx context.Context, lines []sinkLine) error {
+	body, contentType, err := hs.body(lines)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errSinkRejected, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hs.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range hs.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := hs.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			slog.Debug("Failed to close aggregation push response body", "error", err)
+		}
+	}()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		return nil
+	case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests:
+		return fmt.Errorf("status code: %d", resp.StatusCode)
+	case resp.StatusCode >= 400 && resp.StatusCode <= 499:
+		// Such as Loki refusing out of order or too old entries, or a batch too large
+		return fmt.Errorf("%w: status code: %d", errSinkRejected, resp.StatusCode)
+	default:
+		return fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+}
+
+func (hs *httpSender) close() error {
+	hs.client.CloseIdleConnections()
+	return nil
+}
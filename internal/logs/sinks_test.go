@@ -0,0 +1,419 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package logs
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var sinkTestLines = []ConsoleLine{
+	NewConsoleLine("x1000c0s0b0n0", "console.x1000c0s0b0n0", "2026-03-04 05:06:07 UTC login:", 0, time.Now()),
+	NewConsoleLine("x1000c0s1b0n0", "console.x1000c0s1b0n0", "Linux version 6.1", 42, time.Date(2026, 3, 4, 5, 6, 8, 0, time.UTC)),
+}
+
+// writeSinkTestLines writes the test lines to a sink and closes it once they have been sent
+func writeSinkTestLines(t *testing.T, sink logSink) {
+	for i, line := range sinkTestLines {
+		sink.write(line, uint64(i+1))
+	}
+	require.Eventually(t, func() bool {
+		sent, _ := sink.stats()
+		return sent == uint64(len(sinkTestLines))
+	}, 10*time.Second, 10*time.Millisecond)
+	require.NoError(t, sink.close(context.Background()))
+}
+
+func TestNewLogSinksInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		config func(*LogConfig)
+	}{
+		{"unknown sink", func(c *LogConfig) { c.AggLogsSinks = []string{"kafka"} }},
+		{"missing syslog address", func(c *LogConfig) { c.AggLogsSinks = []string{SinkSyslog} }},
+		{"syslog scheme", func(c *LogConfig) {
+			c.AggLogsSinks = []string{SinkSyslog}
+			c.AggSyslogAddress = "http://localhost:514"
+		}},
+		{"missing syslog CA file", func(c *LogConfig) {
+			c.AggLogsSinks = []string{SinkSyslog}
+			c.AggSyslogAddress = "tls://localhost:6514"
+			c.AggSyslogCAFile = "/nonexistent/ca.pem"
+		}},
+		{"missing push URL", func(c *LogConfig) { c.AggLogsSinks = []string{SinkHTTP} }},
+		{"push format", func(c *LogConfig) {
+			c.AggLogsSinks = []string{SinkHTTP}
+			c.AggPushURL = "http://localhost:3100/loki/api/v1/push"
+			c.AggPushFormat = "xml"
+		}},
+		{"push header", func(c *LogConfig) {
+			c.AggLogsSinks = []string{SinkHTTP}
+			c.AggPushURL = "http://localhost:3100/loki/api/v1/push"
+			c.AggPushHeaders = []string{"X-Scope-OrgID"}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultLogConfig()
+			tt.config(&config)
+			_, err := newLogSinks(&LogsService{}, config)
+			require.Error(t, err)
+		})
+	}
+
+	config := DefaultLogConfig()
+	config.AggLogsSinks = []string{SinkFile, " file", SinkStdout}
+	sinks, err := newLogSinks(&LogsService{}, config)
+	require.NoError(t, err)
+	require.Len(t, sinks, 2)
+	for _, sink := range sinks {
+		require.NoError(t, sink.close(context.Background()))
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent use
+type syncBuffer struct {
+	mutex sync.Mutex
+	buf   strings.Builder
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.String()
+}
+
+func TestStdoutSink(t *testing.T) {
+	var out syncBuffer
+	writeSinkTestLines(t, newBufferedSink(SinkStdout, &writerSender{w: &out}, 10))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	var frame LineFrame
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &frame))
+	require.Equal(t, sinkTestLines[1].Frame(2), frame)
+}
+
+// failingSender fails the first sends, then records the lines
+type failingSender struct {
+	mutex    sync.Mutex
+	failures int
+	lines    []sinkLine
+}
+
+func (fs *failingSender) send(_ context.Context, lines []sinkLine) error {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	if fs.failures > 0 {
+		fs.failures--
+		return io.ErrClosedPipe
+	}
+	fs.lines = append(fs.lines, lines...)
+	return nil
+}
+
+func (fs *failingSender) close() error { return nil }
+
+func TestBufferedSinkRetries(t *testing.T) {
+	sender := &failingSender{failures: 1}
+	writeSinkTestLines(t, newBufferedSink(SinkHTTP, sender, 10))
+	require.Len(t, sender.lines, 2)
+	require.Equal(t, uint64(1), sender.lines[0].seq)
+}
+
+func TestHTTPSinkDropsRejectedBatches(t *testing.T) {
+	statuses := []int{http.StatusBadRequest, http.StatusTooManyRequests, http.StatusNoContent}
+	var mutex sync.Mutex
+	var received []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mutex.Lock()
+		defer mutex.Unlock()
+		status := statuses[0]
+		if len(statuses) > 1 {
+			statuses = statuses[1:]
+		}
+		if status == http.StatusNoContent {
+			received = append(received, string(body))
+		}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	sender, err := newHTTPSender(srv.URL, "", nil)
+	require.NoError(t, err)
+	sink := newBufferedSink(SinkHTTP, sender, 10)
+	service := &LogsService{sinks: []logSink{sink}}
+
+	// The rejected batch is dropped and the next one retried until it is sent
+	sink.write(sinkTestLines[0], 1)
+	require.Eventually(t, func() bool { return sink.droppedBatches.Load() == 1 }, 5*time.Second, 10*time.Millisecond)
+	sink.write(sinkTestLines[1], 2)
+	require.Eventually(t, func() bool {
+		sent, dropped := sink.stats()
+		return sent == 1 && dropped == 1
+	}, 10*time.Second, 10*time.Millisecond)
+	require.NoError(t, sink.close(context.Background()))
+
+	mutex.Lock()
+	require.Len(t, received, 1)
+	require.Contains(t, received[0], "Linux version 6.1")
+	mutex.Unlock()
+
+	sinkMetrics := service.sinkMetrics()
+	require.Len(t, sinkMetrics, 3)
+	require.Equal(t, "remote_console_log_sink_dropped_batches_total", sinkMetrics[2].Name)
+	require.Equal(t, float64(1), sinkMetrics[2].Samples[0].Value)
+}
+
+func TestCloseSinksSendsQueuedLines(t *testing.T) {
+	sender := &failingSender{}
+	service := &LogsService{sinks: []logSink{newBufferedSink(SinkHTTP, sender, 10)}}
+	for i, line := range sinkTestLines {
+		service.sinks[0].write(line, uint64(i+1))
+	}
+	require.NoError(t, service.CloseSinks(context.Background()))
+	require.Len(t, sender.lines, 2)
+}
+
+// partialSender delivers one line of the first send before failing
+type partialSender struct {
+	failingSender
+}
+
+func (ps *partialSender) send(ctx context.Context, lines []sinkLine) error {
+	ps.mutex.Lock()
+	if ps.failures > 0 {
+		ps.failures--
+		ps.lines = append(ps.lines, lines[0])
+		ps.mutex.Unlock()
+		return &partialSendError{sent: 1, err: io.ErrClosedPipe}
+	}
+	ps.mutex.Unlock()
+	return ps.failingSender.send(ctx, lines)
+}
+
+func TestBufferedSinkResendsOnlyUnsentLines(t *testing.T) {
+	sender := &partialSender{failingSender{failures: 1}}
+	sink := &bufferedSink{sinkName: SinkSyslog, sender: sender}
+	batch := []sinkLine{{line: sinkTestLines[0], seq: 1}, {line: sinkTestLines[1], seq: 2}}
+	require.True(t, sink.deliver(context.Background(), batch))
+
+	sent, _ := sink.stats()
+	require.Equal(t, uint64(2), sent)
+	require.Len(t, sender.lines, 2)
+	require.Equal(t, uint64(1), sender.lines[0].seq)
+	require.Equal(t, uint64(2), sender.lines[1].seq)
+}
+
+// blockingSender blocks until the sink is closed
+type blockingSender struct{}
+
+func (blockingSender) send(ctx context.Context, _ []sinkLine) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (blockingSender) close() error { return nil }
+
+func TestBufferedSinkDropsWhenFull(t *testing.T) {
+	sink := newBufferedSink(SinkHTTP, blockingSender{}, 1)
+	for i := range 5 {
+		sink.write(sinkTestLines[0], uint64(i+1))
+	}
+
+	// One line is being sent and one queued, the rest are dropped
+	require.Eventually(t, func() bool {
+		_, dropped := sink.stats()
+		return dropped >= 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Closing gives up on the queued line once its context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	require.NoError(t, sink.close(ctx))
+}
+
+func TestSyslogSinkUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+
+	sender, err := newSyslogSender("udp://"+conn.LocalAddr().String(), "")
+	require.NoError(t, err)
+	sender.hostname = "rcs-0"
+	writeSinkTestLines(t, newBufferedSink(SinkSyslog, sender, 10))
+
+	buf := make([]byte, 2048)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	require.Equal(t, "<134>1 2026-03-04T05:06:07Z rcs-0 remote-console - x1000c0s0b0n0 - 2026-03-04 05:06:07 UTC login:", string(buf[:n]))
+}
+
+// readOctetCounted reads RFC 6587 octet-counted syslog messages
+func readOctetCounted(t *testing.T, conn net.Conn, count int) []string {
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	r := bufio.NewReader(conn)
+	var msgs []string
+	for range count {
+		length, err := r.ReadString(' ')
+		require.NoError(t, err)
+		n, err := strconv.Atoi(strings.TrimSpace(length))
+		require.NoError(t, err)
+		msg := make([]byte, n)
+		_, err = io.ReadFull(r, msg)
+		require.NoError(t, err)
+		msgs = append(msgs, string(msg))
+	}
+	return msgs
+}
+
+// acceptOne returns the first connection accepted by a listener, after the
+// TLS handshake the client waits for
+func acceptOne(ln net.Listener) <-chan net.Conn {
+	conns := make(chan net.Conn, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		if tc, ok := conn.(*tls.Conn); ok && tc.Handshake() != nil {
+			return
+		}
+		conns <- conn
+	}()
+	return conns
+}
+
+func TestSyslogSinkTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = ln.Close() }()
+	conns := acceptOne(ln)
+
+	sender, err := newSyslogSender("tcp://"+ln.Addr().String(), "")
+	require.NoError(t, err)
+	writeSinkTestLines(t, newBufferedSink(SinkSyslog, sender, 10))
+
+	msgs := readOctetCounted(t, <-conns, 2)
+	require.True(t, strings.HasPrefix(msgs[1], "<134>1 2026-03-04T05:06:08Z "))
+	require.True(t, strings.HasSuffix(msgs[1], " remote-console - x1000c0s1b0n0 - Linux version 6.1"))
+}
+
+func TestSyslogSinkTLS(t *testing.T) {
+	// The test server's certificate is valid for 127.0.0.1
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0600))
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", srv.TLS.Clone())
+	require.NoError(t, err)
+	defer func() { _ = ln.Close() }()
+	conns := acceptOne(ln)
+
+	sender, err := newSyslogSender("tls://"+ln.Addr().String(), caFile)
+	require.NoError(t, err)
+	writeSinkTestLines(t, newBufferedSink(SinkSyslog, sender, 10))
+
+	msgs := readOctetCounted(t, <-conns, 2)
+	require.Contains(t, msgs[0], " x1000c0s0b0n0 - 2026-03-04 05:06:07 UTC login:")
+}
+
+func TestHTTPSink(t *testing.T) {
+	type request struct {
+		contentType, orgID string
+		body               []byte
+	}
+	requests := make(chan request, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- request{r.Header.Get("Content-Type"), r.Header.Get("X-Scope-OrgID"), body}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	t.Run("jsonl", func(t *testing.T) {
+		sender, err := newHTTPSender(srv.URL, "", nil)
+		require.NoError(t, err)
+		writeSinkTestLines(t, newBufferedSink(SinkHTTP, sender, 10))
+
+		var lines []string
+		for len(lines) < 2 {
+			req := <-requests
+			require.Equal(t, "application/x-ndjson", req.contentType)
+			lines = append(lines, strings.Split(strings.TrimSpace(string(req.body)), "\n")...)
+		}
+		var frame LineFrame
+		require.NoError(t, json.Unmarshal([]byte(lines[0]), &frame))
+		require.Equal(t, sinkTestLines[0].Frame(1), frame)
+	})
+
+	t.Run("loki", func(t *testing.T) {
+		sender, err := newHTTPSender(srv.URL, PushFormatLoki, []string{"X-Scope-OrgID=tenant-1"})
+		require.NoError(t, err)
+		writeSinkTestLines(t, newBufferedSink(SinkHTTP, sender, 10))
+
+		values := make(map[string][][2]string)
+		for received := 0; received < 2; {
+			req := <-requests
+			require.Equal(t, "application/json", req.contentType)
+			require.Equal(t, "tenant-1", req.orgID)
+
+			var push lokiPush
+			require.NoError(t, json.Unmarshal(req.body, &push))
+			for _, stream := range push.Streams {
+				require.Equal(t, syslogAppName, stream.Stream["job"])
+				values[stream.Stream["node"]] = append(values[stream.Stream["node"]], stream.Values...)
+				received += len(stream.Values)
+			}
+		}
+		ts := strconv.FormatInt(sinkTestLines[1].Timestamp.UnixNano(), 10)
+		require.Equal(t, [][2]string{{ts, "Linux version 6.1"}}, values["x1000c0s1b0n0"])
+		require.Len(t, values["x1000c0s0b0n0"], 1)
+	})
+}
+
+func TestAggregationWithoutFileSink(t *testing.T) {
+	config := DefaultLogConfig()
+	config.ConsoleLogsBackupPath = filepath.Join(t.TempDir(), "conman.old")
+	config.AggLogsPath = t.TempDir()
+	config.AggLogsSinks = []string{SinkStdout}
+	service, err := NewLogsService(config)
+	require.NoError(t, err)
+
+	service.EnsureAggLog()
+	require.Empty(t, service.conAggLogFile)
+	entries, err := os.ReadDir(config.AggLogsPath)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	require.Len(t, service.sinks, 1)
+	require.NoError(t, service.sinks[0].close(context.Background()))
+}
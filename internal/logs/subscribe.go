@@ -0,0 +1,119 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// This file contains the live feed of aggregated console output
+
+package logs
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ConsoleLine is a line of console output read by log aggregation
+type ConsoleLine struct {
+	Node      string
+	Timestamp time.Time // conman's timestamp for the line when present, otherwise when it was read
+	Text      string
+	File      string // name of the log file the line was read from
+	Offset    int64  // start of the line in the file
+}
+
+// NewConsoleLine describes a line read from a node's log file at readTime
+func NewConsoleLine(xname, file, text string, offset int64, readTime time.Time) ConsoleLine {
+	line := ConsoleLine{Node: xname, Timestamp: readTime, Text: text, File: file, Offset: offset}
+	if ts, ok := ParseLineTimestamp(text); ok {
+		line.Timestamp = ts
+	}
+	return line
+}
+
+// LineFrame is the JSON framing of a console log line. It lets clients merge
+// streams by time, dedupe by node, file and offset, and spot gaps by sequence.
+type LineFrame struct {
+	Seq       uint64    `json:"seq"`
+	Node      string    `json:"node"`
+	Timestamp time.Time `json:"timestamp"` // UTC with nanoseconds
+	File      string    `json:"file"`
+	Offset    int64     `json:"offset"`
+	Text      string    `json:"text"`
+}
+
+// Frame returns the JSON framing of the line with a sequence number
+func (l ConsoleLine) Frame(seq uint64) LineFrame {
+	return LineFrame{
+		Seq:       seq,
+		Node:      l.Node,
+		Timestamp: l.Timestamp.UTC(),
+		File:      l.File,
+		Offset:    l.Offset,
+		Text:      l.Text,
+	}
+}
+
+// Subscription receives the console lines of the nodes it selects. Lines are
+// dropped rather than stalling aggregation when the subscriber falls behind.
+type Subscription struct {
+	lines   chan ConsoleLine
+	accept  func(xname string) bool
+	dropped atomic.Uint64
+}
+
+// Lines returns the channel the console lines are delivered on
+func (s *Subscription) Lines() <-chan ConsoleLine {
+	return s.lines
+}
+
+// Dropped returns the number of lines dropped because the subscriber fell behind
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// subscribers tracks the active subscriptions
+type subscribers struct {
+	mutex sync.RWMutex
+	subs  map[*Subscription]struct{}
+}
+
+// Subscribe starts delivering the console lines of the nodes accepted by accept,
+// buffering up to buffer lines
+func (ls *LogsService) Subscribe(accept func(xname string) bool, buffer int) *Subscription {
+	sub := &Subscription{
+		lines:  make(chan ConsoleLine, buffer),
+		accept: accept,
+	}
+
+	ls.subscribers.mutex.Lock()
+	defer ls.subscribers.mutex.Unlock()
+	if ls.subscribers.subs == nil {
+		ls.subscribers.subs = make(map[*Subscription]struct{})
+	}
+	ls.subscribers.subs[sub] = struct{}{}
+	return sub
+}
+
+// Unsubscribe stops delivery to the subscription
+func (ls *LogsService) Unsubscribe(sub *Subscription) {
+	ls.subscribers.mutex.Lock()
+	defer ls.subscribers.mutex.Unlock()
+	delete(ls.subscribers.subs, sub)
+}
+
+// publish delivers a console line to every subscription that selects its node
+func (ls *LogsService) publish(line ConsoleLine) {
+	ls.subscribers.mutex.RLock()
+	defer ls.subscribers.mutex.RUnlock()
+
+	for sub := range ls.subscribers.subs {
+		if !sub.accept(line.Node) {
+			continue
+		}
+		select {
+		case sub.lines <- line:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package logs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscribe(t *testing.T) {
+	service := &LogsService{}
+	readTime := time.Now()
+
+	sub := service.Subscribe(func(xname string) bool { return xname == "x1000c0s0b0n0" }, 2)
+
+	service.publish(NewConsoleLine("x1000c0s0b0n0", "console.x1000c0s0b0n0", "2026-03-04 05:06:07 UTC login:", 0, readTime))
+	service.publish(NewConsoleLine("x3000c0s1b0n0", "console.x3000c0s1b0n0", "not selected", 0, readTime))
+	service.publish(NewConsoleLine("x1000c0s0b0n0", "console.x1000c0s0b0n0", "no timestamp", 0, readTime))
+
+	line := <-sub.Lines()
+	require.Equal(t, "x1000c0s0b0n0", line.Node)
+	require.Equal(t, time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC), line.Timestamp)
+
+	line = <-sub.Lines()
+	require.Equal(t, "no timestamp", line.Text)
+	require.Equal(t, readTime, line.Timestamp)
+
+	// A subscriber that falls behind loses lines instead of blocking aggregation
+	for range 5 {
+		service.publish(NewConsoleLine("x1000c0s0b0n0", "console.x1000c0s0b0n0", "flood", 0, readTime))
+	}
+	require.Equal(t, uint64(3), sub.Dropped())
+
+	service.Unsubscribe(sub)
+	<-sub.Lines()
+	<-sub.Lines()
+	service.publish(NewConsoleLine("x1000c0s0b0n0", "console.x1000c0s0b0n0", "after unsubscribe", 0, readTime))
+	require.Empty(t, sub.Lines())
+}
@@ -0,0 +1,51 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// This file contains the parsing of the timestamps conman writes into the
+// console logs
+
+package logs
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+const conmanTimeLayout = "2006-01-02 15:04:05"
+
+var (
+	// Prefix written on every line with logopts="timestamp"
+	lineTimestampRegex = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2})(?: (UTC|GMT)\b)?`)
+
+	// Messages written by conman itself, such as the periodic marker from
+	// SERVER timestamp=1h: "<ConMan> Console [x1000c0s0b0n0] log at 2026-01-02 03:00:00 UTC."
+	markerTimestampRegex = regexp.MustCompile(`^<ConMan> .* at (\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2})(?: ([A-Z]{3,5}))?\.?$`)
+)
+
+// ParseLineTimestamp returns the time conman recorded on a console log line,
+// either as a per-line timestamp prefix or in a conman marker message. Conman
+// writes local time, so only UTC and GMT zone names are honored and every
+// other time is taken to be in the local time zone.
+func ParseLineTimestamp(line string) (time.Time, bool) {
+	line = strings.TrimLeft(line, "\r ")
+
+	m := lineTimestampRegex.FindStringSubmatch(line)
+	if m == nil {
+		m = markerTimestampRegex.FindStringSubmatch(strings.TrimRight(line, "\r "))
+	}
+	if m == nil {
+		return time.Time{}, false
+	}
+
+	loc := time.Local
+	if m[2] == "UTC" || m[2] == "GMT" {
+		loc = time.UTC
+	}
+	ts, err := time.ParseInLocation(conmanTimeLayout, m[1], loc)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return ts, true
+}
@@ -0,0 +1,41 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package logs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLineTimestamp(t *testing.T) {
+	local := time.Date(2026, 3, 4, 5, 6, 7, 0, time.Local)
+	utc := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		line     string
+		expected time.Time
+		ok       bool
+	}{
+		{"line prefix", "2026-03-04 05:06:07 [    0.000000] Linux version 6.1.0", local, true},
+		{"line prefix with zone", "2026-03-04 05:06:07 UTC login: ", utc, true},
+		{"marker", "<ConMan> Console [x1000c0s0b0n0] log at 2026-03-04 05:06:07 UTC.", utc, true},
+		{"marker with carriage return", "\r<ConMan> Console [x1000c0s0b0n0] log at 2026-03-04 05:06:07.\r", local, true},
+		{"word after timestamp is not a zone", "2026-03-04 05:06:07 EDAC MC0: 1 CE error", local, true},
+		{"no timestamp", "login: ", time.Time{}, false},
+		{"timestamp not at start", "booted at 2026-03-04 05:06:07", time.Time{}, false},
+		{"invalid date", "2026-13-04 05:06:07 login: ", time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts, ok := ParseLineTimestamp(tt.line)
+			require.Equal(t, tt.ok, ok)
+			require.True(t, tt.expected.Equal(ts), "expected %s, got %s", tt.expected, ts)
+		})
+	}
+}
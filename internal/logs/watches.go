@@ -0,0 +1,471 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// This file contains the pattern watches evaluated on console output and the
+// delivery of the resulting events
+
+package logs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/OpenCHAMI/remote-console/internal/nodes"
+)
+
+const (
+	// number of recent events kept in memory for the events API
+	maxRecentEvents = 1000
+
+	// number of events queued per webhook before new events are dropped
+	webhookQueueSize = 1000
+
+	defaultWebhookMaxRetries = 5
+	defaultWebhookTimeout    = 10 * time.Second
+	webhookInitialBackoff    = time.Second
+	webhookMaxBackoff        = time.Minute
+)
+
+// WatchRule raises an event when a console line matches its pattern
+type WatchRule struct {
+	Name     string   `yaml:"name" json:"name"`
+	Pattern  string   `yaml:"pattern" json:"pattern"`
+	Severity string   `yaml:"severity" json:"severity"`
+	Groups   []string `yaml:"groups" json:"groups,omitempty"` // SMD groups, empty applies to all nodes
+	Nodes    []string `yaml:"nodes" json:"nodes,omitempty"`   // xname glob patterns, empty applies to all nodes
+
+	regex *regexp.Regexp
+}
+
+// Webhook is an endpoint that watch events are POSTed to
+type Webhook struct {
+	URL        string            `yaml:"url"`
+	Headers    map[string]string `yaml:"headers"`
+	MaxRetries int               `yaml:"maxRetries"`
+	Timeout    string            `yaml:"timeout"`
+}
+
+// WatchConfig is the content of the watch rules file
+type WatchConfig struct {
+	Rules    []WatchRule `yaml:"rules"`
+	Webhooks []Webhook   `yaml:"webhooks"`
+}
+
+// WatchEvent is produced when a console line matches a watch rule
+type WatchEvent struct {
+	ID        uint64    `json:"id"`
+	Node      string    `json:"node"`
+	Rule      string    `json:"rule"`
+	Severity  string    `json:"severity,omitempty"`
+	Line      string    `json:"line"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// EventFilter selects events from the recent events list. Zero values match everything.
+type EventFilter struct {
+	Node   string
+	Rule   string
+	Since  time.Time
+	Accept func(xname string) bool // selects the nodes whose events are returned, nil for all
+	Limit  int                     // the most recent events matched, 0 for all
+}
+
+// LoadWatchConfig reads and validates a watch rules file
+func LoadWatchConfig(filename string) (*WatchConfig, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read watch rules file: %w", err)
+	}
+
+	var config WatchConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse watch rules file %q: %w", filename, err)
+	}
+
+	if err := config.compile(); err != nil {
+		return nil, fmt.Errorf("invalid watch rules file %q: %w", filename, err)
+	}
+
+	return &config, nil
+}
+
+func (wc *WatchConfig) compile() error {
+	for i := range wc.Rules {
+		rule := &wc.Rules[i]
+		if rule.Name == "" {
+			return fmt.Errorf("rule %d has no name", i)
+		}
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return fmt.Errorf("rule %s has invalid pattern: %w", rule.Name, err)
+		}
+		rule.regex = re
+		for _, pattern := range rule.Nodes {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("rule %s has invalid node pattern %q: %w", rule.Name, pattern, err)
+			}
+		}
+	}
+
+	for i, hook := range wc.Webhooks {
+		if hook.URL == "" {
+			return fmt.Errorf("webhook %d has no url", i)
+		}
+		if hook.Timeout != "" {
+			if _, err := time.ParseDuration(hook.Timeout); err != nil {
+				return fmt.Errorf("webhook %s has invalid timeout: %w", hook.URL, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// appliesTo reports whether the rule should be evaluated for xname
+func (rule *WatchRule) appliesTo(xname string) bool {
+	if len(rule.Nodes) == 0 && len(rule.Groups) == 0 {
+		return true
+	}
+	for _, pattern := range rule.Nodes {
+		if ok, _ := path.Match(pattern, xname); ok {
+			return true
+		}
+	}
+	for _, group := range rule.Groups {
+		if nodes.IsGroupMember(group, xname) {
+			return true
+		}
+	}
+	return false
+}
+
+// watchEngine evaluates the rules and fans events out to the event log,
+// the recent events list and the webhooks
+type watchEngine struct {
+	rules []WatchRule
+
+	mutex       sync.Mutex
+	nextID      uint64
+	recent      []WatchEvent // ring buffer of the most recent events
+	recentStart int
+	eventLog    *os.File
+	logSize     int64 // size of the event log
+	logMaxSize  int64 // size past which the event log is rotated
+
+	webhooks []*webhookSender
+}
+
+// newWatchEngine builds the engine for the rules, appending events to the
+// event log at eventLogPath when set. The event log is rotated to
+// <eventLogPath>.1 once it would grow past eventLogMaxSize.
+func newWatchEngine(config *WatchConfig, eventLogPath string, eventLogMaxSize int64) (*watchEngine, error) {
+	we := &watchEngine{
+		rules:      config.Rules,
+		nextID:     1,
+		logMaxSize: eventLogMaxSize,
+	}
+
+	if eventLogPath != "" {
+		if err := os.MkdirAll(filepath.Dir(eventLogPath), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create event log directory: %w", err)
+		}
+		if err := we.openEventLog(eventLogPath); err != nil {
+			return nil, fmt.Errorf("failed to open event log %q: %w", eventLogPath, err)
+		}
+	}
+
+	for _, hook := range config.Webhooks {
+		we.webhooks = append(we.webhooks, newWebhookSender(hook))
+	}
+
+	return we, nil
+}
+
+// evaluate checks a console line against every rule that applies to the node
+func (we *watchEngine) evaluate(xname, line string, timestamp time.Time) {
+	for i := range we.rules {
+		rule := &we.rules[i]
+		if !rule.regex.MatchString(line) || !rule.appliesTo(xname) {
+			continue
+		}
+
+		we.record(WatchEvent{
+			Node:      xname,
+			Rule:      rule.Name,
+			Severity:  rule.Severity,
+			Line:      line,
+			Timestamp: timestamp.UTC(),
+		})
+	}
+}
+
+func (we *watchEngine) record(event WatchEvent) {
+	we.mutex.Lock()
+	event.ID = we.nextID
+	we.nextID++
+
+	if len(we.recent) < maxRecentEvents {
+		we.recent = append(we.recent, event)
+	} else {
+		we.recent[we.recentStart] = event
+		we.recentStart = (we.recentStart + 1) % maxRecentEvents
+	}
+
+	if we.eventLog != nil {
+		if err := we.writeEvent(event); err != nil {
+			slog.Error("Failed to write watch event to event log", "error", err)
+		}
+	}
+	we.mutex.Unlock()
+
+	slog.Info("Console watch rule matched", "xname", event.Node, "rule", event.Rule, "severity", event.Severity)
+
+	for _, hook := range we.webhooks {
+		hook.enqueue(event)
+	}
+}
+
+// openEventLog opens the event log for appending
+func (we *watchEngine) openEventLog(path string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	we.eventLog = f
+	we.logSize = info.Size()
+	return nil
+}
+
+// writeEvent appends an event to the event log, rotating the log first when
+// the event would take it past its size limit
+func (we *watchEngine) writeEvent(event WatchEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if we.logMaxSize > 0 && we.logSize > 0 && we.logSize+int64(len(data)) > we.logMaxSize {
+		if err := we.rotateEventLog(); err != nil {
+			return fmt.Errorf("failed to rotate event log: %w", err)
+		}
+	}
+
+	n, err := we.eventLog.Write(data)
+	we.logSize += int64(n)
+	return err
+}
+
+// rotateEventLog moves the event log to <path>.1, replacing the previous
+// rotated log, and starts a new one. The log is reopened even when it
+// couldn't be moved, so events keep being written.
+func (we *watchEngine) rotateEventLog() error {
+	path := we.eventLog.Name()
+	if err := we.eventLog.Close(); err != nil {
+		slog.Warn("Failed to close event log", "error", err)
+	}
+	we.eventLog = nil
+
+	renameErr := os.Rename(path, path+".1")
+	if err := we.openEventLog(path); err != nil {
+		return err
+	}
+	return renameErr
+}
+
+// events returns the recent events matching the filter, oldest first
+func (we *watchEngine) events(filter EventFilter) []WatchEvent {
+	we.mutex.Lock()
+	defer we.mutex.Unlock()
+
+	var matched []WatchEvent
+	for i := range we.recent {
+		event := we.recent[(we.recentStart+i)%len(we.recent)]
+		if filter.Node != "" && event.Node != filter.Node {
+			continue
+		}
+		if filter.Rule != "" && event.Rule != filter.Rule {
+			continue
+		}
+		if !filter.Since.IsZero() && event.Timestamp.Before(filter.Since) {
+			continue
+		}
+		if filter.Accept != nil && !filter.Accept(event.Node) {
+			continue
+		}
+		matched = append(matched, event)
+	}
+
+	if filter.Limit > 0 && len(matched) > filter.Limit {
+		matched = matched[len(matched)-filter.Limit:]
+	}
+	return matched
+}
+
+// start runs the webhook delivery loops until the context is cancelled
+func (we *watchEngine) start(ctx context.Context) {
+	for _, hook := range we.webhooks {
+		go hook.run(ctx)
+	}
+}
+
+func (we *watchEngine) close() {
+	we.mutex.Lock()
+	defer we.mutex.Unlock()
+
+	if we.eventLog != nil {
+		if err := we.eventLog.Close(); err != nil {
+			slog.Warn("Failed to close event log", "error", err)
+		}
+		we.eventLog = nil
+	}
+}
+
+// webhookSender delivers events to a single webhook with retries
+type webhookSender struct {
+	hook       Webhook
+	client     *http.Client
+	maxRetries int
+	queue      chan WatchEvent
+}
+
+func newWebhookSender(hook Webhook) *webhookSender {
+	timeout := defaultWebhookTimeout
+	if hook.Timeout != "" {
+		// validated when the config was loaded
+		timeout, _ = time.ParseDuration(hook.Timeout)
+	}
+
+	maxRetries := hook.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = defaultWebhookMaxRetries
+	}
+
+	return &webhookSender{
+		hook:       hook,
+		client:     &http.Client{Timeout: timeout},
+		maxRetries: maxRetries,
+		queue:      make(chan WatchEvent, webhookQueueSize),
+	}
+}
+
+func (ws *webhookSender) enqueue(event WatchEvent) {
+	select {
+	case ws.queue <- event:
+	default:
+		slog.Warn("Webhook queue full, dropping watch event", "url", ws.hook.URL, "eventID", event.ID)
+	}
+}
+
+func (ws *webhookSender) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-ws.queue:
+			ws.deliver(ctx, event)
+		}
+	}
+}
+
+// deliver POSTs the event, retrying with exponential backoff
+func (ws *webhookSender) deliver(ctx context.Context, event WatchEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		slog.Error("Failed to encode watch event for webhook", "error", err)
+		return
+	}
+
+	backoff := webhookInitialBackoff
+	for attempt := 0; attempt <= ws.maxRetries; attempt++ {
+		err = ws.post(ctx, body)
+		if err == nil {
+			return
+		}
+
+		slog.Warn("Failed to deliver watch event to webhook", "url", ws.hook.URL, "eventID", event.ID, "attempt", attempt+1, "error", err)
+		if attempt == ws.maxRetries {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, webhookMaxBackoff)
+	}
+
+	slog.Error("Giving up delivering watch event to webhook", "url", ws.hook.URL, "eventID", event.ID, "error", err)
+}
+
+func (ws *webhookSender) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ws.hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range ws.hook.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := ws.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			slog.Debug("Failed to close webhook response body", "error", err)
+		}
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Events returns the recent watch events matching the filter, oldest first
+func (ls *LogsService) Events(filter EventFilter) []WatchEvent {
+	if ls.watches == nil {
+		return nil
+	}
+	return ls.watches.events(filter)
+}
+
+// RunWatches delivers watch events to the webhooks until ctx is cancelled,
+// then closes the event log
+func (ls *LogsService) RunWatches(ctx context.Context) {
+	if ls.watches == nil {
+		return
+	}
+	ls.watches.start(ctx)
+	<-ctx.Done()
+	ls.watches.close()
+}
+
+// WatchRules returns the configured watch rules
+func (ls *LogsService) WatchRules() []WatchRule {
+	if ls.watches == nil {
+		return nil
+	}
+	return ls.watches.rules
+}
@@ -0,0 +1,205 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package logs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadWatchConfig(t *testing.T) {
+	tempDir := t.TempDir()
+	rulesFile := filepath.Join(tempDir, "watches.yaml")
+	require.NoError(t, os.WriteFile(rulesFile, []byte(`
+rules:
+  - name: kernel-panic
+    pattern: "Kernel panic"
+    severity: critical
+  - name: oom
+    pattern: "Out of memory"
+    nodes: ["x1000*"]
+webhooks:
+  - url: http://localhost:9999/hook
+    timeout: 2s
+`), 0600))
+
+	config, err := LoadWatchConfig(rulesFile)
+	require.NoError(t, err)
+	require.Len(t, config.Rules, 2)
+	require.Len(t, config.Webhooks, 1)
+
+	require.NoError(t, os.WriteFile(rulesFile, []byte(`
+rules:
+  - name: broken
+    pattern: "("
+`), 0600))
+	_, err = LoadWatchConfig(rulesFile)
+	require.Error(t, err)
+}
+
+func TestWatchEngineEvaluate(t *testing.T) {
+	tempDir := t.TempDir()
+	eventLog := filepath.Join(tempDir, "events", "events.log")
+
+	config := &WatchConfig{Rules: []WatchRule{
+		{Name: "kernel-panic", Pattern: "Kernel panic", Severity: "critical"},
+		{Name: "oom", Pattern: "Out of memory", Nodes: []string{"x1000*"}},
+	}}
+	require.NoError(t, config.compile())
+
+	engine, err := newWatchEngine(config, eventLog, 0)
+	require.NoError(t, err)
+	defer engine.close()
+
+	now := time.Now()
+	engine.evaluate("x1000c0s0b0n0", "Kernel panic - not syncing: Fatal exception", now)
+	engine.evaluate("x3000c0s1b0n0", "Out of memory: Killed process 1234", now)
+	engine.evaluate("x1000c0s1b0n0", "Out of memory: Killed process 42", now)
+	engine.evaluate("x1000c0s1b0n0", "login: ", now)
+
+	events := engine.events(EventFilter{})
+	require.Len(t, events, 2)
+	require.Equal(t, "kernel-panic", events[0].Rule)
+	require.Equal(t, "critical", events[0].Severity)
+	require.Equal(t, "oom", events[1].Rule)
+	require.Equal(t, "x1000c0s1b0n0", events[1].Node)
+
+	require.Len(t, engine.events(EventFilter{Node: "x1000c0s0b0n0"}), 1)
+	require.Len(t, engine.events(EventFilter{Rule: "oom"}), 1)
+	require.Empty(t, engine.events(EventFilter{Since: now.Add(time.Minute)}))
+	require.Equal(t, uint64(2), engine.events(EventFilter{Limit: 1})[0].ID)
+
+	// The limit applies to the events of the accepted nodes
+	accepted := engine.events(EventFilter{Accept: func(xname string) bool { return xname == "x1000c0s0b0n0" }, Limit: 1})
+	require.Len(t, accepted, 1)
+	require.Equal(t, uint64(1), accepted[0].ID)
+
+	data, err := os.ReadFile(eventLog)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+
+	var logged WatchEvent
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &logged))
+	require.Equal(t, "x1000c0s0b0n0", logged.Node)
+}
+
+func TestWatchEngineRecentEventsBounded(t *testing.T) {
+	config := &WatchConfig{Rules: []WatchRule{{Name: "any", Pattern: "."}}}
+	require.NoError(t, config.compile())
+
+	engine, err := newWatchEngine(config, "", 0)
+	require.NoError(t, err)
+
+	for i := 0; i < maxRecentEvents+10; i++ {
+		engine.evaluate("x1000c0s0b0n0", "line", time.Now())
+	}
+
+	events := engine.events(EventFilter{})
+	require.Len(t, events, maxRecentEvents)
+	require.Equal(t, uint64(11), events[0].ID, "oldest events should be discarded first")
+	require.Equal(t, uint64(maxRecentEvents+10), events[len(events)-1].ID)
+}
+
+func TestWebhookRetry(t *testing.T) {
+	var attempts atomic.Int32
+	received := make(chan WatchEvent, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "secret", r.Header.Get("X-Token"))
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var event WatchEvent
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		received <- event
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sender := newWebhookSender(Webhook{URL: server.URL, Headers: map[string]string{"X-Token": "secret"}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go sender.run(ctx)
+
+	sender.enqueue(WatchEvent{ID: 7, Node: "x1000c0s0b0n0", Rule: "kernel-panic"})
+
+	select {
+	case event := <-received:
+		require.Equal(t, uint64(7), event.ID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+	require.Equal(t, int32(2), attempts.Load())
+}
+
+func TestRunWatchesClosesEventLog(t *testing.T) {
+	config := &WatchConfig{Rules: []WatchRule{{Name: "any", Pattern: "."}}}
+	require.NoError(t, config.compile())
+	engine, err := newWatchEngine(config, filepath.Join(t.TempDir(), "events.log"), 0)
+	require.NoError(t, err)
+	service := &LogsService{watches: engine}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		service.RunWatches(ctx)
+		close(done)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watches kept running after shutdown")
+	}
+
+	// Events are still kept once the event log is closed
+	engine.evaluate("x1000c0s0b0n0", "late line", time.Now())
+	require.Nil(t, engine.eventLog)
+	require.Len(t, engine.events(EventFilter{}), 1)
+}
+
+func TestWatchEngineEventLogRotation(t *testing.T) {
+	config := &WatchConfig{Rules: []WatchRule{{Name: "any", Pattern: "."}}}
+	require.NoError(t, config.compile())
+	eventLog := filepath.Join(t.TempDir(), "events.log")
+
+	// Room for two events per log
+	event, err := json.Marshal(WatchEvent{ID: 1, Node: "x1000c0s0b0n0", Rule: "any", Line: "line", Timestamp: time.Unix(0, 0).UTC()})
+	require.NoError(t, err)
+	engine, err := newWatchEngine(config, eventLog, int64(2*(len(event)+1)))
+	require.NoError(t, err)
+	defer engine.close()
+
+	for range 5 {
+		engine.evaluate("x1000c0s0b0n0", "line", time.Unix(0, 0))
+	}
+
+	readIDs := func(path string) []uint64 {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		var ids []uint64
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			var logged WatchEvent
+			require.NoError(t, json.Unmarshal([]byte(line), &logged))
+			ids = append(ids, logged.ID)
+		}
+		return ids
+	}
+	require.Equal(t, []uint64{5}, readIDs(eventLog))
+	require.Equal(t, []uint64{3, 4}, readIDs(eventLog+".1"))
+}
@@ -0,0 +1,117 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// Package metrics collects service metrics and writes them in the Prometheus
+// text exposition format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Metric types
+const (
+	TypeCounter = "counter"
+	TypeGauge   = "gauge"
+)
+
+// Metric is a named set of samples
+type Metric struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []Sample
+}
+
+// Sample is one value of a metric, told apart from the others by its labels
+type Sample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+// Collector returns the current value of a group of metrics
+type Collector func() []Metric
+
+var (
+	mutex      sync.Mutex
+	collectors []Collector
+)
+
+// Register adds a collector to those written by Write
+func Register(c Collector) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	collectors = append(collectors, c)
+}
+
+// Bool returns 1 for true and 0 for false
+func Bool(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Write writes the metrics of every registered collector, ordered by name
+func Write(w io.Writer) error {
+	mutex.Lock()
+	registered := slices.Clone(collectors)
+	mutex.Unlock()
+
+	var all []Metric
+	for _, c := range registered {
+		all = append(all, c()...)
+	}
+	slices.SortStableFunc(all, func(a, b Metric) int { return strings.Compare(a.Name, b.Name) })
+
+	bw := bufio.NewWriter(w)
+	for _, m := range all {
+		_, _ = fmt.Fprintf(bw, "# HELP %s %s\n", m.Name, escapeHelp(m.Help))
+		_, _ = fmt.Fprintf(bw, "# TYPE %s %s\n", m.Name, m.Type)
+		for _, s := range m.Samples {
+			_, _ = fmt.Fprintf(bw, "%s%s %s\n", m.Name, formatLabels(s.Labels), formatValue(s.Value))
+		}
+	}
+	return bw.Flush()
+}
+
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(labels))
+	for _, name := range slices.Sorted(maps.Keys(labels)) {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, name, labelEscaper.Replace(labels[name])))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(help string) string {
+	return helpEscaper.Replace(help)
+}
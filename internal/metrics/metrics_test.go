@@ -0,0 +1,44 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package metrics
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	Register(func() []Metric {
+		return []Metric{{
+			Name: "test_volume_free_ratio",
+			Help: "Free space of a volume.",
+			Type: TypeGauge,
+			Samples: []Sample{
+				{Labels: map[string]string{"path": `/var/log/"conman"`, "device": "sda"}, Value: 0.25},
+				{Labels: map[string]string{"path": "/tmp", "device": "tmpfs"}, Value: math.Inf(1)},
+			},
+		}}
+	})
+	Register(func() []Metric {
+		return []Metric{{Name: "test_evictions_total", Help: "Files evicted.\nEver.", Type: TypeCounter, Samples: []Sample{{Value: 3}}}}
+	})
+
+	var out strings.Builder
+	require.NoError(t, Write(&out))
+	require.Equal(t, `# HELP test_evictions_total Files evicted.\nEver.
+# TYPE test_evictions_total counter
+test_evictions_total 3
+# HELP test_volume_free_ratio Free space of a volume.
+# TYPE test_volume_free_ratio gauge
+test_volume_free_ratio{device="sda",path="/var/log/\"conman\""} 0.25
+test_volume_free_ratio{device="tmpfs",path="/tmp"} +Inf
+`, out.String())
+
+	require.Equal(t, float64(1), Bool(true))
+	require.Equal(t, float64(0), Bool(false))
+}
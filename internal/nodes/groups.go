@@ -0,0 +1,83 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package nodes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+)
+
+type smdGroup struct {
+	Label   string `json:"label"`
+	Members struct {
+		IDs []string `json:"ids"`
+	} `json:"members"`
+}
+
+var groupsMutex sync.RWMutex
+
+// group label -> set of member xnames
+var groupMembers = make(map[string]map[string]struct{})
+
+func getGroups(ctx context.Context, httpClient *http.Client, smdURL string) ([]smdGroup, error) {
+	var response []smdGroup
+
+	// Query smd to get the group memberships
+	URL := smdURL + "hsm/v2/groups"
+	data, statusCode, err := getURL(ctx, httpClient, URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get groups from hsm: %w", err)
+	}
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code getting groups from hsm: %d", statusCode)
+	}
+
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal groups response: %w", err)
+	}
+
+	return response, nil
+}
+
+func updateGroups(groups []smdGroup) {
+	members := make(map[string]map[string]struct{}, len(groups))
+	for _, g := range groups {
+		set := make(map[string]struct{}, len(g.Members.IDs))
+		for _, id := range g.Members.IDs {
+			set[id] = struct{}{}
+		}
+		members[g.Label] = set
+	}
+
+	groupsMutex.Lock()
+	defer groupsMutex.Unlock()
+	groupMembers = members
+}
+
+// IsGroupMember reports whether xname is a member of the SMD group
+func IsGroupMember(group, xname string) bool {
+	groupsMutex.RLock()
+	defer groupsMutex.RUnlock()
+
+	_, ok := groupMembers[group][xname]
+	return ok
+}
+
+// GroupMembers returns the sorted members of the SMD group
+func GroupMembers(group string) []string {
+	groupsMutex.RLock()
+	defer groupsMutex.RUnlock()
+
+	ids := make([]string, 0, len(groupMembers[group]))
+	for id := range groupMembers[group] {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
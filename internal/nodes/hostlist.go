@@ -0,0 +1,151 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package nodes
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxHostlistSize bounds the expansion of a single hostlist expression
+const maxHostlistSize = 100000
+
+// ExpandHostlist expands a hostlist expression such as
+// "x1000c0s[0-7]b0n[0,1],x3000c0s1b0n0" into the individual names. Ranges
+// keep the zero padding of their lower bound, so "n[00-02]" expands to
+// n00, n01 and n02. Duplicate names are removed while preserving order.
+func ExpandHostlist(expr string) ([]string, error) {
+	var names []string
+	seen := make(map[string]struct{})
+
+	for _, item := range splitHostlist(expr) {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		expanded, err := expandHostlistItem(item)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, name := range expanded {
+			if _, ok := seen[name]; ok {
+				continue
+			}
+			seen[name] = struct{}{}
+			names = append(names, name)
+			if len(names) > maxHostlistSize {
+				return nil, fmt.Errorf("hostlist expands to more than %d names", maxHostlistSize)
+			}
+		}
+	}
+
+	return names, nil
+}
+
+// splitHostlist splits on commas that are not inside brackets
+func splitHostlist(expr string) []string {
+	var items []string
+	depth := 0
+	start := 0
+	for i, c := range expr {
+		switch c {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				items = append(items, expr[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(items, expr[start:])
+}
+
+// expandHostlistItem expands a single comma free item, which may contain any
+// number of bracketed range sets
+func expandHostlistItem(item string) ([]string, error) {
+	open := strings.IndexByte(item, '[')
+	if open == -1 {
+		if strings.ContainsRune(item, ']') {
+			return nil, fmt.Errorf("unbalanced brackets in hostlist %q", item)
+		}
+		return []string{item}, nil
+	}
+
+	closing := strings.IndexByte(item[open:], ']')
+	if closing == -1 {
+		return nil, fmt.Errorf("unbalanced brackets in hostlist %q", item)
+	}
+	closing += open
+
+	prefix := item[:open]
+	suffixes, err := expandHostlistItem(item[closing+1:])
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := expandRangeSet(item[open+1 : closing])
+	if err != nil {
+		return nil, fmt.Errorf("invalid range in hostlist %q: %w", item, err)
+	}
+
+	if len(values)*len(suffixes) > maxHostlistSize {
+		return nil, fmt.Errorf("hostlist expands to more than %d names", maxHostlistSize)
+	}
+
+	names := make([]string, 0, len(values)*len(suffixes))
+	for _, v := range values {
+		for _, suffix := range suffixes {
+			names = append(names, prefix+v+suffix)
+		}
+	}
+	return names, nil
+}
+
+// expandRangeSet expands the contents of a bracket such as "0-3,7,10-11"
+func expandRangeSet(set string) ([]string, error) {
+	var values []string
+	for _, part := range strings.Split(set, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return nil, fmt.Errorf("empty range")
+		}
+
+		lo, hi, isRange := strings.Cut(part, "-")
+		if !isRange {
+			if _, err := strconv.Atoi(part); err != nil {
+				return nil, fmt.Errorf("invalid number %q", part)
+			}
+			values = append(values, part)
+			continue
+		}
+
+		start, err := strconv.Atoi(lo)
+		if err != nil {
+			return nil, fmt.Errorf("invalid range start %q", lo)
+		}
+		end, err := strconv.Atoi(hi)
+		if err != nil {
+			return nil, fmt.Errorf("invalid range end %q", hi)
+		}
+		if end < start {
+			return nil, fmt.Errorf("range %q is descending", part)
+		}
+		if end-start >= maxHostlistSize {
+			return nil, fmt.Errorf("range %q is too large", part)
+		}
+
+		width := len(lo)
+		for i := start; i <= end; i++ {
+			values = append(values, fmt.Sprintf("%0*d", width, i))
+		}
+	}
+	return values, nil
+}
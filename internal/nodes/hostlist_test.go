@@ -0,0 +1,43 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package nodes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandHostlist(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected []string
+	}{
+		{"x3000c0s1b0n0", []string{"x3000c0s1b0n0"}},
+		{"x1000c0s[0-2]b0n0", []string{"x1000c0s0b0n0", "x1000c0s1b0n0", "x1000c0s2b0n0"}},
+		{"x1000c0s[0,3]b0n[0-1]", []string{"x1000c0s0b0n0", "x1000c0s0b0n1", "x1000c0s3b0n0", "x1000c0s3b0n1"}},
+		{"nid[008-010]", []string{"nid008", "nid009", "nid010"}},
+		{"x1,x2,x1", []string{"x1", "x2"}},
+		{"x1000c0s[0-1]b0n0, x3000c0s1b0n0", []string{"x1000c0s0b0n0", "x1000c0s1b0n0", "x3000c0s1b0n0"}},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			names, err := ExpandHostlist(tt.expr)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, names)
+		})
+	}
+}
+
+func TestExpandHostlistInvalid(t *testing.T) {
+	for _, expr := range []string{"x[0-", "x0-1]", "x[5-1]", "x[a-b]", "x[1,,2]", "x[0-999999999]"} {
+		t.Run(expr, func(t *testing.T) {
+			_, err := ExpandHostlist(expr)
+			require.Error(t, err)
+		})
+	}
+}
@@ -307,6 +307,24 @@ func CheckForUpdates(ctx context.Context, httpClient *http.Client, smdURL string
 
 	changed = updateNodes(fetched_nodes)
 
+	// Group membership is only used for authorization and selection, so a
+	// failure here is not fatal and does not affect the console configuration
+	groups, err := getGroups(ctx, httpClient, smdURL)
+	if err != nil {
+		slog.Warn("Error getting groups from SMD", "error", err)
+	} else {
+		updateGroups(groups)
+	}
+
+	// Vendors only select default credentials, so the last known vendors are
+	// kept when the inventory can't be read
+	hardware, err := getHardware(ctx, httpClient, smdURL)
+	if err != nil {
+		slog.Warn("Error getting hardware inventory from SMD", "error", err)
+	} else {
+		updateVendors(hardware)
+	}
+
 	slog.Info("Completed getting current nodes from SMD")
 
 	return changed
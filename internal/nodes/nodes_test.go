@@ -5,6 +5,7 @@
 package nodes
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -156,3 +157,38 @@ func TestUpdateNodesNoChange(t *testing.T) {
 	require.Contains(t, currentNodes, "x0c0s1b0")
 	require.Contains(t, currentNodes, "x0c0s1b1")
 }
+
+func TestUpdateGroups(t *testing.T) {
+	var compute smdGroup
+	compute.Label = "compute"
+	compute.Members.IDs = []string{"x0c0s2b0n0", "x0c0s1b0n0"}
+
+	updateGroups([]smdGroup{compute})
+
+	require.True(t, IsGroupMember("compute", "x0c0s1b0n0"))
+	require.False(t, IsGroupMember("compute", "x0c0s3b0n0"))
+	require.False(t, IsGroupMember("missing", "x0c0s1b0n0"))
+	require.Equal(t, []string{"x0c0s1b0n0", "x0c0s2b0n0"}, GroupMembers("compute"))
+
+	// A refresh replaces the previous membership
+	updateGroups(nil)
+	require.False(t, IsGroupMember("compute", "x0c0s1b0n0"))
+}
+
+func TestUpdateVendors(t *testing.T) {
+	var hardware []smdHardware
+	require.NoError(t, json.Unmarshal([]byte(`[
+		{"ID": "x0c0s1b0n0", "Type": "Node", "PopulatedFRU": {"Type": "Node", "NodeFRUInfo": {"Manufacturer": "HPE ", "Model": "XL675d"}}},
+		{"ID": "x0c0s2b0", "Type": "NodeBMC", "PopulatedFRU": {"Type": "NodeBMC", "NodeBMCFRUInfo": {"Manufacturer": "Gigabyte"}}},
+		{"ID": "x0c0s3b0n0", "Type": "Node"}
+	]`), &hardware))
+
+	updateVendors(hardware)
+
+	require.Equal(t, "HPE", Vendor("x0c0s1b0n0"))
+	require.Equal(t, "Gigabyte", Vendor("x0c0s2b0"))
+	require.Equal(t, "", Vendor("x0c0s3b0n0"))
+
+	updateVendors(nil)
+	require.Equal(t, "", Vendor("x0c0s1b0n0"))
+}
@@ -0,0 +1,83 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package nodes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// smdHardware is an entry of the SMD hardware inventory. The FRU info is
+// under a key named for the component type, such as NodeFRUInfo.
+type smdHardware struct {
+	ID           string                     `json:"ID"`
+	PopulatedFRU map[string]json.RawMessage `json:"PopulatedFRU"`
+}
+
+var vendorsMutex sync.RWMutex
+
+// xname -> manufacturer
+var nodeVendors = make(map[string]string)
+
+func getHardware(ctx context.Context, httpClient *http.Client, smdURL string) ([]smdHardware, error) {
+	var response []smdHardware
+
+	// Query smd for the nodes and BMCs, which consoles are named for
+	URL := smdURL + "hsm/v2/Inventory/Hardware?type=Node&type=NodeBMC"
+	data, statusCode, err := getURL(ctx, httpClient, URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get hardware inventory from hsm: %w", err)
+	}
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code getting hardware inventory from hsm: %d", statusCode)
+	}
+
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal hardware inventory response: %w", err)
+	}
+
+	return response, nil
+}
+
+// manufacturer returns the manufacturer from the FRU info of the hardware
+func (hw smdHardware) manufacturer() string {
+	for key, raw := range hw.PopulatedFRU {
+		if !strings.HasSuffix(key, "FRUInfo") {
+			continue
+		}
+		var info struct {
+			Manufacturer string `json:"Manufacturer"`
+		}
+		if err := json.Unmarshal(raw, &info); err == nil && info.Manufacturer != "" {
+			return strings.TrimSpace(info.Manufacturer)
+		}
+	}
+	return ""
+}
+
+func updateVendors(hardware []smdHardware) {
+	vendors := make(map[string]string, len(hardware))
+	for _, hw := range hardware {
+		if vendor := hw.manufacturer(); vendor != "" {
+			vendors[hw.ID] = vendor
+		}
+	}
+
+	vendorsMutex.Lock()
+	defer vendorsMutex.Unlock()
+	nodeVendors = vendors
+}
+
+// Vendor returns the manufacturer SMD reports for xname, or an empty string
+func Vendor(xname string) string {
+	vendorsMutex.RLock()
+	defer vendorsMutex.RUnlock()
+
+	return nodeVendors[xname]
+}
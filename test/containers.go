@@ -13,10 +13,12 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/testcontainers/testcontainers-go"
+	tcexec "github.com/testcontainers/testcontainers-go/exec"
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
 	"github.com/testcontainers/testcontainers-go/modules/vault"
 	"github.com/testcontainers/testcontainers-go/wait"
@@ -112,6 +114,21 @@ func loadSSHKeysIntoVault(ctx context.Context, vaultContainer testcontainers.Con
 	return nil
 }
 
+// execVault runs a vault command in the Vault container and returns its output
+func execVault(ctx context.Context, vaultContainer testcontainers.Container, args ...string) (string, error) {
+	exitCode, reader, err := vaultContainer.Exec(ctx, append([]string{"vault"}, args...), tcexec.Multiplexed())
+	if err != nil {
+		return "", fmt.Errorf("failed to exec vault command: %w", err)
+	}
+
+	output, _ := io.ReadAll(reader)
+	if exitCode != 0 {
+		return "", fmt.Errorf("vault %s failed: exit code %d, output: %s", args[0], exitCode, string(output))
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
 // setConsoleCredentials sets console credentials for a given xname in Vault
 func setConsoleCredentials(ctx context.Context, vaultContainer testcontainers.Container, xname, username, password string) error {
 
@@ -514,8 +531,6 @@ func startRemoteConsoleWithEnv(ctx context.Context, envOverrides map[string]stri
 			"RCS_CONSOLE_LOGS_FILE_SIZE":     "5M", // Small size to trigger rotation easily
 			"RCS_CONSOLE_LOGS_NUM_ROTATE":    "2",  // Keep 2 rotated files
 			"RCS_CONSOLE_LOGS_BACKUP_PATH":   "/tmp/conman.old",
-			"RCS_LOG_ROTATE_FILE_PATH":       "/tmp/logrotate.conman",
-			"RCS_LOG_ROTATE_STATE_FILE_PATH": "/tmp/rot_conman.state",
 		},
 		ExposedPorts: []string{"26776/tcp"},
 		WaitingFor: wait.ForHTTP("/remote-console/readiness").
@@ -0,0 +1,67 @@
+// Copyright © 2026 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/OpenCHAMI/remote-console/internal/creds"
+)
+
+const vaultAppRolePolicy = `path "hms-creds/*" {
+  capabilities = ["read", "list"]
+}
+`
+
+// TestVaultAuthMethods looks up console credentials in the dev-mode Vault
+// with the token and approle authentication methods
+func (s *IntegrationTestSuite) TestVaultAuthMethods() {
+	ctx := context.Background()
+	console := consoleFixtures["ssh-key"]
+
+	vaultAddr, err := s.vaultContainer.PortEndpoint(ctx, "8200/tcp", "http")
+	s.Require().NoError(err)
+	s.T().Setenv("VAULT_ADDR", vaultAddr)
+	s.T().Setenv("VAULT_TOKEN", "")
+	tempDir := s.T().TempDir()
+
+	requireCredentials := func(config creds.CredsConfig) {
+		passwords, err := creds.NewCredsService(config).GetPasswordsWithRetries(ctx, []string{console.nodeID}, 1, 0)
+		s.Require().NoError(err)
+		s.Require().Equal(console.username, passwords[console.nodeID].Username)
+	}
+
+	// Token read from a file
+	config := creds.DefaultCredsConfig()
+	config.VaultAuthMethod = creds.VaultAuthToken
+	config.VaultTokenFile = filepath.Join(tempDir, "token")
+	s.Require().NoError(os.WriteFile(config.VaultTokenFile, []byte("hms\n"), 0600))
+	requireCredentials(config)
+
+	// AppRole with a short token TTL, so the token expires between lookups
+	s.Require().NoError(s.vaultContainer.CopyToContainer(ctx, []byte(vaultAppRolePolicy), "/tmp/remote-console.hcl", 0644))
+	_, err = execVault(ctx, s.vaultContainer, "auth", "enable", "approle")
+	s.Require().NoError(err)
+	_, err = execVault(ctx, s.vaultContainer, "policy", "write", "remote-console", "/tmp/remote-console.hcl")
+	s.Require().NoError(err)
+	_, err = execVault(ctx, s.vaultContainer, "write", "auth/approle/role/remote-console", "token_policies=remote-console", "token_ttl=3s", "token_max_ttl=6s")
+	s.Require().NoError(err)
+	roleID, err := execVault(ctx, s.vaultContainer, "read", "-field=role_id", "auth/approle/role/remote-console/role-id")
+	s.Require().NoError(err)
+	secretID, err := execVault(ctx, s.vaultContainer, "write", "-f", "-field=secret_id", "auth/approle/role/remote-console/secret-id")
+	s.Require().NoError(err)
+
+	config = creds.DefaultCredsConfig()
+	config.VaultAuthMethod = creds.VaultAuthAppRole
+	config.VaultAppRoleID = roleID
+	config.VaultAppRoleSecretIDFile = filepath.Join(tempDir, "secret-id")
+	s.Require().NoError(os.WriteFile(config.VaultAppRoleSecretIDFile, []byte(secretID), 0600))
+	requireCredentials(config)
+	time.Sleep(4 * time.Second)
+	requireCredentials(config)
+}